@@ -16,6 +16,16 @@ func initRootCmd(
 	rootCmd.AddCommand(
 		txCommand(rootCmd),
 		keys.Commands(),
+		signercli.GetPolicyCommand(),
+		signercli.GetSequenceCommand(),
+		signercli.GetAuditCommand(),
+		signercli.GetAddressBookCommand(),
+		signercli.GetApproveCommand(),
+		signercli.GetReportCommand(),
+		signercli.GetAccountsCommand(),
+		signercli.GetEnvelopeCommand(),
+		signercli.GetWatchCommand(),
+		signercli.GetPSTCommand(),
 	)
 }
 
@@ -31,21 +41,30 @@ func txCommand(rootCmd *cobra.Command) *cobra.Command {
 			if err != nil {
 				return err
 			}
-			cmd.SetOut(signercli.NewFilterNullKeysJSON(cmd.OutOrStdout()))
+			signercli.FilterOutput(cmd)
 			return nil
 		},
 		PersistentPostRunE: func(cmd *cobra.Command, _ []string) error {
-			outputDoc, err := cmd.Flags().GetString(flags.FlagOutputDocument)
-			if err != nil {
-				return err
-			}
-			signercli.FilterNullJSONKeysFile(outputDoc)
-			return nil
+			return signercli.FilterOutputDocument(cmd)
 		},
 	}
 
 	cmd.AddCommand(
 		signercli.GetSignCommand(),
+		signercli.GetConvertCommand(),
+		signercli.GetSignBytesCommand(),
+		signercli.GetAttachSignatureCommand(),
+		signercli.GetEncodeCommand(),
+		signercli.GetDecodeCommand(),
+		signercli.GetHashCommand(),
+		signercli.GetReviewCommand(),
+		signercli.GetPrepareCommand(),
+		signercli.GetBroadcastCommand(),
+		signercli.GetExportQRCommand(),
+		signercli.GetImportQRCommand(),
+		signercli.GetSplitCommand(),
+		signercli.GetMergeCommand(),
+		signercli.GetEditCommand(),
 	)
 	cmd.PersistentFlags().String(flags.FlagChainID, "", "The network chain ID")
 
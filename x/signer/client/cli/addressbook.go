@@ -0,0 +1,379 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+)
+
+const (
+	flagTrust                  = "trust"
+	flagRequireKnownRecipients = "require-known-recipients"
+
+	// addressBookFile is the address book, in the config directory of the
+	// home.
+	addressBookFile = "addressbook.json"
+
+	trustTrusted   = "trusted"
+	trustKnown     = "known"
+	trustUntrusted = "untrusted"
+)
+
+var trustLevels = []string{trustTrusted, trustKnown, trustUntrusted}
+
+// AddressBookEntry labels an address.
+type AddressBookEntry struct {
+	Address string `json:"address"`
+	Label   string `json:"label"`
+	Trust   string `json:"trust"`
+}
+
+// AddressBook maps addresses to labels and trust levels. Addresses are
+// matched on their bytes, so that an entry matches the address whatever its
+// bech32 prefix, e.g. on every chain, or for the operator address of a
+// validator.
+type AddressBook struct {
+	Entries []AddressBookEntry `json:"entries"`
+}
+
+func addressBookPath(home string) string {
+	return filepath.Join(home, "config", addressBookFile)
+}
+
+// LoadAddressBook loads the address book from file. An empty address book
+// is returned if the file does not exist.
+func LoadAddressBook(file string) (*AddressBook, error) {
+	bz, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return &AddressBook{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var book AddressBook
+	if err := json.Unmarshal(bz, &book); err != nil {
+		return nil, fmt.Errorf("invalid address book %s: %w", file, err)
+	}
+	return &book, nil
+}
+
+// Save writes the address book to file.
+func (b *AddressBook) Save(file string) error {
+	bz, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(bz, '\n'), 0o600)
+}
+
+// addressKey returns the hex encoded bytes of a bech32 address.
+func addressKey(addr string) (string, error) {
+	_, bz, err := bech32.DecodeAndConvert(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid address %s: %w", addr, err)
+	}
+	return hex.EncodeToString(bz), nil
+}
+
+// Lookup returns the entry of addr, whatever its bech32 prefix.
+func (b *AddressBook) Lookup(addr string) (AddressBookEntry, bool) {
+	key, err := addressKey(addr)
+	if err != nil {
+		return AddressBookEntry{}, false
+	}
+	for _, entry := range b.Entries {
+		if entryKey, err := addressKey(entry.Address); err == nil && entryKey == key {
+			return entry, true
+		}
+	}
+	return AddressBookEntry{}, false
+}
+
+// IsKnown returns true if addr is in the address book and is not untrusted.
+func (b *AddressBook) IsKnown(addr string) bool {
+	entry, ok := b.Lookup(addr)
+	return ok && entry.Trust != trustUntrusted
+}
+
+// Set adds an entry, or replaces the entry of the same address.
+func (b *AddressBook) Set(entry AddressBookEntry) error {
+	if entry.Trust == "" {
+		entry.Trust = trustKnown
+	}
+	if !slices.Contains(trustLevels, entry.Trust) {
+		return fmt.Errorf("invalid trust level %q, expected one of %s", entry.Trust, strings.Join(trustLevels, ", "))
+	}
+	key, err := addressKey(entry.Address)
+	if err != nil {
+		return err
+	}
+	for i, e := range b.Entries {
+		if k, err := addressKey(e.Address); err == nil && k == key {
+			b.Entries[i] = entry
+			return nil
+		}
+	}
+	b.Entries = append(b.Entries, entry)
+	return nil
+}
+
+// Remove removes the entry of addr and returns false if there is none.
+func (b *AddressBook) Remove(addr string) bool {
+	key, err := addressKey(addr)
+	if err != nil {
+		return false
+	}
+	for i, e := range b.Entries {
+		if k, err := addressKey(e.Address); err == nil && k == key {
+			b.Entries = slices.Delete(b.Entries, i, i+1)
+			return true
+		}
+	}
+	return false
+}
+
+// Annotate returns addr followed by its label and trust level, or marked as
+// unknown.
+func (b *AddressBook) Annotate(addr string) string {
+	entry, ok := b.Lookup(addr)
+	if !ok {
+		return addr + " (unknown)"
+	}
+	return fmt.Sprintf("%s (%s, %s)", addr, entry.Label, entry.Trust)
+}
+
+// readAddressBookCSV reads address book entries from CSV records of
+// address, label and an optional trust level. A header line is skipped.
+func readAddressBookCSV(r io.Reader) ([]AddressBookEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []AddressBookEntry
+	for i, record := range records {
+		if i == 0 && strings.EqualFold(record[0], "address") {
+			continue
+		}
+		if len(record) < 2 || len(record) > 3 {
+			return nil, fmt.Errorf("line %d: expected address, label and optional trust level", i+1)
+		}
+		entry := AddressBookEntry{Address: record[0], Label: record[1]}
+		if len(record) == 3 {
+			entry.Trust = record[2]
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// checkRecipients warns about the recipients of the messages that are not
+// known in the address book, or refuses them with --require-known-recipients.
+func checkRecipients(cmd *cobra.Command, book *AddressBook, msgs []msgSummary) error {
+	requireKnown, err := cmd.Flags().GetBool(flagRequireKnownRecipients)
+	if err != nil {
+		return err
+	}
+
+	var unknown []string
+	for _, msg := range flattenMsgs(msgs) {
+		for _, recipient := range msg.Recipients {
+			if !book.IsKnown(recipient) && !slices.Contains(unknown, recipient) {
+				unknown = append(unknown, recipient)
+			}
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	if requireKnown {
+		return fmt.Errorf("recipients %s are not known in the address book", strings.Join(unknown, ", "))
+	}
+	if len(book.Entries) > 0 {
+		cmd.PrintErrf("WARNING: recipients %s are not known in the address book\n", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// checkAddressBook checks the recipients of the transaction against the
+// address book of the home directory.
+func checkAddressBook(clientCtx client.Context, cmd *cobra.Command, tx sdk.Tx) error {
+	book, err := LoadAddressBook(addressBookPath(clientCtx.HomeDir))
+	if err != nil {
+		return err
+	}
+	msgs, err := summarizeMsgs(clientCtx, tx)
+	if err != nil {
+		return err
+	}
+	return checkRecipients(cmd, book, msgs)
+}
+
+// GetAddressBookCommand returns the addressbook command.
+func GetAddressBookCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "addressbook",
+		Short: "Address book subcommands",
+		Long: `Manage the address book, config/addressbook.json in the home directory,
+which maps addresses to labels and trust levels (trusted, known or
+untrusted).
+
+Addresses are matched whatever their bech32 prefix. The address book is used
+to annotate the addresses of the transactions in tx review, and by tx sign to
+warn about recipients that are not known, or to refuse them with
+--require-known-recipients.
+`,
+		DisableFlagParsing:         false,
+		SuggestionsMinimumDistance: 2,
+		RunE:                       client.ValidateCmd,
+	}
+
+	cmd.AddCommand(
+		GetAddressBookAddCommand(),
+		GetAddressBookRemoveCommand(),
+		GetAddressBookListCommand(),
+		GetAddressBookImportCommand(),
+	)
+
+	return cmd
+}
+
+// updateAddressBook loads the address book of the home directory, applies
+// update and saves it.
+func updateAddressBook(cmd *cobra.Command, update func(book *AddressBook) error) error {
+	clientCtx := client.GetClientContextFromCmd(cmd)
+	file := addressBookPath(clientCtx.HomeDir)
+	book, err := LoadAddressBook(file)
+	if err != nil {
+		return err
+	}
+	if err := update(book); err != nil {
+		return err
+	}
+	return book.Save(file)
+}
+
+// GetAddressBookAddCommand returns the addressbook add command.
+func GetAddressBookAddCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add [address] [label]",
+		Short: "Add or update an address book entry",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			trust, err := cmd.Flags().GetString(flagTrust)
+			if err != nil {
+				return err
+			}
+			return updateAddressBook(cmd, func(book *AddressBook) error {
+				return book.Set(AddressBookEntry{Address: args[0], Label: args[1], Trust: trust})
+			})
+		},
+	}
+
+	cmd.Flags().String(flagTrust, trustKnown, "The trust level of the address (trusted|known|untrusted)")
+
+	return cmd
+}
+
+// GetAddressBookRemoveCommand returns the addressbook remove command.
+func GetAddressBookRemoveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove [address]",
+		Short: "Remove an address book entry",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return updateAddressBook(cmd, func(book *AddressBook) error {
+				if !book.Remove(args[0]) {
+					return fmt.Errorf("%s is not in the address book", args[0])
+				}
+				return nil
+			})
+		},
+	}
+}
+
+// GetAddressBookListCommand returns the addressbook list command.
+func GetAddressBookListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the address book entries",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			clientCtx := client.GetClientContextFromCmd(cmd)
+			book, err := LoadAddressBook(addressBookPath(clientCtx.HomeDir))
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ADDRESS\tLABEL\tTRUST")
+			for _, entry := range book.Entries {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", entry.Address, entry.Label, entry.Trust)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+// GetAddressBookImportCommand returns the addressbook import command.
+func GetAddressBookImportCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import [csv-file]",
+		Short: "Import address book entries from a CSV file",
+		Long: `Import address book entries from a CSV file with the address, the label and
+optionally the trust level of each entry, 'known' by default, e.g.:
+
+address,label,trust
+cosmos1...,treasury,trusted
+cosmosvaloper1...,validator A
+
+Existing entries for the same addresses are replaced.
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			entries, err := readAddressBookCSV(f)
+			if err != nil {
+				return fmt.Errorf("%s: %w", args[0], err)
+			}
+
+			err = updateAddressBook(cmd, func(book *AddressBook) error {
+				for _, entry := range entries {
+					if err := book.Set(entry); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			cmd.Printf("imported %d entries\n", len(entries))
+			return nil
+		},
+	}
+}
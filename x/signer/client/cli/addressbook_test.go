@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+)
+
+// writeTestAddressBook writes the address book of entries to the home
+// directory of clientCtx.
+func writeTestAddressBook(t *testing.T, clientCtx client.Context, entries ...AddressBookEntry) {
+	t.Helper()
+	book := &AddressBook{}
+	for _, entry := range entries {
+		if err := book.Set(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := book.Save(addressBookPath(clientCtx.HomeDir)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAddressBook(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	bob := newTestKey(t, clientCtx, "bob")
+	carol := newTestKey(t, clientCtx, "carol")
+	osmoBob, err := bech32.ConvertAndEncode("osmo", bob)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	book := &AddressBook{}
+	if err := book.Set(AddressBookEntry{Address: bob.String(), Label: "Bob"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := book.Set(AddressBookEntry{Address: carol.String(), Label: "Carol", Trust: trustUntrusted}); err != nil {
+		t.Fatal(err)
+	}
+	if err := book.Set(AddressBookEntry{Address: carol.String(), Label: "Carol", Trust: "maybe"}); err == nil {
+		t.Fatal("expected an invalid trust level error")
+	}
+
+	tests := []struct {
+		addr          string
+		wantKnown     bool
+		wantAnnotated string
+	}{
+		{addr: bob.String(), wantKnown: true, wantAnnotated: bob.String() + " (Bob, known)"},
+		{addr: osmoBob, wantKnown: true, wantAnnotated: osmoBob + " (Bob, known)"},
+		{addr: carol.String(), wantAnnotated: carol.String() + " (Carol, untrusted)"},
+		{addr: "invalid", wantAnnotated: "invalid (unknown)"},
+	}
+	for _, tt := range tests {
+		if got := book.IsKnown(tt.addr); got != tt.wantKnown {
+			t.Fatalf("got known %t for %s, want %t", got, tt.addr, tt.wantKnown)
+		}
+		if got := book.Annotate(tt.addr); got != tt.wantAnnotated {
+			t.Fatalf("got %q, want %q", got, tt.wantAnnotated)
+		}
+	}
+
+	if !book.Remove(osmoBob) || book.IsKnown(bob.String()) || book.Remove(osmoBob) {
+		t.Fatalf("expected %s to be removed once", osmoBob)
+	}
+}
+
+func TestSignKnownRecipients(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	bob := newTestKey(t, clientCtx, "bob")
+	carol := newTestKey(t, clientCtx, "carol")
+
+	tests := []struct {
+		name        string
+		to          sdk.AccAddress
+		args        []string
+		wantWarning bool
+		wantErr     string
+	}{
+		{name: "known recipient", to: bob},
+		{name: "untrusted recipient", to: carol, wantWarning: true},
+		{name: "unknown recipient", to: alice, wantWarning: true},
+		{name: "known recipient required", to: bob, args: []string{"--" + flagRequireKnownRecipients}},
+		{name: "untrusted recipient refused", to: carol, args: []string{"--" + flagRequireKnownRecipients}, wantErr: "are not known in the address book"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientCtx := clientCtx.WithHomeDir(t.TempDir())
+			writeTestAddressBook(t, clientCtx,
+				AddressBookEntry{Address: bob.String(), Label: "Bob", Trust: trustTrusted},
+				AddressBookEntry{Address: carol.String(), Label: "Carol", Trust: trustUntrusted})
+			file := writeTestTx(t, clientCtx, newTestTx(t, clientCtx, nil, 200000, newTestSend(alice, tt.to, 10)))
+
+			cmd := GetSignCommand()
+			stderr := &bytes.Buffer{}
+			cmd.SetErr(stderr)
+			args := append([]string{file, "--" + flags.FlagOffline, "--from", "alice", "-a", "1", "-s", "5"}, tt.args...)
+			_, err := runTestCommand(t, clientCtx, cmd, args...)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			case tt.wantErr != "":
+				return
+			}
+			if got := strings.Contains(stderr.String(), "WARNING: recipients "+tt.to.String()); got != tt.wantWarning {
+				t.Fatalf("got warning %t, want %t: %q", got, tt.wantWarning, stderr)
+			}
+		})
+	}
+}
+
+func TestReviewCommand(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	bob := newTestKey(t, clientCtx, "bob")
+	writeTestAddressBook(t, clientCtx, AddressBookEntry{Address: bob.String(), Label: "Bob", Trust: trustTrusted})
+	file := writeTestTx(t, clientCtx, newTestTx(t, clientCtx, sdk.NewCoins(sdk.NewInt64Coin("uatom", 10)), 200000, newTestSend(alice, bob, 10)))
+
+	bz, err := runTestCommand(t, clientCtx, GetReviewCommand(), file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Signers:        " + alice.String() + " (unknown)",
+		"Fee:            10uatom (gas 200000)",
+		"from: " + alice.String() + " (unknown)",
+		"to:   " + bob.String() + " (Bob, trusted)",
+		"amount: 10uatom",
+	} {
+		if !strings.Contains(string(bz), want) {
+			t.Fatalf("review %q does not contain %q", bz, want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	"github.com/cosmos/cosmos-sdk/x/auth/migrations/legacytx"
+)
+
+// legacyStdTxName is the amino name of the legacy StdTx.
+const legacyStdTxName = "cosmos-sdk/StdTx"
+
+// isLegacyAminoTx returns true if bz is a legacy amino JSON StdTx, either
+// wrapped in its amino `{"type": ..., "value": ...}` envelope or not.
+func isLegacyAminoTx(bz []byte) bool {
+	var doc struct {
+		Type string          `json:"type"`
+		Msg  json.RawMessage `json:"msg"`
+	}
+	if err := json.Unmarshal(bz, &doc); err != nil {
+		return false
+	}
+	return doc.Type == legacyStdTxName || doc.Msg != nil
+}
+
+// decodeLegacyAminoTx decodes a legacy amino JSON StdTx and converts it to a
+// protobuf transaction. Since amino names do not tell which plugin provides
+// them, all the plugins found in pluginsDir are registered.
+func decodeLegacyAminoTx(clientCtx client.Context, pluginsDir string, bz []byte) (sdk.Tx, error) {
+	if err := RegisterAllTypes(clientCtx, pluginsDir); err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(bz, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Type == "" {
+		bz = []byte(fmt.Sprintf(`{"type":%q,"value":%s}`, legacyStdTxName, bz))
+	}
+
+	var stdTx legacytx.StdTx
+	if err := clientCtx.LegacyAmino.UnmarshalJSON(bz, &stdTx); err != nil {
+		return nil, err
+	}
+	// amino signatures do not carry the signer sequence, which is part of the
+	// protobuf signer infos, so they cannot be converted.
+	if len(stdTx.Signatures) > 0 {
+		return nil, errors.New("cannot convert a signed amino transaction: signer sequences are not part of the amino encoding")
+	}
+
+	txBuilder := clientCtx.TxConfig.NewTxBuilder()
+	if err := txBuilder.SetMsgs(stdTx.Msgs...); err != nil {
+		return nil, err
+	}
+	txBuilder.SetMemo(stdTx.Memo)
+	txBuilder.SetTimeoutHeight(stdTx.TimeoutHeight)
+	txBuilder.SetFeeAmount(stdTx.Fee.Amount)
+	txBuilder.SetGasLimit(stdTx.Fee.Gas)
+	if stdTx.Fee.Payer != "" {
+		payer, err := sdk.AccAddressFromBech32(stdTx.Fee.Payer)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fee payer: %w", err)
+		}
+		txBuilder.SetFeePayer(payer)
+	}
+	if stdTx.Fee.Granter != "" {
+		granter, err := sdk.AccAddressFromBech32(stdTx.Fee.Granter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fee granter: %w", err)
+		}
+		txBuilder.SetFeeGranter(granter)
+	}
+
+	return txBuilder.GetTx(), nil
+}
+
+// encodeLegacyAminoTx encodes a protobuf transaction as a legacy amino JSON
+// StdTx. Only SIGN_MODE_LEGACY_AMINO_JSON signatures can be carried over.
+func encodeLegacyAminoTx(clientCtx client.Context, tx sdk.Tx) ([]byte, error) {
+	txBuilder, err := clientCtx.TxConfig.WrapTxBuilder(tx)
+	if err != nil {
+		return nil, err
+	}
+	theTx := txBuilder.GetTx()
+
+	sigs, err := theTx.GetSignaturesV2()
+	if err != nil {
+		return nil, err
+	}
+	stdSigs := make([]legacytx.StdSignature, 0, len(sigs))
+	for _, sig := range sigs {
+		if single, ok := sig.Data.(*signing.SingleSignatureData); ok && single.SignMode != signing.SignMode_SIGN_MODE_LEGACY_AMINO_JSON {
+			return nil, fmt.Errorf("cannot convert a %s signature to amino", single.SignMode)
+		}
+		stdSig, err := legacytx.SignatureV2ToStdSignature(clientCtx.LegacyAmino, sig)
+		if err != nil {
+			return nil, err
+		}
+		stdSigs = append(stdSigs, stdSig)
+	}
+
+	protoTx, err := getProtoTx(theTx)
+	if err != nil {
+		return nil, err
+	}
+	fee := legacytx.NewStdFee(theTx.GetGas(), theTx.GetFee())
+	fee.Payer = protoTx.AuthInfo.Fee.Payer
+	fee.Granter = protoTx.AuthInfo.Fee.Granter
+
+	stdTx := legacytx.StdTx{
+		Msgs:          theTx.GetMsgs(),
+		Fee:           fee,
+		Signatures:    stdSigs,
+		Memo:          theTx.GetMemo(),
+		TimeoutHeight: theTx.GetTimeoutHeight(),
+	}
+	return clientCtx.LegacyAmino.MarshalJSON(stdTx)
+}
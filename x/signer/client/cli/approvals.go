@@ -0,0 +1,324 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+)
+
+const (
+	flagApproval = "approval"
+	flagSigner   = "signer"
+
+	// approvalsFile is the approval requirements, in the config directory
+	// of the home.
+	approvalsFile = "approvals.json"
+)
+
+// ApprovalRequirement requires Threshold approvals out of the Approvers
+// addresses before the Keys may sign.
+type ApprovalRequirement struct {
+	Keys      []string `json:"keys"` // key names or addresses
+	Threshold int      `json:"threshold"`
+	Approvers []string `json:"approvers"`
+}
+
+// ApprovalsConfig is the approval requirements of the protected keys.
+type ApprovalsConfig struct {
+	Requirements []ApprovalRequirement `json:"requirements"`
+}
+
+// Approval is the signature of an approver over the hash of the sign bytes
+// of a signer.
+type Approval struct {
+	ChainID       string          `json:"chain_id"`
+	Signer        string          `json:"signer"`
+	SignBytesHash string          `json:"sign_bytes_hash"`
+	Approver      string          `json:"approver"`
+	PubKey        json.RawMessage `json:"pub_key"`
+	Signature     []byte          `json:"signature"`
+}
+
+// LoadApprovalsConfig loads the approval requirements from file. No
+// requirements are returned if the file does not exist.
+func LoadApprovalsConfig(file string) (*ApprovalsConfig, error) {
+	bz, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return &ApprovalsConfig{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var config ApprovalsConfig
+	if err := json.Unmarshal(bz, &config); err != nil {
+		return nil, fmt.Errorf("invalid approvals config %s: %w", file, err)
+	}
+	for i, req := range config.Requirements {
+		if req.Threshold <= 0 || req.Threshold > len(req.Approvers) {
+			return nil, fmt.Errorf("invalid approvals config %s: requirement #%d: threshold %d out of %d approvers", file, i, req.Threshold, len(req.Approvers))
+		}
+	}
+	return &config, nil
+}
+
+// requirement returns the approval requirement of a key, if any.
+func (c *ApprovalsConfig) requirement(key signingKey) (ApprovalRequirement, bool) {
+	for _, req := range c.Requirements {
+		if slices.Contains(req.Keys, key.Name) || slices.Contains(req.Keys, key.Address.String()) {
+			return req, true
+		}
+	}
+	return ApprovalRequirement{}, false
+}
+
+// readApprovals reads the approvals of the --approval files, each holding
+// an approval or a list of approvals.
+func readApprovals(cmd *cobra.Command) ([]Approval, error) {
+	files, err := cmd.Flags().GetStringArray(flagApproval)
+	if err != nil {
+		return nil, err
+	}
+	var approvals []Approval
+	for _, file := range files {
+		bz, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		bz = bytes.TrimSpace(bz)
+		if bytes.HasPrefix(bz, []byte("[")) {
+			var list []Approval
+			if err := json.Unmarshal(bz, &list); err != nil {
+				return nil, fmt.Errorf("invalid approvals %s: %w", file, err)
+			}
+			approvals = append(approvals, list...)
+			continue
+		}
+		var approval Approval
+		if err := json.Unmarshal(bz, &approval); err != nil {
+			return nil, fmt.Errorf("invalid approval %s: %w", file, err)
+		}
+		approvals = append(approvals, approval)
+	}
+	return approvals, nil
+}
+
+// countApprovals returns the distinct configured approvers with a valid
+// approval of the sign bytes, and the reasons the invalid approvals of the
+// sign bytes are skipped for.
+func countApprovals(clientCtx client.Context, req ApprovalRequirement, approvals []Approval, chainID, signer string, signBytes []byte) (approvers, skipped []string) {
+	hash := signBytesHash(signBytes)
+	for _, approval := range approvals {
+		if approval.ChainID != chainID || approval.Signer != signer || approval.SignBytesHash != hash {
+			continue
+		}
+		if !slices.Contains(req.Approvers, approval.Approver) || slices.Contains(approvers, approval.Approver) {
+			continue
+		}
+		if err := verifyApproval(clientCtx, approval); err != nil {
+			skipped = append(skipped, fmt.Sprintf("invalid approval of %s: %v", approval.Approver, err))
+			continue
+		}
+		approvers = append(approvers, approval.Approver)
+	}
+	return approvers, skipped
+}
+
+// verifyApproval verifies the signature of the approver over the hash of the
+// sign bytes.
+func verifyApproval(clientCtx client.Context, approval Approval) error {
+	var pubKey cryptotypes.PubKey
+	if err := clientCtx.Codec.UnmarshalInterfaceJSON(approval.PubKey, &pubKey); err != nil {
+		return err
+	}
+	if sdk.AccAddress(pubKey.Address()).String() != approval.Approver {
+		return errors.New("public key does not match the approver")
+	}
+	hashBytes, err := hex.DecodeString(approval.SignBytesHash)
+	if err != nil {
+		return err
+	}
+	if !pubKey.VerifySignature(hashBytes, approval.Signature) {
+		return errors.New("signature verification failed")
+	}
+	return nil
+}
+
+// checkApprovals refuses to sign with protected keys that do not carry
+// enough approvals of their sign bytes. Invalid approvals are reported and
+// not counted.
+func checkApprovals(clientCtx client.Context, cmd *cobra.Command, sigs []journalSignature, signers []localSigner, keys []signingKey) error {
+	config, err := LoadApprovalsConfig(filepath.Join(clientCtx.HomeDir, "config", approvalsFile))
+	if err != nil {
+		return err
+	}
+	if len(config.Requirements) == 0 {
+		return nil
+	}
+	approvals, err := readApprovals(cmd)
+	if err != nil {
+		return err
+	}
+
+	for i, sig := range sigs {
+		// the protected key is either the signer, or the multisig account
+		key := signingKey{Name: signers[i].Name, Address: signers[i].Address}
+		req, ok := config.requirement(key)
+		if !ok {
+			key = keys[min(i, len(keys)-1)]
+			if req, ok = config.requirement(key); !ok {
+				continue
+			}
+		}
+		approvers, skipped := countApprovals(clientCtx, req, approvals, clientCtx.ChainID, sig.Signer, sig.SignBytes)
+		for _, reason := range skipped {
+			cmd.PrintErrf("WARNING: skipping an approval for key %s: %s\n", key.Name, reason)
+		}
+		if len(approvers) < req.Threshold {
+			return fmt.Errorf("key %s requires %d approvals out of %s, got %d (sign bytes %s); use the approve command and --%s",
+				key.Name, req.Threshold, strings.Join(req.Approvers, ", "), len(approvers), signBytesHash(sig.SignBytes), flagApproval)
+		}
+	}
+	return nil
+}
+
+// GetApproveCommand returns the approve command.
+func GetApproveCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "approve [file]",
+		Short: "Approve the signature of a protected key over a transaction",
+		Long: `Produce an approval, with the --from approver key, for the signature of the
+transaction in [file] by a protected key. The approval is a signature over
+the SHA-256 hash of the sign bytes of the protected key, which is identified
+with --pubkey, or with --signer if it is in the keyring, and the same
+--account-number, --sequence and --sign-mode flags as with tx sign.
+
+tx sign refuses to sign with a protected key unless the approvals passed
+with --approval are from enough of its approvers. The approval requirements
+are configured in config/approvals.json in the home directory:
+
+{
+  "requirements": [
+    {
+      "keys": ["treasury"],
+      "threshold": 2,
+      "approvers": ["cosmos1...", "cosmos1...", "cosmos1..."]
+    }
+  ]
+}
+
+Since the sign bytes of SIGN_MODE_DIRECT cover the signer infos of all the
+signers, approvals of transactions with several signers are best given for
+--sign-mode amino-json.
+
+The approval is signed over a hash, which Ledger devices do not sign, as they
+only sign transactions in SIGN_MODE_LEGACY_AMINO_JSON: the approver key must
+be a local key of the keyring.
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientTxContext(cmd)
+			if err != nil {
+				return err
+			}
+			approverName := clientCtx.FromName
+			signerName, err := cmd.Flags().GetString(flagSigner)
+			if err != nil {
+				return err
+			}
+			pubKey, err := cmd.Flags().GetString(flagPubKey)
+			if err != nil {
+				return err
+			}
+			if (signerName == "") == (pubKey == "") {
+				return fmt.Errorf("either --%s or --%s must be set", flagSigner, flagPubKey)
+			}
+			record, err := clientCtx.Keyring.Key(approverName)
+			if err != nil {
+				return fmt.Errorf("error getting account from keybase: %w", err)
+			}
+			if record.GetType() != keyring.TypeLocal {
+				return fmt.Errorf("key %s of type %s cannot sign approvals, only local keys can", approverName, record.GetType())
+			}
+			overwrite, err := cmd.Flags().GetBool(flagOverwrite)
+			if err != nil {
+				return err
+			}
+
+			// the protected key is the signer the sign bytes are computed for
+			txBuilder, signer, err := readTxAndSigner(clientCtx.WithFromName(signerName), cmd, args[0])
+			if err != nil {
+				return err
+			}
+			signBytes, err := getSignBytes(cmd.Context(), clientCtx, txBuilder, signer, overwrite)
+			if err != nil {
+				return err
+			}
+			hash := signBytesHash(signBytes)
+			hashBytes, err := hex.DecodeString(hash)
+			if err != nil {
+				return err
+			}
+
+			sigBytes, approverPubKey, err := clientCtx.Keyring.Sign(approverName, hashBytes, signing.SignMode_SIGN_MODE_DIRECT)
+			if err != nil {
+				return err
+			}
+			approver := sdk.AccAddress(approverPubKey.Address()).String()
+			pubKeyJSON, err := clientCtx.Codec.MarshalInterfaceJSON(approverPubKey)
+			if err != nil {
+				return err
+			}
+
+			signerAddr := sdk.AccAddress(signer.PubKey.Address()).String()
+			out, err := json.MarshalIndent(Approval{
+				ChainID:       signer.ChainID,
+				Signer:        signerAddr,
+				SignBytesHash: hash,
+				Approver:      approver,
+				PubKey:        pubKeyJSON,
+				Signature:     sigBytes,
+			}, "", "  ")
+			if err != nil {
+				return err
+			}
+
+			records, err := newAuditRecords(clientCtx.WithChainID(signer.ChainID), auditOperationApprove, cmd.CommandPath(), txBuilder.GetTx(), []journalSignature{{
+				Signer:        approver,
+				Account:       signerAddr,
+				AccountNumber: signer.AccountNumber,
+				Sequence:      signer.Sequence,
+				SignMode:      signer.SignMode,
+				SignBytes:     signBytes,
+			}}, nil)
+			if err != nil {
+				return err
+			}
+			if err := appendAuditRecords(clientCtx.HomeDir, records); err != nil {
+				return err
+			}
+
+			return printOutput(cmd, out)
+		},
+	}
+
+	cmd.Flags().String(flagSigner, "", "Name of the protected key in the keyring, if not using --pubkey")
+	addSignerFlags(cmd)
+	_ = cmd.MarkFlagRequired(flags.FlagFrom)
+
+	return cmd
+}
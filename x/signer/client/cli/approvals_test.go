@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+)
+
+// newTestApproval returns the approval of approver for the sign bytes of
+// signer.
+func newTestApproval(t *testing.T, clientCtx client.Context, approver string, signer sdk.AccAddress, signBytes []byte) Approval {
+	t.Helper()
+	hash := signBytesHash(signBytes)
+	hashBytes, err := hex.DecodeString(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, pubKey, err := clientCtx.Keyring.Sign(approver, hashBytes, signing.SignMode_SIGN_MODE_DIRECT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubKeyJSON, err := clientCtx.Codec.MarshalInterfaceJSON(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Approval{
+		ChainID:       clientCtx.ChainID,
+		Signer:        signer.String(),
+		SignBytesHash: hash,
+		Approver:      sdk.AccAddress(pubKey.Address()).String(),
+		PubKey:        pubKeyJSON,
+		Signature:     sig,
+	}
+}
+
+func TestCountApprovals(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	treasury := newTestKey(t, clientCtx, "treasury")
+	a := newTestKey(t, clientCtx, "a")
+	b := newTestKey(t, clientCtx, "b")
+	c := newTestKey(t, clientCtx, "c")
+	newTestKey(t, clientCtx, "outsider")
+	req := ApprovalRequirement{Keys: []string{"treasury"}, Threshold: 2, Approvers: []string{a.String(), b.String(), c.String()}}
+	signBytes := []byte("sign bytes")
+
+	approvalA := newTestApproval(t, clientCtx, "a", treasury, signBytes)
+	approvalB := newTestApproval(t, clientCtx, "b", treasury, signBytes)
+	stale := newTestApproval(t, clientCtx, "c", treasury, []byte("other sign bytes"))
+	forged := newTestApproval(t, clientCtx, "c", treasury, signBytes)
+	forged.Signature = approvalA.Signature
+	mismatch := newTestApproval(t, clientCtx, "c", treasury, signBytes)
+	mismatch.PubKey = approvalA.PubKey
+	otherChain := newTestApproval(t, clientCtx, "c", treasury, signBytes)
+	otherChain.ChainID = "other-chain"
+
+	tests := []struct {
+		name      string
+		approvals []Approval
+		approvers int
+		skipped   int
+	}{
+		{name: "no approvals"},
+		{name: "quorum", approvals: []Approval{approvalA, approvalB}, approvers: 2},
+		{name: "duplicate approvals", approvals: []Approval{approvalA, approvalA}, approvers: 1},
+		{name: "approval of other sign bytes", approvals: []Approval{approvalA, stale}, approvers: 1},
+		{name: "approval for another chain", approvals: []Approval{approvalA, otherChain}, approvers: 1},
+		{name: "approval of an outsider", approvals: []Approval{approvalA, newTestApproval(t, clientCtx, "outsider", treasury, signBytes)}, approvers: 1},
+		{name: "forged approval skipped", approvals: []Approval{forged, approvalA, approvalB}, approvers: 2, skipped: 1},
+		{name: "public key of another approver skipped", approvals: []Approval{mismatch, approvalA}, approvers: 1, skipped: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			approvers, skipped := countApprovals(clientCtx, req, tt.approvals, testChainID, treasury.String(), signBytes)
+			if len(approvers) != tt.approvers || len(skipped) != tt.skipped {
+				t.Fatalf("got approvers %v and skipped %v, want %d and %d", approvers, skipped, tt.approvers, tt.skipped)
+			}
+		})
+	}
+}
+
+func TestApproveCommand(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	treasury := newTestKey(t, clientCtx, "treasury")
+	approver := newTestKey(t, clientCtx, "approver")
+	if _, err := clientCtx.Keyring.SaveOfflineKey("offline", testPubKey(t, clientCtx, "approver")); err != nil {
+		t.Fatal(err)
+	}
+	file := writeTestTx(t, clientCtx, newTestTx(t, clientCtx, nil, 200000, newTestSend(treasury, approver, 10)))
+
+	tests := []struct {
+		name    string
+		from    string
+		wantErr string
+	}{
+		{name: "local approver key", from: "approver"},
+		{name: "offline approver key", from: "offline", wantErr: "key offline of type offline cannot sign approvals, only local keys can"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bz, err := runTestCommand(t, clientCtx, GetApproveCommand(), file, "--"+flags.FlagFrom, tt.from, "--"+flagSigner, "treasury",
+				"--"+flags.FlagAccountNumber, "3", "--"+flags.FlagSequence, "7")
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			case tt.wantErr != "":
+				return
+			}
+			var approval Approval
+			if err := json.Unmarshal(bz, &approval); err != nil {
+				t.Fatal(err)
+			}
+			if approval.Approver != approver.String() || approval.Signer != treasury.String() {
+				t.Fatalf("got approval of %s for %s", approval.Approver, approval.Signer)
+			}
+		})
+	}
+}
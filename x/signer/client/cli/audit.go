@@ -0,0 +1,418 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const (
+	flagFormat = "format"
+
+	formatJSON = "json"
+	formatCSV  = "csv"
+
+	// auditLogFile is the audit log, in the data directory of the home.
+	auditLogFile = "audit.jsonl"
+)
+
+const (
+	auditOperationSign    = "sign"
+	auditOperationDenied  = "denied"
+	auditOperationApprove = "approve"
+)
+
+// AuditRecord describes a signing operation, or a signature refused by the
+// signing policy.
+type AuditRecord struct {
+	Time          time.Time       `json:"time"`
+	Operation     string          `json:"operation"`
+	Command       string          `json:"command"`
+	ChainID       string          `json:"chain_id"`
+	Signer        string          `json:"signer"`
+	Account       string          `json:"account"`
+	AccountNumber uint64          `json:"account_number,string"`
+	Sequence      uint64          `json:"sequence,string"`
+	SignMode      string          `json:"sign_mode,omitempty"`
+	TxHash        string          `json:"tx_hash"`
+	SignBytesHash string          `json:"sign_bytes_hash,omitempty"`
+	Msgs          []msgSummary    `json:"msgs"`
+	Fee           sdk.Coins       `json:"fee,omitempty"`
+	Policy        *PolicyDecision `json:"policy,omitempty"`
+}
+
+// operation returns the operation of the record. The records written before
+// the operation was recorded are told apart by their command and policy
+// decision: approvals were made by the approve command, and denials carry a
+// decision which is not allowed.
+func (r AuditRecord) operation() string {
+	switch {
+	case r.Operation != "":
+		return r.Operation
+	case r.Policy != nil && !r.Policy.Allowed:
+		return auditOperationDenied
+	case r.Command == "approve" || strings.HasSuffix(r.Command, " approve"):
+		return auditOperationApprove
+	default:
+		return auditOperationSign
+	}
+}
+
+// auditEntry is a line of the audit log. The hash of an entry covers the
+// hash of the previous entry and the record, as written in the log, so that
+// any change to a record, or the removal of an entry, breaks the chain.
+type auditEntry struct {
+	Index    uint64          `json:"index,string"`
+	PrevHash string          `json:"prev_hash"`
+	Hash     string          `json:"hash"`
+	Record   json.RawMessage `json:"record"`
+}
+
+func (e auditEntry) computeHash() string {
+	h := sha256.New()
+	h.Write([]byte(strconv.FormatUint(e.Index, 10)))
+	h.Write([]byte(e.PrevHash))
+	h.Write(e.Record)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func auditLogPath(home string) string {
+	return filepath.Join(home, "data", auditLogFile)
+}
+
+// readAuditLog reads the entries of the audit log. No entries are returned
+// if the log does not exist.
+func readAuditLog(file string) ([]auditEntry, error) {
+	f, err := os.Open(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []auditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var entry auditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", file, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// appendAuditRecords appends the records to the audit log of the home
+// directory, chained to the last entry of the log.
+func appendAuditRecords(home string, records []AuditRecord) error {
+	file := auditLogPath(home)
+	entries, err := readAuditLog(file)
+	if err != nil {
+		return err
+	}
+	var last auditEntry
+	if len(entries) > 0 {
+		last = entries[len(entries)-1]
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var buf bytes.Buffer
+	for i, record := range records {
+		bz, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		entry := auditEntry{Index: uint64(len(entries) + i), PrevHash: last.Hash, Record: bz}
+		entry.Hash = entry.computeHash()
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+		last = entry
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// verifyAuditLog checks the hash chain of the entries.
+func verifyAuditLog(entries []auditEntry) error {
+	var prevHash string
+	for i, entry := range entries {
+		if entry.Index != uint64(i) {
+			return fmt.Errorf("entry %d: unexpected index %d, entries were removed or reordered", i, entry.Index)
+		}
+		if entry.PrevHash != prevHash {
+			return fmt.Errorf("entry %d: previous hash %s does not match %s, the chain is broken", i, entry.PrevHash, prevHash)
+		}
+		if hash := entry.computeHash(); entry.Hash != hash {
+			return fmt.Errorf("entry %d: hash %s does not match the record hash %s, the record was modified", i, entry.Hash, hash)
+		}
+		prevHash = entry.Hash
+	}
+	return nil
+}
+
+// newAuditRecords returns the audit records of the signatures of a
+// transaction.
+func newAuditRecords(clientCtx client.Context, operation, command string, tx sdk.Tx, sigs []journalSignature, decision *PolicyDecision) ([]AuditRecord, error) {
+	msgs, err := summarizeMsgs(clientCtx, tx)
+	if err != nil {
+		return nil, err
+	}
+	protoTx, err := getProtoTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	txBytes, err := clientCtx.TxConfig.TxEncoder()(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	records := make([]AuditRecord, len(sigs))
+	for i, sig := range sigs {
+		records[i] = AuditRecord{
+			Time:          now,
+			Operation:     operation,
+			Command:       command,
+			ChainID:       clientCtx.ChainID,
+			Signer:        sig.Signer,
+			Account:       sig.Account,
+			AccountNumber: sig.AccountNumber,
+			Sequence:      sig.Sequence,
+			SignMode:      sig.SignMode.String(),
+			TxHash:        txHash(txBytes),
+			Msgs:          msgs,
+			Fee:           protoTx.AuthInfo.GetFee().GetAmount(),
+			Policy:        decision,
+		}
+		if sig.SignBytes != nil {
+			records[i].SignBytesHash = signBytesHash(sig.SignBytes)
+		}
+	}
+	return records, nil
+}
+
+// auditDenial records the keys a transaction was not signed with because
+// of the signing policy.
+func auditDenial(clientCtx client.Context, command string, tx sdk.Tx, signers []localSigner, keys []signingKey, decision PolicyDecision) error {
+	sigs := make([]journalSignature, len(signers))
+	for i, signer := range signers {
+		sigs[i] = journalSignature{
+			Signer:        signer.Address.String(),
+			Account:       keys[min(i, len(keys)-1)].Address.String(),
+			AccountNumber: signer.AccountNumber,
+			Sequence:      signer.Sequence,
+		}
+	}
+	records, err := newAuditRecords(clientCtx, auditOperationDenied, command, tx, sigs, &decision)
+	if err != nil {
+		return err
+	}
+	for i := range records {
+		records[i].SignMode = ""
+	}
+	return appendAuditRecords(clientCtx.HomeDir, records)
+}
+
+// GetAuditCommand returns the audit command.
+func GetAuditCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Audit log subcommands",
+		Long: `Every signature made by tx sign and tx attach-signature, as well as every
+signature refused by the signing policy, is appended to the audit log in the
+data directory of the home, with the transaction hash, the sign bytes hash,
+the chain-id, the signer, the sign mode, a summary of the messages and the
+policy decision.
+
+Each entry is chained to the previous one by its hash, so that modified,
+removed or reordered entries are detected by 'audit verify'. Keep the last
+hash printed by 'audit verify' outside of the signer to also detect a
+rewrite of the whole log.
+`,
+		DisableFlagParsing:         false,
+		SuggestionsMinimumDistance: 2,
+		RunE:                       client.ValidateCmd,
+	}
+
+	cmd.AddCommand(
+		GetAuditVerifyCommand(),
+		GetAuditExportCommand(),
+	)
+
+	return cmd
+}
+
+// GetAuditVerifyCommand returns the audit verify command.
+func GetAuditVerifyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify the hash chain of the audit log",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			clientCtx := client.GetClientContextFromCmd(cmd)
+
+			entries, err := readAuditLog(auditLogPath(clientCtx.HomeDir))
+			if err != nil {
+				return err
+			}
+			if err := verifyAuditLog(entries); err != nil {
+				return fmt.Errorf("audit log verification failed: %w", err)
+			}
+			if len(entries) == 0 {
+				cmd.Println("audit log is empty")
+				return nil
+			}
+			cmd.Printf("audit log verified: %d entries, last hash %s\n", len(entries), entries[len(entries)-1].Hash)
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// GetAuditExportCommand returns the audit export command.
+func GetAuditExportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the audit log records to JSON or CSV",
+		Long: `Export the records of the audit log, after verifying its hash chain, to JSON
+or CSV. The export is written to --output-document, or printed.
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			clientCtx := client.GetClientContextFromCmd(cmd)
+
+			format, err := cmd.Flags().GetString(flagFormat)
+			if err != nil {
+				return err
+			}
+			entries, err := readAuditLog(auditLogPath(clientCtx.HomeDir))
+			if err != nil {
+				return err
+			}
+			if err := verifyAuditLog(entries); err != nil {
+				return fmt.Errorf("audit log verification failed: %w", err)
+			}
+
+			var bz []byte
+			switch format {
+			case formatJSON:
+				bz, err = exportAuditJSON(entries)
+			case formatCSV:
+				bz, err = exportAuditCSV(entries)
+			default:
+				return fmt.Errorf("invalid --%s %q, expected %s or %s", flagFormat, format, formatJSON, formatCSV)
+			}
+			if err != nil {
+				return err
+			}
+			return printOutput(cmd, bytes.TrimSuffix(bz, []byte("\n")))
+		},
+	}
+
+	cmd.Flags().String(flagFormat, formatJSON, "The export format (json|csv)")
+	cmd.Flags().String(flags.FlagOutputDocument, "", "The document will be written to the given file instead of STDOUT")
+
+	return cmd
+}
+
+func exportAuditJSON(entries []auditEntry) ([]byte, error) {
+	type exportedEntry struct {
+		Index  uint64          `json:"index,string"`
+		Hash   string          `json:"hash"`
+		Record json.RawMessage `json:"record"`
+	}
+	exported := make([]exportedEntry, len(entries))
+	for i, entry := range entries {
+		exported[i] = exportedEntry{Index: entry.Index, Hash: entry.Hash, Record: entry.Record}
+	}
+	return json.MarshalIndent(exported, "", "  ")
+}
+
+func exportAuditCSV(entries []auditEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{
+		"index", "time", "operation", "command", "chain_id", "signer", "account", "account_number", "sequence",
+		"sign_mode", "tx_hash", "sign_bytes_hash", "msg_types", "amount", "recipients", "policy", "hash",
+	})
+	for _, entry := range entries {
+		var record AuditRecord
+		if err := json.Unmarshal(entry.Record, &record); err != nil {
+			return nil, fmt.Errorf("entry %d: %w", entry.Index, err)
+		}
+		var (
+			types, recipients []string
+			amount            sdk.Coins
+		)
+		for _, msg := range flattenMsgs(record.Msgs) {
+			types = append(types, msg.TypeURL)
+			recipients = append(recipients, msg.Recipients...)
+			amount = amount.Add(msg.Amount...)
+		}
+		policy := ""
+		if record.Policy != nil {
+			policy = "allowed"
+			if !record.Policy.Allowed {
+				policy = "denied"
+			}
+		}
+		_ = w.Write([]string{
+			strconv.FormatUint(entry.Index, 10),
+			record.Time.Format(time.RFC3339),
+			record.operation(),
+			record.Command,
+			record.ChainID,
+			record.Signer,
+			record.Account,
+			strconv.FormatUint(record.AccountNumber, 10),
+			strconv.FormatUint(record.Sequence, 10),
+			record.SignMode,
+			record.TxHash,
+			record.SignBytesHash,
+			strings.Join(types, " "),
+			amount.String(),
+			strings.Join(recipients, " "),
+			policy,
+			entry.Hash,
+		})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestVerifyAuditLog(t *testing.T) {
+	home := t.TempDir()
+	for _, records := range [][]AuditRecord{
+		{{Operation: auditOperationSign, Signer: "alice", Sequence: 1}, {Operation: auditOperationSign, Signer: "bob", Sequence: 1}},
+		{{Operation: auditOperationApprove, Signer: "carol"}},
+		{{Operation: auditOperationSign, Signer: "alice", Sequence: 2}},
+	} {
+		if err := appendAuditRecords(home, records); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		tamper  func(entries []auditEntry) []auditEntry
+		wantErr string
+	}{
+		{name: "intact", tamper: func(entries []auditEntry) []auditEntry { return entries }},
+		{
+			name: "modified record",
+			tamper: func(entries []auditEntry) []auditEntry {
+				entries[1].Record = json.RawMessage(strings.Replace(string(entries[1].Record), `"bob"`, `"mallory"`, 1))
+				return entries
+			},
+			wantErr: "entry 1: hash",
+		},
+		{
+			name: "rehashed record",
+			tamper: func(entries []auditEntry) []auditEntry {
+				entries[1].Record = json.RawMessage(strings.Replace(string(entries[1].Record), `"bob"`, `"mallory"`, 1))
+				entries[1].Hash = entries[1].computeHash()
+				return entries
+			},
+			wantErr: "entry 2: previous hash",
+		},
+		{
+			name:    "removed entry",
+			tamper:  func(entries []auditEntry) []auditEntry { return append(entries[:2], entries[3:]...) },
+			wantErr: "entry 2: unexpected index 3",
+		},
+		{
+			name: "reordered entries",
+			tamper: func(entries []auditEntry) []auditEntry {
+				entries[1], entries[2] = entries[2], entries[1]
+				return entries
+			},
+			wantErr: "entry 1: unexpected index 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := readAuditLog(auditLogPath(home))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 4 {
+				t.Fatalf("got %d entries, want 4", len(entries))
+			}
+			err = verifyAuditLog(tt.tamper(entries))
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+)
+
+const flagBundle = "bundle"
+
+// SigningBundle is everything the offline signer needs to sign a
+// transaction, prepared on the online side with 'tx prepare': the
+// transaction, the state of its signers and an excerpt of the chain profile
+// with the metadata of its denoms, the only part of the profile used.
+// Checksum is the hex SHA-256 of the bundle JSON without checksum.
+type SigningBundle struct {
+	ChainID      string            `json:"chain_id"`
+	Tx           json.RawMessage   `json:"tx"`
+	Signers      []string          `json:"signers"`
+	Accounts     *AccountsSnapshot `json:"accounts"`
+	ChainProfile *ChainProfile     `json:"chain_profile"`
+	Checksum     string            `json:"checksum"`
+}
+
+// checksum returns the checksum of the bundle, computed over its JSON in the
+// form written by the output filter of the commands, with sorted keys and
+// without the null default keys, so that the filtered output of 'tx prepare'
+// matches its checksum.
+func (b SigningBundle) checksum() (string, error) {
+	b.Checksum = ""
+	bz, err := json.Marshal(b)
+	if err != nil {
+		return "", err
+	}
+	if bz, err = filterNullJSONKeys(bz); err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(bz)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// LoadSigningBundle loads a signing bundle from file and verifies its
+// checksum.
+func LoadSigningBundle(file string) (*SigningBundle, error) {
+	bz, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return parseSigningBundle(bz, file)
+}
+
+// parseSigningBundle parses a signing bundle read from source and verifies
+// its checksum.
+func parseSigningBundle(bz []byte, source string) (*SigningBundle, error) {
+	var bundle SigningBundle
+	if err := json.Unmarshal(bz, &bundle); err != nil {
+		return nil, fmt.Errorf("invalid signing bundle %s: %w", source, err)
+	}
+	checksum, err := bundle.checksum()
+	if err != nil {
+		return nil, err
+	}
+	if bundle.Checksum != checksum {
+		return nil, fmt.Errorf("invalid signing bundle %s: checksum %s does not match %s", source, bundle.Checksum, checksum)
+	}
+	switch {
+	case bundle.Accounts == nil:
+		return nil, fmt.Errorf("invalid signing bundle %s: no accounts", source)
+	case bundle.Accounts.ChainID != bundle.ChainID:
+		return nil, fmt.Errorf("invalid signing bundle %s: accounts of chain %s", source, bundle.Accounts.ChainID)
+	case bundle.ChainProfile != nil && bundle.ChainProfile.ChainID != bundle.ChainID:
+		return nil, fmt.Errorf("invalid signing bundle %s: chain profile of chain %s", source, bundle.ChainProfile.ChainID)
+	}
+	return &bundle, nil
+}
+
+// isSigningBundle tells whether the JSON document bz is a signing bundle
+// rather than a transaction.
+func isSigningBundle(bz []byte) bool {
+	var doc struct {
+		Checksum string `json:"checksum"`
+	}
+	return json.Unmarshal(bz, &doc) == nil && doc.Checksum != ""
+}
+
+// bundleKeys returns the names of the keys of the keyring that can sign for
+// the signers of the bundle.
+func bundleKeys(clientCtx client.Context, bundle *SigningBundle) ([]string, error) {
+	records, err := clientCtx.Keyring.List()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, record := range records {
+		if record.GetType() != keyring.TypeLocal && record.GetType() != keyring.TypeLedger {
+			continue
+		}
+		addr, err := record.GetAddress()
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(bundle.Signers, addr.String()) {
+			names = append(names, record.Name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no key of the keyring can sign for %v", bundle.Signers)
+	}
+	return names, nil
+}
+
+// metadataAsset returns the asset of the denom metadata of the bank module.
+func metadataAsset(metadata banktypes.Metadata) Asset {
+	asset := Asset{
+		Base:    metadata.Base,
+		Display: metadata.Display,
+		Symbol:  metadata.Symbol,
+	}
+	for _, unit := range metadata.DenomUnits {
+		asset.DenomUnits = append(asset.DenomUnits, DenomUnit{Denom: unit.Denom, Exponent: int64(unit.Exponent)})
+	}
+	return asset
+}
+
+// txDenoms returns the denoms of the fee and the amounts of the messages of
+// the transaction.
+func txDenoms(clientCtx client.Context, tx sdk.Tx) ([]string, error) {
+	summaries, err := summarizeMsgs(clientCtx, tx)
+	if err != nil {
+		return nil, err
+	}
+	var coins sdk.Coins
+	if feeTx, ok := tx.(sdk.FeeTx); ok {
+		coins = append(coins, feeTx.GetFee()...)
+	}
+	for _, msg := range flattenMsgs(summaries) {
+		coins = append(coins, msg.Amount...)
+	}
+	var denoms []string
+	for _, coin := range coins {
+		if !slices.Contains(denoms, coin.Denom) {
+			denoms = append(denoms, coin.Denom)
+		}
+	}
+	return denoms, nil
+}
+
+// prepareChainProfile returns the excerpt of the chain profile for the
+// transaction: the assets of the denoms of the transaction, from the denom
+// metadata of the node or the local profile. The fee and timeout settings
+// are left out, the offline signer only applies its own.
+func prepareChainProfile(cmd *cobra.Command, clientCtx client.Context, tx sdk.Tx) (*ChainProfile, error) {
+	local, err := LoadChainProfile(clientCtx.HomeDir, clientCtx.ChainID)
+	if err != nil {
+		return nil, err
+	}
+	profile := &ChainProfile{ChainID: clientCtx.ChainID}
+
+	denoms, err := txDenoms(clientCtx, tx)
+	if err != nil {
+		return nil, err
+	}
+	queryClient := banktypes.NewQueryClient(clientCtx)
+	for _, denom := range denoms {
+		res, err := queryClient.DenomMetadata(cmd.Context(), &banktypes.QueryDenomMetadataRequest{Denom: denom})
+		switch {
+		case err == nil:
+			profile.Assets = append(profile.Assets, metadataAsset(res.Metadata))
+			continue
+		case status.Code(err) != codes.NotFound:
+			return nil, fmt.Errorf("query metadata of %s: %w", denom, err)
+		}
+		if local == nil {
+			continue
+		}
+		if asset, ok := local.asset(denom); ok {
+			profile.Assets = append(profile.Assets, asset)
+		}
+	}
+	return profile, nil
+}
+
+// GetPrepareCommand returns the transaction prepare command.
+func GetPrepareCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prepare [file]",
+		Short: "Prepare a signing bundle for the offline signer",
+		Long: `Prepare a signing bundle of the unsigned transaction in [file], for 'tx sign
+--bundle' on the offline machine. The node is queried for the account numbers,
+sequences and public keys of the signers, with their proofs as in 'accounts
+export', and for the metadata of the denoms of the transaction.
+
+The bundle holds the transaction, the accounts snapshot, an excerpt of the
+chain profile with the assets of the denoms of the transaction, and a
+checksum. The fee bounds and the timeout rule are not bundled: the offline
+signer only applies the ones of its own chain profile and policy.
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+			pluginsDir, err := cmd.Flags().GetString(flagPluginsDir)
+			if err != nil {
+				return err
+			}
+			tx, err := readTxFile(clientCtx, pluginsDir, args[0])
+			if err != nil {
+				return err
+			}
+
+			signers, err := getTxSigners(clientCtx, tx)
+			if err != nil {
+				return err
+			}
+			addrs := make([]sdk.AccAddress, len(signers))
+			for i, signer := range signers {
+				if addrs[i], err = sdk.AccAddressFromBech32(signer); err != nil {
+					return err
+				}
+			}
+			snapshot, err := exportAccounts(cmd.Context(), clientCtx, addrs)
+			if err != nil {
+				return err
+			}
+			if cmd.Flags().Changed(flags.FlagChainID) && clientCtx.ChainID != snapshot.ChainID {
+				return fmt.Errorf("the node is on chain %s, not %s", snapshot.ChainID, clientCtx.ChainID)
+			}
+			clientCtx = clientCtx.WithChainID(snapshot.ChainID).WithHeight(snapshot.Height)
+
+			profile, err := prepareChainProfile(cmd, clientCtx, tx)
+			if err != nil {
+				return err
+			}
+			txJSON, err := clientCtx.TxConfig.TxJSONEncoder()(tx)
+			if err != nil {
+				return err
+			}
+			bundle := SigningBundle{
+				ChainID:      snapshot.ChainID,
+				Tx:           txJSON,
+				Signers:      signers,
+				Accounts:     snapshot,
+				ChainProfile: profile,
+			}
+			if bundle.Checksum, err = bundle.checksum(); err != nil {
+				return err
+			}
+
+			bz, err := json.MarshalIndent(bundle, "", "  ")
+			if err != nil {
+				return err
+			}
+			return printOutput(cmd, bz)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+	cmd.Flags().String(flags.FlagChainID, "", "The network chain ID, checked against the node")
+	cmd.Flags().String(flagPluginsDir, "", "The directory to search for plugin files")
+	cmd.Flags().String(flags.FlagOutputDocument, "", "The document will be written to the given file instead of STDOUT")
+	_ = cmd.MarkFlagRequired(flagPluginsDir)
+
+	return cmd
+}
@@ -0,0 +1,398 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"cosmossdk.io/math"
+	abci "github.com/cometbft/cometbft/abci/types"
+	cmtbytes "github.com/cometbft/cometbft/libs/bytes"
+	cmtjson "github.com/cometbft/cometbft/libs/json"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	rpctypes "github.com/cometbft/cometbft/rpc/jsonrpc/types"
+	cmttypes "github.com/cometbft/cometbft/types"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/codec"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+)
+
+// testBankServer is a stand-in for the bank queries of a node.
+type testBankServer struct {
+	banktypes.UnimplementedQueryServer
+	metadata map[string]banktypes.Metadata
+}
+
+func (s testBankServer) DenomMetadata(_ context.Context, req *banktypes.QueryDenomMetadataRequest) (*banktypes.QueryDenomMetadataResponse, error) {
+	metadata, ok := s.metadata[req.Denom]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "client metadata for denom %s", req.Denom)
+	}
+	return &banktypes.QueryDenomMetadataResponse{Metadata: metadata}, nil
+}
+
+// withTestGRPCNode returns clientCtx querying an in-process gRPC server
+// with the bank queries of server.
+func withTestGRPCNode(t *testing.T, clientCtx client.Context, server banktypes.QueryServer) client.Context {
+	t.Helper()
+	grpcCodec := codec.NewProtoCodec(clientCtx.InterfaceRegistry).GRPCCodec()
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer(grpc.ForceServerCodec(grpcCodec))
+	banktypes.RegisterQueryServer(grpcServer, server)
+	go func() { _ = grpcServer.Serve(listener) }()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(grpcCodec)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return clientCtx.WithGRPCClient(conn)
+}
+
+func TestPrepareChainProfile(t *testing.T) {
+	atom := banktypes.Metadata{
+		Base:       "uatom",
+		Display:    "atom",
+		Symbol:     "ATOM",
+		DenomUnits: []*banktypes.DenomUnit{{Denom: "uatom"}, {Denom: "atom", Exponent: 6}},
+	}
+	localProfile := `{
+		"chain_id": "test-chain",
+		"fees": {"fee_tokens": [{"denom": "uatom", "high_gas_price": 0.1}]},
+		"max_fees": [{"denom": "uatom", "amount": "1000"}],
+		"timeout": {"required": true, "max_blocks": 100},
+		"assets": [{"base": "ufoo", "display": "foo", "denom_units": [{"denom": "foo", "exponent": 3}]}]
+	}`
+
+	tests := []struct {
+		name    string
+		profile string
+		fee     sdk.Coins
+		want    []string
+	}{
+		{name: "metadata of the node", fee: sdk.NewCoins(sdk.NewInt64Coin("uatom", 500)), want: []string{"uatom"}},
+		{name: "unknown denom", fee: sdk.NewCoins(sdk.NewInt64Coin("ubar", 500)), want: []string{"uatom"}},
+		{name: "local metadata", profile: localProfile, fee: sdk.NewCoins(sdk.NewInt64Coin("ufoo", 500)), want: []string{"ufoo", "uatom"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientCtx := newTestClientCtx(t)
+			clientCtx = withTestGRPCNode(t, clientCtx, &testBankServer{metadata: map[string]banktypes.Metadata{"uatom": atom}})
+			if tt.profile != "" {
+				writeTestChainProfile(t, clientCtx, tt.profile)
+			}
+			alice := newTestKey(t, clientCtx, "alice")
+			cmd := &cobra.Command{}
+			cmd.SetContext(context.Background())
+
+			profile, err := prepareChainProfile(cmd, clientCtx, newTestTx(t, clientCtx, tt.fee, 200000, newTestSend(alice, alice, 10)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var bases []string
+			for _, asset := range profile.Assets {
+				bases = append(bases, asset.Base)
+			}
+			if len(bases) != len(tt.want) {
+				t.Fatalf("got assets %v, want %v", bases, tt.want)
+			}
+			for i := range bases {
+				if bases[i] != tt.want[i] {
+					t.Fatalf("got assets %v, want %v", bases, tt.want)
+				}
+			}
+			if len(profile.Fees.FeeTokens) > 0 || len(profile.MaxFees) > 0 || profile.Timeout != nil {
+				t.Fatalf("got the limits of the local profile in the bundle: %+v", profile)
+			}
+		})
+	}
+}
+
+func TestBundleChainProfileLimits(t *testing.T) {
+	bundleProfile := &ChainProfile{
+		ChainID: testChainID,
+		MaxFees: sdk.NewCoins(sdk.NewInt64Coin("uatom", 1000000)),
+		Timeout: &TimeoutRule{},
+		Assets:  []Asset{{Base: "uatom", Display: "uatom"}, {Base: "ufoo", Display: "foo"}},
+	}
+	bundleProfile.Fees.FeeTokens = []FeeToken{{Denom: "uatom", MaxGasPrice: &Decimal{math.LegacyNewDec(100)}}}
+
+	tests := []struct {
+		name        string
+		profile     string
+		wantMaxFees string
+		wantTimeout bool
+		wantAssets  []string
+	}{
+		{
+			name:       "no local profile",
+			wantAssets: []string{"uatom", "ufoo"},
+		},
+		{
+			name:        "local profile",
+			profile:     `{"chain_id": "test-chain", "max_fees": [{"denom": "uatom", "amount": "1000"}], "timeout": {"required": true}, "assets": [{"base": "uatom", "display": "atom"}]}`,
+			wantMaxFees: "1000uatom",
+			wantTimeout: true,
+			wantAssets:  []string{"uatom", "ufoo"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientCtx := newTestClientCtx(t)
+			if tt.profile != "" {
+				writeTestChainProfile(t, clientCtx, tt.profile)
+			}
+			profile, err := loadChainProfile(withChainProfile(clientCtx, bundleProfile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := profile.MaxFees.String(); got != tt.wantMaxFees {
+				t.Fatalf("got max fees %q, want %q", got, tt.wantMaxFees)
+			}
+			if len(profile.Fees.FeeTokens) > 0 && tt.profile == "" {
+				t.Fatalf("got the fee tokens of the bundle: %v", profile.Fees.FeeTokens)
+			}
+			if (profile.Timeout != nil) != tt.wantTimeout || (profile.Timeout != nil && !profile.Timeout.Required) {
+				t.Fatalf("got timeout rule %+v", profile.Timeout)
+			}
+			if len(profile.Assets) != len(tt.wantAssets) {
+				t.Fatalf("got assets %v, want %v", profile.Assets, tt.wantAssets)
+			}
+			// the local metadata wins over the bundle's
+			if tt.profile != "" && profile.Assets[0].Display != "atom" {
+				t.Fatalf("got asset %+v of the bundle", profile.Assets[0])
+			}
+		})
+	}
+}
+
+// testChainNode is a stand-in for the CometBFT RPC of a node, serving the
+// blocks, accounts with their proofs and denom metadata queried by
+// 'tx prepare'.
+type testChainNode struct {
+	clientCtx client.Context
+	snapshot  *AccountsSnapshot
+	appHash   []byte
+	metadata  map[string]banktypes.Metadata
+}
+
+func (n *testChainNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req rpctypes.RPCRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	result, err := n.result(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	bz, err := json.Marshal(rpctypes.NewRPCSuccessResponse(req.ID, result))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(bz)
+}
+
+func (n *testChainNode) result(req rpctypes.RPCRequest) (any, error) {
+	switch req.Method {
+	case "status":
+		return &coretypes.ResultStatus{SyncInfo: coretypes.SyncInfo{LatestBlockHeight: n.snapshot.Height + 1}}, nil
+	case "block":
+		var params struct {
+			Height int64 `json:"height"`
+		}
+		if err := cmtjson.Unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		header := cmttypes.Header{ChainID: testChainID, Height: params.Height, Time: time.Now()}
+		if params.Height == n.snapshot.Height+1 {
+			header.AppHash = n.appHash
+		}
+		return &coretypes.ResultBlock{Block: &cmttypes.Block{Header: header}}, nil
+	case "abci_query":
+		var params struct {
+			Path string            `json:"path"`
+			Data cmtbytes.HexBytes `json:"data"`
+		}
+		if err := cmtjson.Unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		res, err := n.query(params.Path, params.Data)
+		if err != nil {
+			return nil, err
+		}
+		res.Height = n.snapshot.Height
+		return &coretypes.ResultABCIQuery{Response: res}, nil
+	default:
+		return nil, fmt.Errorf("unexpected method %s", req.Method)
+	}
+}
+
+func (n *testChainNode) query(path string, data []byte) (abci.ResponseQuery, error) {
+	var res abci.ResponseQuery
+	switch path {
+	case "/store/" + authtypes.StoreKey + "/key":
+		account, err := n.account(data)
+		if err != nil {
+			return res, err
+		}
+		res.Key, res.Value, res.ProofOps = data, account.Value, account.Proof
+	case "/cosmos.auth.v1beta1.Query/Account":
+		var req authtypes.QueryAccountRequest
+		if err := n.clientCtx.Codec.Unmarshal(data, &req); err != nil {
+			return res, err
+		}
+		addr, err := sdk.AccAddressFromBech32(req.Address)
+		if err != nil {
+			return res, err
+		}
+		account, err := n.account(accountStoreKey(addr))
+		if err != nil {
+			return res, err
+		}
+		var value codectypes.Any
+		if err := value.Unmarshal(account.Value); err != nil {
+			return res, err
+		}
+		if res.Value, err = n.clientCtx.Codec.Marshal(&authtypes.QueryAccountResponse{Account: &value}); err != nil {
+			return res, err
+		}
+	case "/cosmos.bank.v1beta1.Query/DenomMetadata":
+		var req banktypes.QueryDenomMetadataRequest
+		if err := n.clientCtx.Codec.Unmarshal(data, &req); err != nil {
+			return res, err
+		}
+		metadata, ok := n.metadata[req.Denom]
+		if !ok {
+			return res, fmt.Errorf("unexpected metadata query of %s", req.Denom)
+		}
+		bz, err := n.clientCtx.Codec.Marshal(&banktypes.QueryDenomMetadataResponse{Metadata: metadata})
+		if err != nil {
+			return res, err
+		}
+		res.Value = bz
+	default:
+		return res, fmt.Errorf("unexpected query %s", path)
+	}
+	return res, nil
+}
+
+// account returns the account of the snapshot stored at key.
+func (n *testChainNode) account(key []byte) (AccountSnapshot, error) {
+	for _, account := range n.snapshot.Accounts {
+		addr, err := sdk.AccAddressFromBech32(account.Address)
+		if err != nil {
+			return account, err
+		}
+		if bytes.Equal(accountStoreKey(addr), key) {
+			return account, nil
+		}
+	}
+	return AccountSnapshot{}, fmt.Errorf("unexpected account key %X", key)
+}
+
+// newTestTxCommand returns the tx command with the output filters of the
+// signer, with cmds as subcommands.
+func newTestTxCommand(cmds ...*cobra.Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use: "tx",
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			FilterOutput(cmd)
+			return nil
+		},
+		PersistentPostRunE: func(cmd *cobra.Command, _ []string) error {
+			return FilterOutputDocument(cmd)
+		},
+	}
+	cmd.AddCommand(cmds...)
+	return cmd
+}
+
+// prepareTestBundle runs 'tx prepare' of tx against a node serving the
+// accounts of the signers of tx, and returns the bundle with the flags
+// trusting the app hash of the node.
+func prepareTestBundle(t *testing.T, clientCtx client.Context, tx sdk.Tx) ([]byte, []string) {
+	t.Helper()
+	signers, err := getTxSigners(clientCtx, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addrs := make([]sdk.AccAddress, len(signers))
+	for i, signer := range signers {
+		addrs[i] = sdk.MustAccAddressFromBech32(signer)
+	}
+	snapshot, appHash := newTestSnapshot(t, addrs...)
+	server := httptest.NewServer(&testChainNode{
+		clientCtx: clientCtx,
+		snapshot:  snapshot,
+		appHash:   appHash,
+		metadata: map[string]banktypes.Metadata{"uatom": {
+			Base:       "uatom",
+			Display:    "atom",
+			DenomUnits: []*banktypes.DenomUnit{{Denom: "uatom"}, {Denom: "atom", Exponent: 6}},
+		}},
+	})
+	t.Cleanup(server.Close)
+
+	bz, err := runTestCommand(t, clientCtx, newTestTxCommand(GetPrepareCommand()), "prepare", writeTestTx(t, clientCtx, tx), "--"+flags.FlagNode, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bz, []string{"--" + flagTrustedAppHash, hex.EncodeToString(appHash), "--" + flagTrustedHeight, strconv.FormatInt(snapshot.Height+1, 10)}
+}
+
+func TestPrepareSignBundle(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	bob := newTestKey(t, clientCtx, "bob")
+	tx := newTestTx(t, clientCtx, sdk.NewCoins(sdk.NewInt64Coin("uatom", 2000)), 200000, newTestSend(alice, bob, 10))
+
+	bz, trusted := prepareTestBundle(t, clientCtx, tx)
+	// the output filter rewrote the bundle
+	if bytes.Contains(bz, []byte(`"tip"`)) || bytes.Contains(bz, []byte("\n  ")) {
+		t.Fatalf("got an unfiltered bundle: %s", bz)
+	}
+	bundleFile := filepath.Join(t.TempDir(), "bundle.json")
+	if err := os.WriteFile(bundleFile, bz, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	args := append([]string{"sign", "--" + flagBundle, bundleFile, "--from", "alice"}, trusted...)
+	bz, err := runTestCommand(t, clientCtx, newTestTxCommand(GetSignCommand()), args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigs := verifyTestSignatures(t, clientCtx, bz, 10)
+	if sigs[0].Sequence != 20 {
+		t.Fatalf("got sequence %d, want 20", sigs[0].Sequence)
+	}
+}
@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cosmossdk.io/math"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// ChainProfile describes a chain. It is read from config/chains/<chain-id>.json
+// in the home directory, and is compatible with the chain.json files of the
+// chain registry, so that they can be used as is, with a few additions.
+type ChainProfile struct {
+	ChainID string `json:"chain_id"`
+	Fees    struct {
+		FeeTokens []FeeToken `json:"fee_tokens"`
+	} `json:"fees"`
+
+	// MaxFees are the absolute maximum fees per denom.
+	MaxFees sdk.Coins `json:"max_fees,omitempty"`
+	// Assets is the asset metadata of the chain, as in the assetlist.json
+	// files of the chain registry.
+	Assets []Asset `json:"assets,omitempty"`
+	// Timeout overrides the timeout height rule of the policy.
+	Timeout *TimeoutRule `json:"timeout,omitempty"`
+}
+
+// Asset is the metadata of a denom.
+type Asset struct {
+	Base       string      `json:"base"`
+	Display    string      `json:"display"`
+	Symbol     string      `json:"symbol"`
+	DenomUnits []DenomUnit `json:"denom_units"`
+}
+
+// DenomUnit is a unit of an asset.
+type DenomUnit struct {
+	Denom    string `json:"denom"`
+	Exponent int64  `json:"exponent"`
+}
+
+// FeeToken is a denom fees can be paid with, and its gas prices.
+type FeeToken struct {
+	Denom            string   `json:"denom"`
+	FixedMinGasPrice *Decimal `json:"fixed_min_gas_price,omitempty"`
+	LowGasPrice      *Decimal `json:"low_gas_price,omitempty"`
+	AverageGasPrice  *Decimal `json:"average_gas_price,omitempty"`
+	HighGasPrice     *Decimal `json:"high_gas_price,omitempty"`
+	// MaxGasPrice is the highest acceptable gas price, 10 times the high gas
+	// price by default.
+	MaxGasPrice *Decimal `json:"max_gas_price,omitempty"`
+}
+
+// Decimal is a decimal encoded as a JSON number, as in the chain registry,
+// or as a string.
+type Decimal struct {
+	math.LegacyDec
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Decimal) UnmarshalJSON(bz []byte) error {
+	var n json.Number
+	if err := json.Unmarshal(bz, &n); err != nil {
+		return err
+	}
+	dec, err := math.LegacyNewDecFromStr(n.String())
+	if err != nil {
+		return err
+	}
+	d.LegacyDec = dec
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.LegacyDec.String())
+}
+
+func chainProfilePath(home, chainID string) string {
+	return filepath.Join(home, "config", "chains", chainID+".json")
+}
+
+// LoadChainProfile loads the profile of chainID from the home directory. A
+// nil profile is returned if there is none.
+func LoadChainProfile(home, chainID string) (*ChainProfile, error) {
+	if chainID == "" {
+		return nil, nil
+	}
+	file := chainProfilePath(home, chainID)
+	bz, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var profile ChainProfile
+	if err := json.Unmarshal(bz, &profile); err != nil {
+		return nil, fmt.Errorf("invalid chain profile %s: %w", file, err)
+	}
+	if profile.ChainID != chainID {
+		return nil, fmt.Errorf("invalid chain profile %s: chain-id %q does not match %q", file, profile.ChainID, chainID)
+	}
+	return &profile, nil
+}
+
+// chainProfileKey is the context key of the chain profile carried by a
+// signing bundle.
+type chainProfileKey struct{}
+
+// withChainProfile returns clientCtx carrying the assets of profile, for the
+// denoms the profile of the home directory has no metadata of. The fee and
+// timeout settings of profile are left out: a signing bundle is prepared on
+// the online machine, and must not relax the local limits.
+func withChainProfile(clientCtx client.Context, profile *ChainProfile) client.Context {
+	ctx := clientCtx.CmdContext
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	assets := &ChainProfile{ChainID: profile.ChainID, Assets: profile.Assets}
+	return clientCtx.WithCmdContext(context.WithValue(ctx, chainProfileKey{}, assets))
+}
+
+// loadChainProfile loads the profile of the chain of clientCtx from the home
+// directory, completed with the assets carried by clientCtx.
+func loadChainProfile(clientCtx client.Context) (*ChainProfile, error) {
+	profile, err := LoadChainProfile(clientCtx.HomeDir, clientCtx.ChainID)
+	if err != nil || clientCtx.CmdContext == nil {
+		return profile, err
+	}
+	carried, _ := clientCtx.CmdContext.Value(chainProfileKey{}).(*ChainProfile)
+	if carried == nil || carried.ChainID != clientCtx.ChainID {
+		return profile, nil
+	}
+	if profile == nil {
+		profile = &ChainProfile{ChainID: clientCtx.ChainID}
+	}
+	for _, asset := range carried.Assets {
+		if _, ok := profile.asset(asset.Base); !ok {
+			profile.Assets = append(profile.Assets, asset)
+		}
+	}
+	return profile, nil
+}
+
+// LoadChainProfiles loads all the chain profiles of the home directory.
+func LoadChainProfiles(home string) (map[string]*ChainProfile, error) {
+	files, err := filepath.Glob(filepath.Join(home, "config", "chains", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	profiles := make(map[string]*ChainProfile, len(files))
+	for _, file := range files {
+		chainID := strings.TrimSuffix(filepath.Base(file), ".json")
+		profile, err := LoadChainProfile(home, chainID)
+		if err != nil {
+			return nil, err
+		}
+		profiles[chainID] = profile
+	}
+	return profiles, nil
+}
+
+// asset returns the metadata of the base denom, if any.
+func (p *ChainProfile) asset(base string) (Asset, bool) {
+	for _, asset := range p.Assets {
+		if asset.Base == base {
+			return asset, true
+		}
+	}
+	return Asset{}, false
+}
+
+// displayAmount converts an amount of the base denom of the asset to its
+// display denom.
+func (a Asset) displayAmount(amount math.Int) (math.LegacyDec, string) {
+	for _, unit := range a.DenomUnits {
+		if unit.Denom == a.Display {
+			dec := math.LegacyNewDecFromInt(amount).Quo(math.LegacyNewDec(10).Power(uint64(unit.Exponent)))
+			symbol := a.Symbol
+			if symbol == "" {
+				symbol = a.Display
+			}
+			return dec, symbol
+		}
+	}
+	return math.LegacyNewDecFromInt(amount), a.Base
+}
+
+// feeToken returns the fee token of denom, if any.
+func (p *ChainProfile) feeToken(denom string) (FeeToken, bool) {
+	for _, token := range p.Fees.FeeTokens {
+		if token.Denom == denom {
+			return token, true
+		}
+	}
+	return FeeToken{}, false
+}
+
+// minGasPrice returns the lowest gas price validators are expected to
+// accept, nil if unknown.
+func (t FeeToken) minGasPrice() *Decimal {
+	if t.FixedMinGasPrice != nil && t.FixedMinGasPrice.IsPositive() {
+		return t.FixedMinGasPrice
+	}
+	return t.LowGasPrice
+}
+
+// maxGasPrice returns the highest acceptable gas price, nil if unknown.
+func (t FeeToken) maxGasPrice() *Decimal {
+	if t.MaxGasPrice != nil {
+		return t.MaxGasPrice
+	}
+	if t.HighGasPrice != nil {
+		return &Decimal{t.HighGasPrice.MulInt64(10)}
+	}
+	return nil
+}
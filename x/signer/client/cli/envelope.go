@@ -0,0 +1,621 @@
+package cli
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+)
+
+const (
+	flagEnvelope  = "envelope"
+	flagRecipient = "recipient"
+	flagSender    = "sender"
+
+	// envelopeKeyFile is the X25519 envelope key, in the config directory
+	// of the home.
+	envelopeKeyFile = "envelope_key.json"
+
+	envelopeVersion = 1
+	envelopeKDFInfo = "cosmos-signer envelope"
+
+	// envelopeSignPrefix prefixes the hash signed by the envelope sender, so
+	// that an envelope signature cannot be taken for the signature of a
+	// transaction or of anything else signed with the same key.
+	envelopeSignPrefix = "cosmos-signer envelope signature:"
+)
+
+// EnvelopeKey is the X25519 key envelopes are sealed to.
+type EnvelopeKey struct {
+	PrivateKey []byte `json:"private_key"`
+	PublicKey  []byte `json:"public_key"`
+}
+
+func envelopeKeyPath(home string) string {
+	return filepath.Join(home, "config", envelopeKeyFile)
+}
+
+// loadEnvelopeKey loads the envelope key of the home directory, nil if
+// there is none.
+func loadEnvelopeKey(home string) (*ecdh.PrivateKey, error) {
+	bz, err := os.ReadFile(envelopeKeyPath(home))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var key EnvelopeKey
+	if err := json.Unmarshal(bz, &key); err != nil {
+		return nil, fmt.Errorf("invalid envelope key %s: %w", envelopeKeyPath(home), err)
+	}
+	privKey, err := ecdh.X25519().NewPrivateKey(key.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid envelope key %s: %w", envelopeKeyPath(home), err)
+	}
+	return privKey, nil
+}
+
+// parseEnvelopePubKey parses a base64 X25519 public key.
+func parseEnvelopePubKey(s string) (*ecdh.PublicKey, error) {
+	bz, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid envelope public key %q: %w", s, err)
+	}
+	pubKey, err := ecdh.X25519().NewPublicKey(bz)
+	if err != nil {
+		return nil, fmt.Errorf("invalid envelope public key %q: %w", s, err)
+	}
+	return pubKey, nil
+}
+
+// Envelope is a document encrypted to the envelope keys of its recipients
+// and signed by its sender. The document is encrypted with ChaCha20-Poly1305
+// under a random content key, which is wrapped for every recipient with a
+// key derived with HKDF-SHA256 from the X25519 shared secret of the
+// ephemeral key and the recipient key.
+//
+// The version, the sender, the ephemeral key and the recipient keys are
+// authenticated as associated data of the document and of every wrapped
+// key, and the signature is made by a key of the keyring over the SHA-256 of
+// the envelope JSON without signature, prefixed with envelopeSignPrefix.
+type Envelope struct {
+	Version      int                 `json:"version"`
+	Sender       EnvelopeSender      `json:"sender"`
+	EphemeralKey []byte              `json:"ephemeral_key"`
+	Recipients   []EnvelopeRecipient `json:"recipients"`
+	Nonce        []byte              `json:"nonce"`
+	Ciphertext   []byte              `json:"ciphertext"`
+	Signature    []byte              `json:"signature,omitempty"`
+}
+
+// EnvelopeSender is the sender of an envelope: the account signing it and,
+// if the sender has one, the envelope key replies are sealed to.
+type EnvelopeSender struct {
+	Address     string          `json:"address"`
+	PubKey      json.RawMessage `json:"pub_key"`
+	EnvelopeKey []byte          `json:"envelope_key,omitempty"`
+}
+
+// EnvelopeRecipient is the content key wrapped for a recipient.
+type EnvelopeRecipient struct {
+	EnvelopeKey []byte `json:"envelope_key"`
+	WrappedKey  []byte `json:"wrapped_key"`
+}
+
+// signBytes returns the bytes signed by the sender, the prefixed hash of
+// the envelope.
+func (e Envelope) signBytes() ([]byte, error) {
+	e.Signature = nil
+	bz, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(bz)
+	return append([]byte(envelopeSignPrefix), hash[:]...), nil
+}
+
+// associatedData returns the header of the envelope authenticated with the
+// document and the wrapped keys, so that a sender or recipient cannot be
+// swapped without the content key, even by re-signing the envelope.
+func (e Envelope) associatedData() ([]byte, error) {
+	recipientKeys := make([][]byte, len(e.Recipients))
+	for i, recipient := range e.Recipients {
+		recipientKeys[i] = recipient.EnvelopeKey
+	}
+	return json.Marshal(struct {
+		Version       int            `json:"version"`
+		Sender        EnvelopeSender `json:"sender"`
+		EphemeralKey  []byte         `json:"ephemeral_key"`
+		RecipientKeys [][]byte       `json:"recipient_keys"`
+	}{e.Version, e.Sender, e.EphemeralKey, recipientKeys})
+}
+
+// keyWrapAEAD returns the AEAD wrapping the content key for the recipient
+// key, from the X25519 shared secret of the ephemeral and recipient keys.
+func keyWrapAEAD(sharedSecret, ephemeralKey, recipientKey []byte) (cipher.AEAD, error) {
+	salt := append(append([]byte{}, ephemeralKey...), recipientKey...)
+	kek := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, sharedSecret, salt, []byte(envelopeKDFInfo)), kek); err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.New(kek)
+}
+
+// checkEnvelopeKey checks that the key name of the keyring can sign
+// envelopes. The signature is made in SIGN_MODE_DIRECT over a hash, which
+// only local keys sign: Ledger devices refuse anything but the sign bytes of
+// a transaction in SIGN_MODE_LEGACY_AMINO_JSON.
+func checkEnvelopeKey(clientCtx client.Context, name string) error {
+	record, err := clientCtx.Keyring.Key(name)
+	if err != nil {
+		return err
+	}
+	if record.GetType() != keyring.TypeLocal {
+		return fmt.Errorf("key %s of type %s cannot sign envelopes, only local keys can", name, record.GetType())
+	}
+	return nil
+}
+
+// sealEnvelope encrypts the document to the recipients and signs the
+// envelope with the key from of the keyring.
+func sealEnvelope(clientCtx client.Context, from string, recipients []*ecdh.PublicKey, doc []byte) (*Envelope, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no envelope recipient")
+	}
+	addr, name, _, err := client.GetFromFields(clientCtx, clientCtx.Keyring, from)
+	if err != nil {
+		return nil, fmt.Errorf("error getting account from keybase: %w", err)
+	}
+	if err := checkEnvelopeKey(clientCtx, name); err != nil {
+		return nil, err
+	}
+	record, err := clientCtx.Keyring.Key(name)
+	if err != nil {
+		return nil, err
+	}
+	pubKey, err := record.GetPubKey()
+	if err != nil {
+		return nil, err
+	}
+	pubKeyJSON, err := clientCtx.Codec.MarshalInterfaceJSON(pubKey)
+	if err != nil {
+		return nil, err
+	}
+	env := &Envelope{
+		Version: envelopeVersion,
+		Sender:  EnvelopeSender{Address: addr.String(), PubKey: pubKeyJSON},
+	}
+	senderKey, err := loadEnvelopeKey(clientCtx.HomeDir)
+	if err != nil {
+		return nil, err
+	}
+	if senderKey != nil {
+		env.Sender.EnvelopeKey = senderKey.PublicKey().Bytes()
+	}
+
+	ephemeralKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	env.EphemeralKey = ephemeralKey.PublicKey().Bytes()
+	env.Recipients = make([]EnvelopeRecipient, len(recipients))
+	for i, recipient := range recipients {
+		env.Recipients[i].EnvelopeKey = recipient.Bytes()
+	}
+	ad, err := env.associatedData()
+	if err != nil {
+		return nil, err
+	}
+
+	contentKey := make([]byte, chacha20poly1305.KeySize)
+	if _, err := rand.Read(contentKey); err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.New(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	env.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(env.Nonce); err != nil {
+		return nil, err
+	}
+	env.Ciphertext = aead.Seal(nil, env.Nonce, doc, ad)
+
+	for i, recipient := range recipients {
+		sharedSecret, err := ephemeralKey.ECDH(recipient)
+		if err != nil {
+			return nil, err
+		}
+		wrap, err := keyWrapAEAD(sharedSecret, env.EphemeralKey, recipient.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		// every key encryption key is only used once
+		env.Recipients[i].WrappedKey = wrap.Seal(nil, make([]byte, wrap.NonceSize()), contentKey, ad)
+	}
+
+	hash, err := env.signBytes()
+	if err != nil {
+		return nil, err
+	}
+	if env.Signature, _, err = clientCtx.Keyring.Sign(name, hash, signing.SignMode_SIGN_MODE_DIRECT); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// verify verifies the signature of the envelope, by the key of its sender
+// address.
+func (e Envelope) verify(clientCtx client.Context) error {
+	if e.Version != envelopeVersion {
+		return fmt.Errorf("unsupported envelope version %d", e.Version)
+	}
+	var pubKey cryptotypes.PubKey
+	if err := clientCtx.Codec.UnmarshalInterfaceJSON(e.Sender.PubKey, &pubKey); err != nil {
+		return fmt.Errorf("invalid envelope sender public key: %w", err)
+	}
+	addr, err := sdk.AccAddressFromBech32(e.Sender.Address)
+	if err != nil {
+		return fmt.Errorf("invalid envelope sender address: %w", err)
+	}
+	if !bytes.Equal(pubKey.Address(), addr) {
+		return fmt.Errorf("the envelope sender public key does not match the address %s", e.Sender.Address)
+	}
+	hash, err := e.signBytes()
+	if err != nil {
+		return err
+	}
+	if !pubKey.VerifySignature(hash, e.Signature) {
+		return fmt.Errorf("invalid envelope signature of %s", e.Sender.Address)
+	}
+	return nil
+}
+
+// openEnvelope verifies the signature of the envelope and decrypts its
+// document with the envelope key of the home directory.
+func openEnvelope(clientCtx client.Context, env *Envelope) ([]byte, error) {
+	if err := env.verify(clientCtx); err != nil {
+		return nil, err
+	}
+	key, err := loadEnvelopeKey(clientCtx.HomeDir)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, errors.New("no envelope key, create one with 'envelope keygen'")
+	}
+	ephemeralKey, err := ecdh.X25519().NewPublicKey(env.EphemeralKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid envelope ephemeral key: %w", err)
+	}
+	ad, err := env.associatedData()
+	if err != nil {
+		return nil, err
+	}
+	pubKey := key.PublicKey().Bytes()
+	for _, recipient := range env.Recipients {
+		if !bytes.Equal(recipient.EnvelopeKey, pubKey) {
+			continue
+		}
+		sharedSecret, err := key.ECDH(ephemeralKey)
+		if err != nil {
+			return nil, err
+		}
+		wrap, err := keyWrapAEAD(sharedSecret, env.EphemeralKey, pubKey)
+		if err != nil {
+			return nil, err
+		}
+		contentKey, err := wrap.Open(nil, make([]byte, wrap.NonceSize()), recipient.WrappedKey, ad)
+		if err != nil {
+			return nil, fmt.Errorf("cannot unwrap the envelope content key: %w", err)
+		}
+		aead, err := chacha20poly1305.New(contentKey)
+		if err != nil {
+			return nil, err
+		}
+		doc, err := aead.Open(nil, env.Nonce, env.Ciphertext, ad)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decrypt the envelope: %w", err)
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("the envelope is not sealed to the envelope key %s", base64.StdEncoding.EncodeToString(pubKey))
+}
+
+// LoadEnvelope loads an envelope from file.
+func LoadEnvelope(file string) (*Envelope, error) {
+	bz, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var env Envelope
+	if err := json.Unmarshal(bz, &env); err != nil {
+		return nil, fmt.Errorf("invalid envelope %s: %w", file, err)
+	}
+	return &env, nil
+}
+
+// isEnvelope tells whether the JSON document bz is an envelope.
+func isEnvelope(bz []byte) bool {
+	var doc struct {
+		Version    int    `json:"version"`
+		Ciphertext []byte `json:"ciphertext"`
+	}
+	return json.Unmarshal(bz, &doc) == nil && doc.Version > 0 && len(doc.Ciphertext) > 0
+}
+
+// readEnvelope opens the envelope in file, checking its sender against
+// --sender if set, and reports the sender annotated from the address book.
+func readEnvelope(clientCtx client.Context, cmd *cobra.Command, file string) ([]byte, *Envelope, error) {
+	env, err := LoadEnvelope(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	doc, err := openEnvelope(clientCtx, env)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot open envelope %s: %w", file, err)
+	}
+	expected, err := cmd.Flags().GetString(flagSender)
+	if err != nil {
+		return nil, nil, err
+	}
+	if expected != "" {
+		addr, err := sdk.AccAddressFromBech32(expected)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid --%s: %w", flagSender, err)
+		}
+		if addr.String() != env.Sender.Address {
+			return nil, nil, fmt.Errorf("envelope %s is sealed by %s, not %s", file, env.Sender.Address, expected)
+		}
+	}
+	book, err := LoadAddressBook(addressBookPath(clientCtx.HomeDir))
+	if err != nil {
+		return nil, nil, err
+	}
+	cmd.PrintErrf("Envelope sealed by %s\n", book.Annotate(env.Sender.Address))
+	return doc, env, nil
+}
+
+// checkReplyRecipient refuses the envelope env if its sender is neither
+// checked with --sender nor known in the address book. Anyone can seal an
+// envelope, so replies are only sealed to the envelope keys of known
+// senders.
+func checkReplyRecipient(clientCtx client.Context, cmd *cobra.Command, env *Envelope) error {
+	expected, err := cmd.Flags().GetString(flagSender)
+	if err != nil {
+		return err
+	}
+	if expected != "" {
+		// readEnvelope checked the sender
+		return nil
+	}
+	book, err := LoadAddressBook(addressBookPath(clientCtx.HomeDir))
+	if err != nil {
+		return err
+	}
+	if !book.IsKnown(env.Sender.Address) {
+		return fmt.Errorf("the envelope sender %s is not known in the address book, the reply cannot be sealed to it unless checked with --%s", env.Sender.Address, flagSender)
+	}
+	return nil
+}
+
+// sealReply seals the document to the sender of the envelope env, checked
+// with checkReplyRecipient, signed by the key from of the keyring.
+func sealReply(clientCtx client.Context, env *Envelope, from string, doc []byte) ([]byte, error) {
+	if len(env.Sender.EnvelopeKey) == 0 {
+		return nil, fmt.Errorf("the envelope sender %s has no envelope key to reply to", env.Sender.Address)
+	}
+	recipient, err := ecdh.X25519().NewPublicKey(env.Sender.EnvelopeKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid envelope key of the sender %s: %w", env.Sender.Address, err)
+	}
+	reply, err := sealEnvelope(clientCtx, from, []*ecdh.PublicKey{recipient}, doc)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(reply, "", "  ")
+}
+
+// GetEnvelopeCommand returns the envelope command.
+func GetEnvelopeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "envelope",
+		Short: "Encrypted and signed envelope subcommands",
+		Long: `Seal documents, transactions or signing bundles, in envelopes encrypted to
+the envelope keys of their recipients and signed by a key of the keyring, so
+that they can be carried between the online and the offline machines through
+untrusted channels.
+
+The envelope key is an X25519 key, stored unencrypted in
+config/envelope_key.json in the home directory, that is created with
+'envelope keygen'. Its public key is given to the senders, and is set in the
+envelopes sealed from the home directory so that replies can be sealed to it,
+see 'tx sign --envelope'.
+
+The sender, the ephemeral key and the recipient keys of an envelope are
+authenticated with its document, and its signature is made over a hash
+prefixed with "cosmos-signer envelope signature:", which cannot be taken for
+a transaction signed with the same key. Ledger devices only sign transactions,
+in SIGN_MODE_LEGACY_AMINO_JSON, and not such a hash: envelopes can only be
+signed by the local keys of the keyring.
+`,
+		DisableFlagParsing:         false,
+		SuggestionsMinimumDistance: 2,
+		RunE:                       client.ValidateCmd,
+	}
+
+	cmd.AddCommand(
+		GetEnvelopeKeygenCommand(),
+		GetEnvelopePubKeyCommand(),
+		GetEnvelopeSealCommand(),
+		GetEnvelopeOpenCommand(),
+	)
+
+	return cmd
+}
+
+// GetEnvelopeKeygenCommand returns the envelope keygen command.
+func GetEnvelopeKeygenCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "keygen",
+		Short: "Create the envelope key of the home directory",
+		Long: `Create the X25519 envelope key of the home directory, and print its base64
+public key. An existing envelope key is never replaced.
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			clientCtx := client.GetClientContextFromCmd(cmd)
+			file := envelopeKeyPath(clientCtx.HomeDir)
+			if _, err := os.Stat(file); err == nil {
+				return fmt.Errorf("envelope key %s already exists", file)
+			}
+			key, err := ecdh.X25519().GenerateKey(rand.Reader)
+			if err != nil {
+				return err
+			}
+			bz, err := json.MarshalIndent(EnvelopeKey{
+				PrivateKey: key.Bytes(),
+				PublicKey:  key.PublicKey().Bytes(),
+			}, "", "  ")
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+				return err
+			}
+			// O_EXCL so that a concurrent keygen does not replace the key
+			f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+			if err != nil {
+				return err
+			}
+			if _, err := f.Write(append(bz, '\n')); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+			cmd.Println(base64.StdEncoding.EncodeToString(key.PublicKey().Bytes()))
+			return nil
+		},
+	}
+}
+
+// GetEnvelopePubKeyCommand returns the envelope pubkey command.
+func GetEnvelopePubKeyCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "pubkey",
+		Short: "Print the base64 public key of the envelope key",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			clientCtx := client.GetClientContextFromCmd(cmd)
+			key, err := loadEnvelopeKey(clientCtx.HomeDir)
+			if err != nil {
+				return err
+			}
+			if key == nil {
+				return errors.New("no envelope key, create one with 'envelope keygen'")
+			}
+			cmd.Println(base64.StdEncoding.EncodeToString(key.PublicKey().Bytes()))
+			return nil
+		},
+	}
+}
+
+// GetEnvelopeSealCommand returns the envelope seal command.
+func GetEnvelopeSealCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "seal [file]",
+		Short: "Seal a document in an envelope",
+		Long: `Seal the document in [file], e.g. an unsigned transaction or a signing
+bundle, in an envelope encrypted to the base64 envelope public keys given
+with --recipient, and signed by the --from key of the keyring.
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientTxContext(cmd)
+			if err != nil {
+				return err
+			}
+			recipientKeys, err := cmd.Flags().GetStringArray(flagRecipient)
+			if err != nil {
+				return err
+			}
+			recipients := make([]*ecdh.PublicKey, len(recipientKeys))
+			for i, key := range recipientKeys {
+				if recipients[i], err = parseEnvelopePubKey(key); err != nil {
+					return err
+				}
+			}
+			doc, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			env, err := sealEnvelope(clientCtx, clientCtx.FromName, recipients, bytes.TrimSpace(doc))
+			if err != nil {
+				return err
+			}
+			bz, err := json.MarshalIndent(env, "", "  ")
+			if err != nil {
+				return err
+			}
+			return printOutput(cmd, bz)
+		},
+	}
+
+	cmd.Flags().String(flags.FlagFrom, "", "Name or address of the key signing the envelope")
+	cmd.Flags().StringArray(flagRecipient, nil, "The base64 envelope public key of a recipient, can be repeated")
+	cmd.Flags().String(flags.FlagOutputDocument, "", "The document will be written to the given file instead of STDOUT")
+	flags.AddKeyringFlags(cmd.Flags())
+	_ = cmd.MarkFlagRequired(flags.FlagFrom)
+	_ = cmd.MarkFlagRequired(flagRecipient)
+
+	return cmd
+}
+
+// GetEnvelopeOpenCommand returns the envelope open command.
+func GetEnvelopeOpenCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "open [file]",
+		Short: "Open an envelope",
+		Long: `Verify the signature of the envelope in [file] and decrypt its document with
+the envelope key of the home directory. The sender is reported, annotated
+from the address book, and with --sender envelopes sealed by another account
+are refused.
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx := client.GetClientContextFromCmd(cmd)
+			doc, _, err := readEnvelope(clientCtx, cmd, args[0])
+			if err != nil {
+				return err
+			}
+			return printOutput(cmd, doc)
+		},
+	}
+
+	cmd.Flags().String(flagSender, "", "The address of the expected sender of the envelope")
+	cmd.Flags().String(flags.FlagOutputDocument, "", "The document will be written to the given file instead of STDOUT")
+
+	return cmd
+}
@@ -0,0 +1,225 @@
+package cli
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+)
+
+// newTestEnvelopeKey writes a new envelope key to the home of clientCtx
+// and returns its public key.
+func newTestEnvelopeKey(t *testing.T, clientCtx client.Context) *ecdh.PublicKey {
+	t.Helper()
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bz, err := json.Marshal(EnvelopeKey{PrivateKey: key.Bytes(), PublicKey: key.PublicKey().Bytes()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := envelopeKeyPath(clientCtx.HomeDir)
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, bz, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return key.PublicKey()
+}
+
+// resignEnvelope signs env with the key name of the keyring of clientCtx,
+// as its sender, over the hash signed by sign.
+func resignEnvelope(t *testing.T, clientCtx client.Context, env *Envelope, name string, sign func(env *Envelope) []byte) {
+	t.Helper()
+	sealed, err := sealEnvelope(clientCtx, name, []*ecdh.PublicKey{newTestEnvelopeKey(t, newTestClientCtx(t))}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.Sender = sealed.Sender
+	if env.Signature, _, err = clientCtx.Keyring.Sign(name, sign(env), signing.SignMode_SIGN_MODE_DIRECT); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenEnvelope(t *testing.T) {
+	senderCtx := newTestClientCtx(t)
+	newTestKey(t, senderCtx, "alice")
+	newTestKey(t, senderCtx, "mallory")
+	newTestEnvelopeKey(t, senderCtx)
+	recipientCtx := newTestClientCtx(t)
+	recipientKey := newTestEnvelopeKey(t, recipientCtx)
+	otherCtx := newTestClientCtx(t)
+	otherKey := newTestEnvelopeKey(t, otherCtx)
+	doc := []byte(`{"body":{"messages":[]}}`)
+
+	signBytes := func(env *Envelope) []byte {
+		bz, err := env.signBytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return bz
+	}
+
+	tests := []struct {
+		name    string
+		openCtx client.Context
+		modify  func(env *Envelope)
+		wantErr string
+	}{
+		{name: "opened", openCtx: recipientCtx},
+		{name: "other recipient", openCtx: otherCtx, wantErr: "not sealed to the envelope key"},
+		{
+			name:    "tampered ciphertext",
+			openCtx: recipientCtx,
+			modify:  func(env *Envelope) { env.Ciphertext[0] ^= 1 },
+			wantErr: "invalid envelope signature",
+		},
+		{
+			name:    "tampered sender envelope key",
+			openCtx: recipientCtx,
+			modify:  func(env *Envelope) { env.Sender.EnvelopeKey = otherKey.Bytes() },
+			wantErr: "invalid envelope signature",
+		},
+		{
+			name:    "re-signed by another sender",
+			openCtx: recipientCtx,
+			modify:  func(env *Envelope) { resignEnvelope(t, senderCtx, env, "mallory", signBytes) },
+			wantErr: "cannot unwrap the envelope content key",
+		},
+		{
+			name:    "re-signed with another recipient",
+			openCtx: recipientCtx,
+			modify: func(env *Envelope) {
+				env.Recipients = append(env.Recipients, EnvelopeRecipient{EnvelopeKey: otherKey.Bytes(), WrappedKey: env.Recipients[0].WrappedKey})
+				resignEnvelope(t, senderCtx, env, "alice", signBytes)
+			},
+			wantErr: "cannot unwrap the envelope content key",
+		},
+		{
+			name:    "unprefixed signature",
+			openCtx: recipientCtx,
+			modify: func(env *Envelope) {
+				resignEnvelope(t, senderCtx, env, "alice", func(env *Envelope) []byte {
+					bz := signBytes(env)
+					if !strings.HasPrefix(string(bz), envelopeSignPrefix) {
+						t.Fatalf("sign bytes %X are not prefixed", bz)
+					}
+					hash := sha256.Sum256(bz)
+					return hash[:]
+				})
+			},
+			wantErr: "invalid envelope signature",
+		},
+		{
+			name:    "unknown version",
+			openCtx: recipientCtx,
+			modify:  func(env *Envelope) { env.Version = 2 },
+			wantErr: "unsupported envelope version 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := sealEnvelope(senderCtx, "alice", []*ecdh.PublicKey{recipientKey}, doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the envelope goes through its JSON, as carried
+			bz, err := json.MarshalIndent(env, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			env = &Envelope{}
+			if err := json.Unmarshal(bz, env); err != nil {
+				t.Fatal(err)
+			}
+			if tt.modify != nil {
+				tt.modify(env)
+			}
+			opened, err := openEnvelope(tt.openCtx, env)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			case tt.wantErr == "" && string(opened) != string(doc):
+				t.Fatalf("got document %s, want %s", opened, doc)
+			}
+		})
+	}
+}
+
+func TestCheckReplyRecipient(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	env := &Envelope{Sender: EnvelopeSender{Address: alice.String()}}
+
+	tests := []struct {
+		name    string
+		trust   string
+		sender  string
+		wantErr bool
+	}{
+		{name: "unknown sender", wantErr: true},
+		{name: "known sender", trust: trustKnown},
+		{name: "untrusted sender", trust: trustUntrusted, wantErr: true},
+		{name: "checked sender", sender: alice.String()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientCtx := clientCtx.WithHomeDir(t.TempDir())
+			if tt.trust != "" {
+				book := &AddressBook{Entries: []AddressBookEntry{{Address: alice.String(), Label: "alice", Trust: tt.trust}}}
+				if err := book.Save(addressBookPath(clientCtx.HomeDir)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			cmd := &cobra.Command{}
+			cmd.Flags().String(flagSender, tt.sender, "")
+
+			err := checkReplyRecipient(clientCtx, cmd, env)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSealEnvelopeKeyType(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	newTestKey(t, clientCtx, "alice")
+	if _, err := clientCtx.Keyring.SaveOfflineKey("offline", testPubKey(t, clientCtx, "alice")); err != nil {
+		t.Fatal(err)
+	}
+	recipient := newTestEnvelopeKey(t, newTestClientCtx(t))
+
+	tests := []struct {
+		name    string
+		from    string
+		wantErr string
+	}{
+		{name: "local key", from: "alice"},
+		{name: "offline key", from: "offline", wantErr: "key offline of type offline cannot sign envelopes, only local keys can"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := sealEnvelope(clientCtx, tt.from, []*ecdh.PublicKey{recipient}, []byte("document"))
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
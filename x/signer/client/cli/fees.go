@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"cosmossdk.io/math"
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const flagAllowHighFee = "allow-high-fee"
+
+// feeCheck is the result of the comparison of a transaction fee with the
+// chain profile: warnings about fees likely to be rejected, and errors about
+// excessive fees.
+type feeCheck struct {
+	Warnings []string
+	Errors   []string
+}
+
+// checkFeeBounds compares the fee with the gas price bounds and maximum fees
+// of the chain profile. Without any of them, the fee is not checked, which
+// is reported with a warning.
+func checkFeeBounds(profile *ChainProfile, fee sdk.Coins, gas uint64) feeCheck {
+	var check feeCheck
+	if profile == nil {
+		check.Warnings = append(check.Warnings, "no chain profile, the fee is not checked")
+		return check
+	}
+	if len(profile.MaxFees) == 0 && len(profile.Fees.FeeTokens) == 0 {
+		check.Warnings = append(check.Warnings, fmt.Sprintf("the chain profile of chain %s has no fee bounds, the fee is not checked", profile.ChainID))
+		return check
+	}
+
+	for _, max := range profile.MaxFees {
+		if amount := fee.AmountOf(max.Denom); amount.GT(max.Amount) {
+			check.Errors = append(check.Errors, fmt.Sprintf("fee %s%s exceeds the maximum fee %s", amount, max.Denom, max))
+		}
+	}
+	if len(profile.Fees.FeeTokens) == 0 {
+		return check
+	}
+	if gas == 0 {
+		check.Warnings = append(check.Warnings, "gas limit is 0")
+		return check
+	}
+
+	gasLimit := math.LegacyNewDec(int64(gas))
+	for _, coin := range fee {
+		token, ok := profile.feeToken(coin.Denom)
+		if !ok {
+			check.Warnings = append(check.Warnings, fmt.Sprintf("%s is not a fee token of chain %s", coin.Denom, profile.ChainID))
+			continue
+		}
+		gasPrice := math.LegacyNewDecFromInt(coin.Amount).Quo(gasLimit)
+		if max := token.maxGasPrice(); max != nil && gasPrice.GT(max.LegacyDec) {
+			check.Errors = append(check.Errors, fmt.Sprintf("gas price %s%s exceeds the maximum gas price %s%s", gasPrice, coin.Denom, max, coin.Denom))
+		}
+		if min := token.minGasPrice(); min != nil && gasPrice.LT(min.LegacyDec) {
+			check.Warnings = append(check.Warnings, fmt.Sprintf("gas price %s%s is below the minimum gas price %s%s, the transaction is likely to be rejected", gasPrice, coin.Denom, min, coin.Denom))
+		}
+	}
+	if fee.IsZero() {
+		check.Warnings = append(check.Warnings, "no fee, the transaction is likely to be rejected")
+	}
+	return check
+}
+
+// getFeeCheck compares the fee of the transaction with the chain profile.
+func getFeeCheck(clientCtx client.Context, tx sdk.Tx) (feeCheck, error) {
+	profile, err := loadChainProfile(clientCtx)
+	if err != nil {
+		return feeCheck{}, err
+	}
+	protoTx, err := getProtoTx(tx)
+	if err != nil {
+		return feeCheck{}, err
+	}
+	fee := protoTx.AuthInfo.GetFee()
+	return checkFeeBounds(profile, fee.GetAmount(), fee.GetGasLimit()), nil
+}
+
+// checkFee warns about fees likely to be rejected, and refuses excessive fees
+// unless --allow-high-fee is set, according to the chain profile.
+func checkFee(clientCtx client.Context, cmd *cobra.Command, tx sdk.Tx) error {
+	check, err := getFeeCheck(clientCtx, tx)
+	if err != nil {
+		return err
+	}
+
+	for _, warning := range check.Warnings {
+		cmd.PrintErrf("WARNING: %s\n", warning)
+	}
+	if len(check.Errors) == 0 {
+		return nil
+	}
+	allowHighFee, err := cmd.Flags().GetBool(flagAllowHighFee)
+	if err != nil {
+		return err
+	}
+	if allowHighFee {
+		for _, e := range check.Errors {
+			cmd.PrintErrf("WARNING: %s\n", e)
+		}
+		return nil
+	}
+	return fmt.Errorf("excessive fee: %s; use --%s to sign anyway", strings.Join(check.Errors, ", "), flagAllowHighFee)
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const testChainProfile = `{
+  "chain_id": "test-chain",
+  "fees": {
+    "fee_tokens": [
+      {"denom": "uatom", "fixed_min_gas_price": 0.005, "low_gas_price": 0.01, "high_gas_price": 0.03}
+    ]
+  },
+  "max_fees": [{"denom": "uatom", "amount": "50000"}]
+}`
+
+// writeTestChainProfile writes the chain profile of the test chain to the
+// home of clientCtx.
+func writeTestChainProfile(t *testing.T, clientCtx client.Context, profile string) {
+	t.Helper()
+	file := chainProfilePath(clientCtx.HomeDir, testChainID)
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(profile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckFeeBounds(t *testing.T) {
+	var profile ChainProfile
+	if err := json.Unmarshal([]byte(testChainProfile), &profile); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		profile     *ChainProfile
+		fee         sdk.Coins
+		gas         uint64
+		wantWarning string
+		wantErr     string
+	}{
+		{name: "no profile", fee: sdk.NewCoins(sdk.NewInt64Coin("uatom", 1000000)), gas: 200000, wantWarning: "no chain profile, the fee is not checked"},
+		{name: "no fee bounds", profile: &ChainProfile{ChainID: testChainID}, fee: sdk.NewCoins(sdk.NewInt64Coin("uatom", 1000000)), gas: 200000, wantWarning: "has no fee bounds"},
+		{name: "within bounds", profile: &profile, fee: sdk.NewCoins(sdk.NewInt64Coin("uatom", 2000)), gas: 200000},
+		{name: "below the minimum gas price", profile: &profile, fee: sdk.NewCoins(sdk.NewInt64Coin("uatom", 500)), gas: 200000, wantWarning: "below the minimum gas price 0.005"},
+		{name: "above the maximum gas price", profile: &profile, fee: sdk.NewCoins(sdk.NewInt64Coin("uatom", 40000)), gas: 100000, wantErr: "exceeds the maximum gas price 0.3"},
+		{name: "above the maximum fee", profile: &profile, fee: sdk.NewCoins(sdk.NewInt64Coin("uatom", 60000)), gas: 1000000, wantErr: "exceeds the maximum fee 50000uatom"},
+		{name: "not a fee token", profile: &profile, fee: sdk.NewCoins(sdk.NewInt64Coin("ufoo", 100)), gas: 200000, wantWarning: "ufoo is not a fee token"},
+		{name: "no fee", profile: &profile, gas: 200000, wantWarning: "no fee"},
+		{name: "no gas", profile: &profile, fee: sdk.NewCoins(sdk.NewInt64Coin("uatom", 2000)), wantWarning: "gas limit is 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := checkFeeBounds(tt.profile, tt.fee, tt.gas)
+			warnings, errs := strings.Join(check.Warnings, "\n"), strings.Join(check.Errors, "\n")
+			if (tt.wantWarning == "") != (warnings == "") || !strings.Contains(warnings, tt.wantWarning) {
+				t.Fatalf("got warnings %q, want %q", warnings, tt.wantWarning)
+			}
+			if (tt.wantErr == "") != (errs == "") || !strings.Contains(errs, tt.wantErr) {
+				t.Fatalf("got errors %q, want %q", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSignFeeBounds(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	bob := newTestKey(t, clientCtx, "bob")
+
+	tests := []struct {
+		name        string
+		noProfile   bool
+		fee         int64
+		args        []string
+		wantWarning string
+		wantErr     string
+	}{
+		{name: "within bounds", fee: 2000},
+		{name: "no profile", noProfile: true, fee: 60000, wantWarning: "no chain profile, the fee is not checked"},
+		{name: "low fee", fee: 500, wantWarning: "is below the minimum gas price"},
+		{name: "excessive fee", fee: 60000, wantErr: "excessive fee"},
+		{name: "allowed excessive fee", fee: 60000, args: []string{"--" + flagAllowHighFee}, wantWarning: "exceeds the maximum fee"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientCtx := clientCtx.WithHomeDir(t.TempDir())
+			if !tt.noProfile {
+				writeTestChainProfile(t, clientCtx, testChainProfile)
+			}
+			fee := sdk.NewCoins(sdk.NewInt64Coin("uatom", tt.fee))
+			file := writeTestTx(t, clientCtx, newTestTx(t, clientCtx, fee, 200000, newTestSend(alice, bob, 10)))
+
+			cmd := GetSignCommand()
+			stderr := &bytes.Buffer{}
+			cmd.SetErr(stderr)
+			args := append([]string{file, "--" + flags.FlagOffline, "--from", "alice", "-a", "1", "-s", "5"}, tt.args...)
+			_, err := runTestCommand(t, clientCtx, cmd, args...)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			case tt.wantErr != "":
+				return
+			}
+			if (tt.wantWarning == "") != (stderr.Len() == 0) || !strings.Contains(stderr.String(), tt.wantWarning) {
+				t.Fatalf("got warnings %q, want %q", stderr, tt.wantWarning)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+)
+
+const (
+	// hooksFile is the hooks configuration, in the config directory of the
+	// home.
+	hooksFile = "hooks.json"
+
+	// defaultHookTimeout is the time a hook may run for, unless the hooks
+	// configuration sets another one.
+	defaultHookTimeout = time.Minute
+)
+
+// HooksConfig lists the hook executables run around signing, each with its
+// arguments, and the time they may run for before being killed.
+type HooksConfig struct {
+	PreSign  [][]string `json:"pre_sign"`
+	PostSign [][]string `json:"post_sign"`
+	Timeout  Duration   `json:"timeout,omitempty"`
+}
+
+// postSignInput is the input of the post-sign hooks.
+type postSignInput struct {
+	ChainID string          `json:"chain_id"`
+	Signers []string        `json:"signers"`
+	TxHash  string          `json:"tx_hash"`
+	Tx      json.RawMessage `json:"tx"`
+}
+
+// LoadHooksConfig loads the hooks configuration from file. No hooks are
+// returned if the file does not exist.
+func LoadHooksConfig(file string) (*HooksConfig, error) {
+	bz, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return &HooksConfig{Timeout: Duration(defaultHookTimeout)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var config HooksConfig
+	if err := json.Unmarshal(bz, &config); err != nil {
+		return nil, fmt.Errorf("invalid hooks config %s: %w", file, err)
+	}
+	if config.Timeout == 0 {
+		config.Timeout = Duration(defaultHookTimeout)
+	}
+	for _, hook := range append(config.PreSign, config.PostSign...) {
+		if len(hook) == 0 {
+			return nil, fmt.Errorf("invalid hooks config %s: empty hook command", file)
+		}
+	}
+	return &config, nil
+}
+
+func loadHooks(clientCtx client.Context) (*HooksConfig, error) {
+	return LoadHooksConfig(filepath.Join(clientCtx.HomeDir, "config", hooksFile))
+}
+
+// runHook runs a hook with input on its standard input, and kills it after
+// timeout. Its outputs go to the command error output, so that they do not
+// mix with the signed transaction.
+func runHook(cmd *cobra.Command, hook []string, input []byte, env []string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+	defer cancel()
+	c := exec.CommandContext(ctx, hook[0], hook[1:]...)
+	c.Stdin = bytes.NewReader(input)
+	c.Stdout = cmd.ErrOrStderr()
+	c.Stderr = cmd.ErrOrStderr()
+	c.Env = append(os.Environ(), env...)
+	// the outputs may be held open by children of the killed hook
+	c.WaitDelay = time.Second
+	err := c.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	return err
+}
+
+func hookEnv(chainID string, signers []signingKey) []string {
+	addrs := make([]string, len(signers))
+	for i, signer := range signers {
+		addrs[i] = signer.Address.String()
+	}
+	return []string{
+		"COSMOS_SIGNER_CHAIN_ID=" + chainID,
+		"COSMOS_SIGNER_SIGNERS=" + strings.Join(addrs, ","),
+	}
+}
+
+// runPreSignHooks runs the pre-sign hooks with the transaction JSON on
+// their standard input. A hook exiting with a non-zero status vetoes the
+// signature.
+func runPreSignHooks(clientCtx client.Context, cmd *cobra.Command, hooks *HooksConfig, txJSON []byte, keys []signingKey) error {
+	for _, hook := range hooks.PreSign {
+		if err := runHook(cmd, hook, txJSON, hookEnv(clientCtx.ChainID, keys), time.Duration(hooks.Timeout)); err != nil {
+			return fmt.Errorf("signature vetoed by pre-sign hook %s: %w", hook[0], err)
+		}
+	}
+	return nil
+}
+
+// runPostSignHooks runs the post-sign hooks with the signed transaction,
+// its hash and the signers on their standard input.
+func runPostSignHooks(clientCtx client.Context, cmd *cobra.Command, hooks *HooksConfig, signedTxJSON []byte, txHash string, keys []signingKey) error {
+	if len(hooks.PostSign) == 0 {
+		return nil
+	}
+	env := hookEnv(clientCtx.ChainID, keys)
+	input := postSignInput{
+		ChainID: clientCtx.ChainID,
+		TxHash:  txHash,
+		Tx:      signedTxJSON,
+	}
+	for _, key := range keys {
+		input.Signers = append(input.Signers, key.Address.String())
+	}
+	bz, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	env = append(env, "COSMOS_SIGNER_TX_HASH="+txHash)
+	for _, hook := range hooks.PostSign {
+		if err := runHook(cmd, hook, bz, env, time.Duration(hooks.Timeout)); err != nil {
+			return fmt.Errorf("post-sign hook %s failed, the transaction was signed: %w", hook[0], err)
+		}
+	}
+	return nil
+}
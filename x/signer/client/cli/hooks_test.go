@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+)
+
+// writeTestHook writes a shell script hook to a temporary directory and
+// returns its path.
+func writeTestHook(t *testing.T, script string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "hook.sh")
+	if err := os.WriteFile(file, []byte("#!/bin/sh\n"+script+"\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// writeTestHooksConfig writes the hooks configuration to the home of
+// clientCtx.
+func writeTestHooksConfig(t *testing.T, clientCtx client.Context, config HooksConfig) {
+	t.Helper()
+	bz, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(clientCtx.HomeDir, "config", hooksFile)
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, bz, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadHooksConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		wantTimeout time.Duration
+		wantErr     string
+	}{
+		{name: "no config", wantTimeout: defaultHookTimeout},
+		{name: "default timeout", config: `{"pre_sign": [["true"]]}`, wantTimeout: defaultHookTimeout},
+		{name: "timeout", config: `{"pre_sign": [["true"]], "timeout": "5s"}`, wantTimeout: 5 * time.Second},
+		{name: "negative timeout", config: `{"timeout": "-5s"}`, wantErr: "-5s"},
+		{name: "empty hook", config: `{"post_sign": [[]]}`, wantErr: "empty hook command"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), hooksFile)
+			if tt.config != "" {
+				if err := os.WriteFile(file, []byte(tt.config), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			config, err := LoadHooksConfig(file)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			case tt.wantErr != "":
+				return
+			}
+			if got := time.Duration(config.Timeout); got != tt.wantTimeout {
+				t.Fatalf("got timeout %s, want %s", got, tt.wantTimeout)
+			}
+		})
+	}
+}
+
+func TestSignHooks(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	bob := newTestKey(t, clientCtx, "bob")
+
+	tests := []struct {
+		name      string
+		preSign   string
+		postSign  string
+		timeout   time.Duration
+		wantInput []string
+		wantErr   string
+	}{
+		{
+			name:      "pre-sign input",
+			preSign:   `cat > "$HOOK_OUTPUT"; echo "$COSMOS_SIGNER_CHAIN_ID $COSMOS_SIGNER_SIGNERS" >> "$HOOK_OUTPUT"`,
+			wantInput: []string{bob.String(), testChainID + " " + alice.String()},
+		},
+		{name: "pre-sign veto", preSign: "exit 3", wantErr: "signature vetoed by pre-sign hook"},
+		{name: "pre-sign timeout", preSign: "exec sleep 10", timeout: 100 * time.Millisecond, wantErr: "timed out after 100ms"},
+		{
+			name:      "post-sign input",
+			postSign:  `cat > "$HOOK_OUTPUT"; echo "$COSMOS_SIGNER_TX_HASH" >> "$HOOK_OUTPUT"`,
+			wantInput: []string{`"chain_id":"` + testChainID + `"`, `"signers":["` + alice.String() + `"]`, `"tx_hash":"`, `"signatures":["`},
+		},
+		{name: "post-sign failure", postSign: "exit 1", wantErr: "the transaction was signed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientCtx := clientCtx.WithHomeDir(t.TempDir())
+			hookOutput := filepath.Join(t.TempDir(), "input")
+			t.Setenv("HOOK_OUTPUT", hookOutput)
+			config := HooksConfig{Timeout: Duration(tt.timeout)}
+			if tt.preSign != "" {
+				config.PreSign = [][]string{{writeTestHook(t, tt.preSign)}}
+			}
+			if tt.postSign != "" {
+				config.PostSign = [][]string{{writeTestHook(t, tt.postSign)}}
+			}
+			writeTestHooksConfig(t, clientCtx, config)
+			file := writeTestTx(t, clientCtx, newTestTx(t, clientCtx, nil, 200000, newTestSend(alice, bob, 10)))
+
+			args := []string{file, "--" + flags.FlagOffline, "--from", "alice", "-a", "1", "-s", "5"}
+			_, err := runTestCommand(t, clientCtx, GetSignCommand(), args...)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			case tt.wantErr != "":
+				return
+			}
+
+			input, err := os.ReadFile(hookOutput)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.wantInput {
+				if !strings.Contains(string(input), want) {
+					t.Fatalf("hook input %q does not contain %q", input, want)
+				}
+			}
+		})
+	}
+}
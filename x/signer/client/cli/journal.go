@@ -0,0 +1,285 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	dbm "github.com/cosmos/cosmos-db"
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+)
+
+const (
+	// journalDBName is the name of the sequence journal database, in the
+	// data directory of the home.
+	journalDBName = "journal"
+
+	sequencePrefix = "sequence/"
+)
+
+// journalEntry is a signature recorded in the sequence journal.
+type journalEntry struct {
+	AccountNumber uint64    `json:"account_number,string"`
+	Sequence      uint64    `json:"sequence,string"`
+	SignBytesHash string    `json:"sign_bytes_hash"`
+	Time          time.Time `json:"time"`
+}
+
+// sequenceJournal records the sign bytes signed for every account sequence,
+// so that two different transactions are never signed with the same
+// sequence, similarly to the double-sign protection of validators.
+type sequenceJournal struct {
+	db dbm.DB
+}
+
+// journalSignature is a signature to check against the journal. Account is
+// the account signed for, which differs from Signer for multisig accounts.
+type journalSignature struct {
+	Signer        string
+	Account       string
+	AccountNumber uint64
+	Sequence      uint64
+	SignMode      signing.SignMode
+	SignBytes     []byte
+}
+
+// openJournal opens the sequence journal of the home directory.
+func openJournal(home string) (*sequenceJournal, error) {
+	db, err := dbm.NewGoLevelDB(journalDBName, filepath.Join(home, "data"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("open sequence journal: %w", err)
+	}
+	return &sequenceJournal{db: db}, nil
+}
+
+// Close closes the journal database.
+func (j *sequenceJournal) Close() error {
+	return j.db.Close()
+}
+
+// sequenceKey returns the key of a sequence, big endian encoded so that the
+// sequences of an account are iterated in order.
+func sequenceKey(chainID, addr string, sequence uint64) []byte {
+	key := []byte(sequencePrefix + chainID + "/" + addr + "/")
+	return binary.BigEndian.AppendUint64(key, sequence)
+}
+
+// Get returns the journal entry of a sequence, nil if it was never signed.
+func (j *sequenceJournal) Get(chainID, addr string, sequence uint64) (*journalEntry, error) {
+	bz, err := j.db.Get(sequenceKey(chainID, addr, sequence))
+	if err != nil || bz == nil {
+		return nil, err
+	}
+	var entry journalEntry
+	if err := json.Unmarshal(bz, &entry); err != nil {
+		return nil, fmt.Errorf("invalid journal entry: %w", err)
+	}
+	return &entry, nil
+}
+
+// NextSequence returns the sequence following the last one signed for addr
+// on chainID, and false if no sequence was signed.
+func (j *sequenceJournal) NextSequence(chainID, addr string) (uint64, bool, error) {
+	prefix := []byte(sequencePrefix + chainID + "/" + addr + "/")
+	it, err := dbm.IteratePrefix(j.db, prefix)
+	if err != nil {
+		return 0, false, err
+	}
+	defer it.Close()
+
+	var (
+		next  uint64
+		found bool
+	)
+	for ; it.Valid(); it.Next() {
+		next, found = binary.BigEndian.Uint64(it.Key()[len(prefix):])+1, true
+	}
+	return next, found, it.Error()
+}
+
+// Check refuses signatures of sign bytes that differ from the ones already
+// signed with the same account sequence.
+func (j *sequenceJournal) Check(chainID string, sigs []journalSignature) error {
+	for _, sig := range sigs {
+		entry, err := j.Get(chainID, sig.Account, sig.Sequence)
+		if err != nil {
+			return err
+		}
+		if entry == nil || entry.SignBytesHash == signBytesHash(sig.SignBytes) {
+			continue
+		}
+		msg := fmt.Sprintf("sequence %d of account %s on chain %s was already used on %s to sign a different transaction (sign bytes %s)",
+			sig.Sequence, sig.Account, chainID, entry.Time.Format(time.RFC3339), entry.SignBytesHash)
+		if next, ok, err := j.NextSequence(chainID, sig.Account); err == nil && ok {
+			msg += fmt.Sprintf("; the next unused sequence is %d", next)
+		}
+		return errors.New(msg)
+	}
+	return nil
+}
+
+// Record records the signatures in the journal.
+func (j *sequenceJournal) Record(chainID string, sigs []journalSignature) error {
+	now := time.Now().UTC()
+	batch := j.db.NewBatch()
+	defer batch.Close()
+	for _, sig := range sigs {
+		bz, err := json.Marshal(journalEntry{
+			AccountNumber: sig.AccountNumber,
+			Sequence:      sig.Sequence,
+			SignBytesHash: signBytesHash(sig.SignBytes),
+			Time:          now,
+		})
+		if err != nil {
+			return err
+		}
+		if err := batch.Set(sequenceKey(chainID, sig.Account, sig.Sequence), bz); err != nil {
+			return err
+		}
+	}
+	return batch.WriteSync()
+}
+
+func signBytesHash(signBytes []byte) string {
+	hash := sha256.Sum256(signBytes)
+	return hex.EncodeToString(hash[:])
+}
+
+// getJournalSignatures returns the sign bytes of the pending signatures of
+// the local signers, set empty in the transaction, on behalf of the signing
+// keys: the keys themselves, or the multisig account.
+func getJournalSignatures(ctx context.Context, clientCtx client.Context, tx sdk.Tx, signers []localSigner, keys []signingKey) ([]journalSignature, error) {
+	sigTx, ok := tx.(authsigning.Tx)
+	if !ok {
+		return nil, errors.New("invalid transaction type")
+	}
+	txSigs, err := sigTx.GetSignaturesV2()
+	if err != nil {
+		return nil, err
+	}
+
+	var sigs []journalSignature
+	for i, signer := range signers {
+		account := keys[0].Address
+		if len(keys) == len(signers) {
+			account = keys[i].Address
+		}
+		j, ok := findPendingSignature(txSigs, signer.Address.String())
+		if !ok {
+			return nil, fmt.Errorf("no pending signature of %s in the transaction", signer.Address)
+		}
+		data, ok := txSigs[j].Data.(*signing.SingleSignatureData)
+		if !ok {
+			return nil, fmt.Errorf("unexpected signature data of %s", signer.Address)
+		}
+		signBytes, err := authsigning.GetSignBytesAdapter(ctx, clientCtx.TxConfig.SignModeHandler(), data.SignMode, authsigning.SignerData{
+			ChainID:       clientCtx.ChainID,
+			AccountNumber: signer.AccountNumber,
+			Sequence:      signer.Sequence,
+			PubKey:        signer.PubKey,
+			Address:       signer.Address.String(),
+		}, sigTx)
+		if err != nil {
+			return nil, err
+		}
+		sigs = append(sigs, journalSignature{
+			Signer:        signer.Address.String(),
+			Account:       account.String(),
+			AccountNumber: signer.AccountNumber,
+			Sequence:      signer.Sequence,
+			SignMode:      data.SignMode,
+			SignBytes:     signBytes,
+		})
+	}
+	return sigs, nil
+}
+
+// GetSequenceCommand returns the sequence command.
+func GetSequenceCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                        "sequence",
+		Short:                      "Sequence journal subcommands",
+		DisableFlagParsing:         false,
+		SuggestionsMinimumDistance: 2,
+		RunE:                       client.ValidateCmd,
+	}
+
+	cmd.AddCommand(
+		GetSequenceNextCommand(),
+	)
+
+	return cmd
+}
+
+// GetSequenceNextCommand returns the sequence next command.
+func GetSequenceNextCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "next",
+		Short: "Suggest the next sequence of an account",
+		Long: `Print the sequence following the last one signed by the --from account on
+the --chain-id chain, according to the sequence journal.
+
+tx sign records the sign bytes of every signature per chain, account and
+sequence in the journal, in the data directory of the home, and refuses to
+sign a different transaction with a sequence that was already used.
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			clientCtx, err := client.GetClientTxContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			journal, err := openJournal(clientCtx.HomeDir)
+			if err != nil {
+				return err
+			}
+			defer journal.Close()
+
+			next, ok, err := journal.NextSequence(clientCtx.ChainID, clientCtx.FromAddress.String())
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("no sequence of %s on chain %s in the journal", clientCtx.FromAddress, clientCtx.ChainID)
+			}
+			cmd.Println(next)
+			return nil
+		},
+	}
+
+	cmd.Flags().String(flags.FlagFrom, "", "Name or address of the account")
+	cmd.Flags().String(flags.FlagChainID, "", "The network chain ID")
+	flags.AddKeyringFlags(cmd.Flags())
+	_ = cmd.MarkFlagRequired(flags.FlagFrom)
+
+	return cmd
+}
+
+// checkSequenceJournal checks the signatures against the journal of the
+// home directory and records them. It is called before the keys sign, so that
+// no signature is ever made without being journaled.
+func checkSequenceJournal(clientCtx client.Context, chainID string, sigs []journalSignature) error {
+	journal, err := openJournal(clientCtx.HomeDir)
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+
+	if err := journal.Check(chainID, sigs); err != nil {
+		return err
+	}
+	return journal.Record(chainID, sigs)
+}
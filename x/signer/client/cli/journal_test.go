@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestSequenceJournal(t *testing.T) {
+	journal, err := openJournal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	signed := []journalSignature{
+		{Signer: "alice", Account: "alice", Sequence: 3, SignBytes: []byte("tx1")},
+		{Signer: "alice", Account: "alice", Sequence: 4, SignBytes: []byte("tx2")},
+		{Signer: "alice", Account: "multisig", Sequence: 7, SignBytes: []byte("tx3")},
+	}
+	if err := journal.Record(testChainID, signed); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		chainID string
+		sig     journalSignature
+		wantErr string
+	}{
+		{name: "same sign bytes", chainID: testChainID, sig: journalSignature{Account: "alice", Sequence: 3, SignBytes: []byte("tx1")}},
+		{name: "new sequence", chainID: testChainID, sig: journalSignature{Account: "alice", Sequence: 5, SignBytes: []byte("tx4")}},
+		{name: "other account", chainID: testChainID, sig: journalSignature{Account: "bob", Sequence: 3, SignBytes: []byte("tx4")}},
+		{name: "other chain", chainID: "other-chain", sig: journalSignature{Account: "alice", Sequence: 3, SignBytes: []byte("tx4")}},
+		{
+			name:    "different sign bytes",
+			chainID: testChainID,
+			sig:     journalSignature{Account: "alice", Sequence: 3, SignBytes: []byte("tx4")},
+			wantErr: "the next unused sequence is 5",
+		},
+		{
+			name:    "multisig account",
+			chainID: testChainID,
+			sig:     journalSignature{Signer: "bob", Account: "multisig", Sequence: 7, SignBytes: []byte("tx4")},
+			wantErr: "sequence 7 of account multisig",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := journal.Check(tt.chainID, []journalSignature{tt.sig})
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	for _, tt := range []struct {
+		chainID, addr string
+		next          uint64
+		found         bool
+	}{
+		{testChainID, "alice", 5, true},
+		{testChainID, "multisig", 8, true},
+		{testChainID, "bob", 0, false},
+		{"other-chain", "alice", 0, false},
+	} {
+		next, found, err := journal.NextSequence(tt.chainID, tt.addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if next != tt.next || found != tt.found {
+			t.Fatalf("got next sequence %d (%t) of %s on %s, want %d (%t)", next, found, tt.addr, tt.chainID, tt.next, tt.found)
+		}
+	}
+}
+
+func TestSignSequenceJournal(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	bob := newTestKey(t, clientCtx, "bob")
+	clientCtx = clientCtx.WithHomeDir(t.TempDir())
+	send := newTestTx(t, clientCtx, nil, 200000, newTestSend(alice, bob, 10))
+	otherSend := newTestTx(t, clientCtx, nil, 200000, newTestSend(alice, bob, 11))
+
+	// the steps share the journal of the home directory
+	tests := []struct {
+		name     string
+		tx       sdk.Tx
+		sequence string
+		wantErr  string
+	}{
+		{name: "first signature", tx: send, sequence: "5"},
+		{name: "same transaction", tx: send, sequence: "5"},
+		{name: "other transaction", tx: otherSend, sequence: "5", wantErr: "the next unused sequence is 6"},
+		{name: "next sequence", tx: otherSend, sequence: "6"},
+	}
+	for _, tt := range tests {
+		args := []string{writeTestTx(t, clientCtx, tt.tx), "--" + flags.FlagOffline, "--from", "alice", "-a", "1", "-s", tt.sequence}
+		_, err := runTestCommand(t, clientCtx, GetSignCommand(), args...)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Fatalf("%s: expected an error containing %q, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	dbm "github.com/cosmos/cosmos-db"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const (
+	// ledgerDBName is the name of the spending ledger database, in the data
+	// directory of the home.
+	ledgerDBName = "ledger"
+
+	outflowPrefix = "outflow/"
+)
+
+// outflowMsgTypes are the messages moving funds out of the sender account.
+var outflowMsgTypes = []string{
+	"/cosmos.bank.v1beta1.MsgSend",
+	"/cosmos.bank.v1beta1.MsgMultiSend",
+	"/ibc.applications.transfer.v1.MsgTransfer",
+	"/cosmos.staking.v1beta1.MsgDelegate",
+	"/cosmos.staking.v1beta1.MsgCreateValidator",
+}
+
+// outflowEntry is an outflow recorded in the ledger.
+type outflowEntry struct {
+	Time          time.Time `json:"time"`
+	Amount        sdk.Coins `json:"amount"`
+	SignBytesHash string    `json:"sign_bytes_hash"`
+}
+
+// spendingLedger keeps track of the signed outflows per chain and account,
+// so that spending limits hold across signing sessions.
+type spendingLedger struct {
+	db dbm.DB
+}
+
+// openLedger opens the spending ledger of the home directory.
+func openLedger(home string) (*spendingLedger, error) {
+	db, err := dbm.NewGoLevelDB(ledgerDBName, filepath.Join(home, "data"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("open spending ledger: %w", err)
+	}
+	return &spendingLedger{db: db}, nil
+}
+
+// Close closes the ledger database.
+func (l *spendingLedger) Close() error {
+	return l.db.Close()
+}
+
+func outflowKey(chainID, addr, id string) []byte {
+	return []byte(outflowPrefix + chainID + "/" + addr + "/" + id)
+}
+
+// outflowID returns the identifier in the ledger of the outflow of a
+// signature, its account number and sequence. The sequence journal only
+// lets a sequence be signed again over the same sign bytes, so that a
+// signature made again is not counted twice, while the same transaction
+// signed at another sequence is.
+func outflowID(accountNumber, sequence uint64) string {
+	return fmt.Sprintf("%d/%d", accountNumber, sequence)
+}
+
+// Spent returns the outflows of addr on chainID recorded since the given
+// time. The outflow id, of the signature being made, is left out.
+func (l *spendingLedger) Spent(chainID, addr, id string, since time.Time) (sdk.Coins, error) {
+	prefix := outflowKey(chainID, addr, "")
+	it, err := dbm.IteratePrefix(l.db, prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	var spent sdk.Coins
+	for ; it.Valid(); it.Next() {
+		if string(it.Key()[len(prefix):]) == id {
+			continue
+		}
+		var entry outflowEntry
+		if err := json.Unmarshal(it.Value(), &entry); err != nil {
+			return nil, fmt.Errorf("invalid ledger entry %s: %w", it.Key(), err)
+		}
+		if entry.Time.After(since) {
+			spent = spent.Add(entry.Amount...)
+		}
+	}
+	return spent, it.Error()
+}
+
+// Record records the outflow of addr on chainID for the signature id of
+// signBytes. An outflow id is only recorded again for the same sign bytes.
+func (l *spendingLedger) Record(chainID, addr, id string, signBytes []byte, amount sdk.Coins, at time.Time) error {
+	key := outflowKey(chainID, addr, id)
+	bz, err := l.db.Get(key)
+	if err != nil {
+		return err
+	}
+	if bz != nil {
+		var entry outflowEntry
+		if err := json.Unmarshal(bz, &entry); err != nil {
+			return fmt.Errorf("invalid ledger entry %s: %w", key, err)
+		}
+		if entry.SignBytesHash != signBytesHash(signBytes) {
+			return fmt.Errorf("outflow %s of account %s on chain %s was already recorded for different sign bytes %s", id, addr, chainID, entry.SignBytesHash)
+		}
+	}
+	if bz, err = json.Marshal(outflowEntry{Time: at.UTC(), Amount: amount, SignBytesHash: signBytesHash(signBytes)}); err != nil {
+		return err
+	}
+	return l.db.SetSync(key, bz)
+}
+
+// getOutflow returns the funds leaving addr in the messages. The messages
+// wrapped by authz MsgExec are executed with the authority of their sender,
+// the granter, but on behalf of the signer of the wrapper: their outflows
+// are charged to the signer of the wrapper, so that the spending limits of a
+// grantee key cannot be bypassed by wrapping its sends.
+func getOutflow(msgs []msgSummary, addr string) sdk.Coins {
+	var outflow sdk.Coins
+	for _, msg := range msgs {
+		if len(msg.Msgs) > 0 {
+			if slices.Contains(msg.Signers, addr) || slices.Contains(msg.Senders, addr) {
+				outflow = outflow.Add(getWrappedOutflow(msg.Msgs)...)
+			} else {
+				outflow = outflow.Add(getOutflow(msg.Msgs, addr)...)
+			}
+			continue
+		}
+		if !slices.Contains(outflowMsgTypes, msg.TypeURL) {
+			continue
+		}
+		if slices.Contains(msg.Senders, addr) || slices.Contains(msg.Signers, addr) {
+			outflow = outflow.Add(msg.Amount...)
+		}
+	}
+	return outflow
+}
+
+// getWrappedOutflow returns the funds leaving any account in the messages.
+func getWrappedOutflow(msgs []msgSummary) sdk.Coins {
+	var outflow sdk.Coins
+	for _, msg := range flattenMsgs(msgs) {
+		if slices.Contains(outflowMsgTypes, msg.TypeURL) {
+			outflow = outflow.Add(msg.Amount...)
+		}
+	}
+	return outflow
+}
+
+// signingOutflowIDs returns the outflow ids of the signatures of signers,
+// per account signed for: the key of the signer or, for several signers
+// with a single key, the multisig account.
+func signingOutflowIDs(signers []localSigner, keys []signingKey) map[string]string {
+	ids := make(map[string]string, len(keys))
+	for i, signer := range signers {
+		account := keys[0].Address
+		if len(keys) == len(signers) {
+			account = keys[i].Address
+		}
+		ids[account.String()] = outflowID(signer.AccountNumber, signer.Sequence)
+	}
+	return ids
+}
+
+// recordOutflows records the outflows of the signatures in the ledger, once
+// per account signed for.
+func recordOutflows(clientCtx client.Context, ledger *spendingLedger, tx sdk.Tx, sigs []journalSignature) error {
+	msgs, err := summarizeMsgs(clientCtx, tx)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	recorded := make(map[string]bool)
+	for _, sig := range sigs {
+		if recorded[sig.Account] {
+			continue
+		}
+		recorded[sig.Account] = true
+		outflow := getOutflow(msgs, sig.Account)
+		if outflow.IsZero() {
+			continue
+		}
+		if err := ledger.Record(clientCtx.ChainID, sig.Account, outflowID(sig.AccountNumber, sig.Sequence), sig.SignBytes, outflow, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
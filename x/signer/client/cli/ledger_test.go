@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/authz"
+)
+
+func TestGetOutflow(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	granter := newTestKey(t, clientCtx, "granter")
+	grantee := newTestKey(t, clientCtx, "grantee")
+	other := newTestKey(t, clientCtx, "other")
+
+	send := newTestSend(granter, other, 100)
+	exec := authz.NewMsgExec(grantee, []sdk.Msg{send, newTestSend(granter, other, 20)})
+	nested := authz.NewMsgExec(grantee, []sdk.Msg{&exec})
+	revoke := authz.NewMsgRevoke(granter, grantee, sdk.MsgTypeURL(send))
+
+	tests := []struct {
+		name string
+		msgs []sdk.Msg
+		addr sdk.AccAddress
+		want int64
+	}{
+		{name: "send of the key", msgs: []sdk.Msg{send}, addr: granter, want: 100},
+		{name: "send of another key", msgs: []sdk.Msg{send}, addr: grantee},
+		{name: "MsgExec charged to the grantee", msgs: []sdk.Msg{&exec}, addr: grantee, want: 120},
+		{name: "nested MsgExec charged to the grantee", msgs: []sdk.Msg{&nested}, addr: grantee, want: 120},
+		{name: "MsgExec not charged to the recipient", msgs: []sdk.Msg{&exec}, addr: other},
+		{name: "MsgExec and send", msgs: []sdk.Msg{&exec, newTestSend(grantee, other, 5)}, addr: grantee, want: 125},
+		{name: "no outflow", msgs: []sdk.Msg{&revoke}, addr: granter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msgs, err := summarizeMsgs(clientCtx, newTestTx(t, clientCtx, nil, 200000, tt.msgs...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := getOutflow(msgs, tt.addr.String()).AmountOf("uatom").Int64(); got != tt.want {
+				t.Fatalf("got outflow %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpendingLedger(t *testing.T) {
+	ledger, err := openLedger(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ledger.Close()
+
+	now := time.Now()
+	coins := func(amount int64) sdk.Coins { return sdk.NewCoins(sdk.NewInt64Coin("uatom", amount)) }
+	records := []struct {
+		chainID, addr, id string
+		amount            sdk.Coins
+		at                time.Time
+	}{
+		{testChainID, "addr1", outflowID(1, 1), coins(10), now.Add(-48 * time.Hour)},
+		{testChainID, "addr1", outflowID(1, 2), coins(20), now.Add(-time.Hour)},
+		{testChainID, "addr1", outflowID(1, 3), coins(30), now},
+		{testChainID, "addr2", outflowID(2, 1), coins(40), now},
+		{"other-chain", "addr1", outflowID(1, 3), coins(50), now},
+	}
+	for _, r := range records {
+		if err := ledger.Record(r.chainID, r.addr, r.id, []byte(r.id), r.amount, r.at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// an outflow is recorded again for the same sign bytes only
+	if err := ledger.Record(testChainID, "addr1", outflowID(1, 3), []byte(outflowID(1, 3)), coins(30), now); err != nil {
+		t.Fatal(err)
+	}
+	if err := ledger.Record(testChainID, "addr1", outflowID(1, 3), []byte("other"), coins(30), now); err == nil || !strings.Contains(err.Error(), "already recorded for different sign bytes") {
+		t.Fatalf("expected an error for different sign bytes, got %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		addr  string
+		id    string
+		since time.Time
+		want  int64
+	}{
+		{name: "window", addr: "addr1", since: now.Add(-24 * time.Hour), want: 50},
+		{name: "whole ledger", addr: "addr1", since: now.Add(-72 * time.Hour), want: 60},
+		{name: "signature left out", addr: "addr1", id: outflowID(1, 3), since: now.Add(-24 * time.Hour), want: 20},
+		{name: "other sequence counted", addr: "addr1", id: outflowID(1, 4), since: now.Add(-24 * time.Hour), want: 50},
+		{name: "other account", addr: "addr2", since: now.Add(-24 * time.Hour), want: 40},
+		{name: "unknown account", addr: "addr3", since: now.Add(-24 * time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spent, err := ledger.Spent(testChainID, tt.addr, tt.id, tt.since)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := spent.AmountOf("uatom").Int64(); got != tt.want {
+				t.Fatalf("got spent %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignSpendingLimit(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	bob := newTestKey(t, clientCtx, "bob")
+	policyFile := filepath.Join(t.TempDir(), "policy.json")
+	policy := `{"rules": [{"name": "daily", "spending_limits": [{"window": "24h", "max_amounts": [{"denom": "uatom", "amount": "1000"}]}]}]}`
+	if err := os.WriteFile(policyFile, []byte(policy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	file := writeTestTx(t, clientCtx, newTestTx(t, clientCtx, nil, 200000, newTestSend(alice, bob, 600)))
+
+	tests := []struct {
+		name     string
+		sequence string
+		wantErr  string
+	}{
+		{name: "first signature", sequence: "5"},
+		{name: "same signature again", sequence: "5"},
+		{name: "same transaction at the next sequence", sequence: "6", wantErr: "outflow of 1200uatom over 24h0m0s exceeds the limit 1000uatom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := runTestCommand(t, clientCtx, GetSignCommand(), file, "--"+flags.FlagOffline, "--from", "alice",
+				"--"+flags.FlagAccountNumber, "1", "--"+flags.FlagSequence, tt.sequence, "--"+flagPolicy, policyFile)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
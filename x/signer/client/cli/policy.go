@@ -0,0 +1,463 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const (
+	flagPolicy = "policy"
+
+	// defaultPolicyFile is the policy file loaded from the home config
+	// directory when --policy is not set.
+	defaultPolicyFile = "policy.json"
+)
+
+// Policy is the set of rules evaluated before every signature. A
+// transaction is signed only if it satisfies all the rules in scope for the
+// signing key and chain.
+type Policy struct {
+	Rules []PolicyRule `json:"rules"`
+	// Timeout is the timeout height rule, see TimeoutRule.
+	Timeout *TimeoutRule `json:"timeout,omitempty"`
+}
+
+// PolicyRule restricts what can be signed. The Keys and ChainIDs fields
+// define the scope of the rule, an empty scope matching every key or chain.
+// All the other fields are constraints, ignored when empty.
+type PolicyRule struct {
+	Name     string   `json:"name"`
+	Keys     []string `json:"keys,omitempty"`      // key names or addresses
+	ChainIDs []string `json:"chain_ids,omitempty"` // chain profiles the rule applies to
+
+	AllowedMsgTypes      []string  `json:"allowed_msg_types,omitempty"`
+	MaxAmounts           sdk.Coins `json:"max_amounts,omitempty"` // per denom, summed over the messages
+	AllowedRecipients    []string  `json:"allowed_recipients,omitempty"`
+	RequireMemo          bool      `json:"require_memo,omitempty"`
+	RequireTimeoutHeight bool      `json:"require_timeout_height,omitempty"`
+	ForbiddenChainIDs    []string  `json:"forbidden_chain_ids,omitempty"`
+
+	SpendingLimits []SpendingLimit `json:"spending_limits,omitempty"`
+
+	// Conditions are CEL expressions which must all evaluate to true, see
+	// newConditionEnv for their variables.
+	Conditions []string `json:"conditions,omitempty"`
+	programs   []cel.Program
+}
+
+// SpendingLimit caps the outflows of a key over a rolling window, across
+// signing sessions. The signed outflows are kept in the spending ledger.
+type SpendingLimit struct {
+	Window     Duration  `json:"window"`
+	MaxAmounts sdk.Coins `json:"max_amounts"`
+}
+
+// Duration is a time.Duration encoded as a string in JSON, e.g. "24h".
+type Duration time.Duration
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(bz []byte) error {
+	var s string
+	if err := json.Unmarshal(bz, &s); err != nil {
+		return err
+	}
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	if duration <= 0 {
+		return fmt.Errorf("invalid duration %s", s)
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+// signingKey is a key a transaction is signed with.
+type signingKey struct {
+	Name    string
+	Address sdk.AccAddress
+}
+
+// policyInput is what the policy rules are evaluated against.
+type policyInput struct {
+	ChainID       string
+	Key           signingKey
+	Msgs          []msgSummary
+	Memo          string
+	TimeoutHeight uint64
+
+	// Outflow is the funds leaving the key account in the transaction, and
+	// Spent the outflows already signed for the key, per window.
+	Outflow sdk.Coins
+	Spent   map[Duration]sdk.Coins
+}
+
+// PolicyViolation is the reason a rule denies a transaction.
+type PolicyViolation struct {
+	Rule   string `json:"rule"`
+	Key    string `json:"key"`
+	Reason string `json:"reason"`
+}
+
+// PolicyDecision is the result of the evaluation of a policy.
+type PolicyDecision struct {
+	Allowed    bool              `json:"allowed"`
+	Violations []PolicyViolation `json:"violations,omitempty"`
+}
+
+// Error returns the denial reasons.
+func (d PolicyDecision) Error() string {
+	reasons := make([]string, len(d.Violations))
+	for i, v := range d.Violations {
+		reasons[i] = fmt.Sprintf("rule %q for key %s: %s", v.Rule, v.Key, v.Reason)
+	}
+	return "transaction denied by the signing policy:\n  " + strings.Join(reasons, "\n  ")
+}
+
+// LoadPolicy loads the policy from file. A nil policy is returned if the
+// file does not exist.
+func LoadPolicy(file string) (*Policy, error) {
+	bz, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var policy Policy
+	if err := json.Unmarshal(bz, &policy); err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %w", file, err)
+	}
+	env, err := newConditionEnv()
+	if err != nil {
+		return nil, err
+	}
+	for i := range policy.Rules {
+		rule := &policy.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("#%d", i)
+		}
+		if err := rule.compile(env); err != nil {
+			return nil, fmt.Errorf("invalid policy %s: %w", file, err)
+		}
+	}
+	return &policy, nil
+}
+
+// loadPolicyFromFlags loads the policy set with --policy, or the default
+// policy of the home directory.
+func loadPolicyFromFlags(clientCtx client.Context, cmd *cobra.Command) (*Policy, error) {
+	file, err := cmd.Flags().GetString(flagPolicy)
+	if err != nil {
+		return nil, err
+	}
+	if file == "" {
+		return LoadPolicy(filepath.Join(clientCtx.HomeDir, "config", defaultPolicyFile))
+	}
+	policy, err := LoadPolicy(file)
+	if err == nil && policy == nil {
+		return nil, fmt.Errorf("policy %s not found", file)
+	}
+	return policy, err
+}
+
+// Evaluate evaluates the rules in scope of the input.
+func (p Policy) Evaluate(in policyInput) []PolicyViolation {
+	var violations []PolicyViolation
+	for _, rule := range p.Rules {
+		if !rule.inScope(in) {
+			continue
+		}
+		for _, reason := range rule.check(in) {
+			violations = append(violations, PolicyViolation{Rule: rule.Name, Key: in.Key.Address.String(), Reason: reason})
+		}
+	}
+	return violations
+}
+
+func (r PolicyRule) inScope(in policyInput) bool {
+	if len(r.ChainIDs) > 0 && !slices.Contains(r.ChainIDs, in.ChainID) {
+		return false
+	}
+	if len(r.Keys) > 0 && !slices.Contains(r.Keys, in.Key.Name) && !slices.Contains(r.Keys, in.Key.Address.String()) {
+		return false
+	}
+	return true
+}
+
+func (r PolicyRule) check(in policyInput) []string {
+	var reasons []string
+
+	if slices.Contains(r.ForbiddenChainIDs, in.ChainID) {
+		reasons = append(reasons, fmt.Sprintf("chain-id %s is forbidden", in.ChainID))
+	}
+	if r.RequireMemo && strings.TrimSpace(in.Memo) == "" {
+		reasons = append(reasons, "a memo is required")
+	}
+	if r.RequireTimeoutHeight && in.TimeoutHeight == 0 {
+		reasons = append(reasons, "a timeout height is required")
+	}
+
+	var total sdk.Coins
+	var checkMsg func(i int, msg msgSummary)
+	checkMsg = func(i int, msg msgSummary) {
+		if len(r.AllowedMsgTypes) > 0 && !slices.Contains(r.AllowedMsgTypes, msg.TypeURL) {
+			reasons = append(reasons, fmt.Sprintf("message #%d: type %s is not allowed", i, msg.TypeURL))
+		}
+		if len(r.AllowedRecipients) > 0 {
+			for _, recipient := range msg.Recipients {
+				if !slices.Contains(r.AllowedRecipients, recipient) {
+					reasons = append(reasons, fmt.Sprintf("message #%d: recipient %s is not allowed", i, recipient))
+				}
+			}
+		}
+		total = total.Add(msg.Amount...)
+		for _, inner := range msg.Msgs {
+			checkMsg(i, inner)
+		}
+	}
+	for i, msg := range in.Msgs {
+		checkMsg(i, msg)
+	}
+
+	for _, max := range r.MaxAmounts {
+		if amount := total.AmountOf(max.Denom); amount.GT(max.Amount) {
+			reasons = append(reasons, fmt.Sprintf("total amount %s%s exceeds the maximum %s", amount, max.Denom, max))
+		}
+	}
+
+	reasons = append(reasons, r.checkConditions(in, total)...)
+
+	for _, limit := range r.SpendingLimits {
+		spent := in.Spent[limit.Window].Add(in.Outflow...)
+		for _, max := range limit.MaxAmounts {
+			if amount := spent.AmountOf(max.Denom); amount.GT(max.Amount) {
+				reasons = append(reasons, fmt.Sprintf("outflow of %s%s over %s exceeds the limit %s", amount, max.Denom, time.Duration(limit.Window), max))
+			}
+		}
+	}
+
+	return reasons
+}
+
+// windows returns the spending limit windows of the policy.
+func (p Policy) windows() []Duration {
+	var windows []Duration
+	for _, rule := range p.Rules {
+		for _, limit := range rule.SpendingLimits {
+			if !slices.Contains(windows, limit.Window) {
+				windows = append(windows, limit.Window)
+			}
+		}
+	}
+	return windows
+}
+
+// evaluatePolicy evaluates the policy for every signing key. ids are the
+// outflow ids of the signatures being made per key address, left out of the
+// outflows already spent.
+func evaluatePolicy(clientCtx client.Context, policy *Policy, ledger *spendingLedger, tx sdk.Tx, keys []signingKey, ids map[string]string) (PolicyDecision, error) {
+	decision := PolicyDecision{Allowed: true}
+	if policy == nil {
+		return decision, nil
+	}
+
+	msgs, err := summarizeMsgs(clientCtx, tx)
+	if err != nil {
+		return PolicyDecision{}, err
+	}
+	protoTx, err := getProtoTx(tx)
+	if err != nil {
+		return PolicyDecision{}, err
+	}
+	now := time.Now()
+	for _, key := range keys {
+		spent := make(map[Duration]sdk.Coins)
+		for _, window := range policy.windows() {
+			spent[window], err = ledger.Spent(clientCtx.ChainID, key.Address.String(), ids[key.Address.String()], now.Add(-time.Duration(window)))
+			if err != nil {
+				return PolicyDecision{}, err
+			}
+		}
+		decision.Violations = append(decision.Violations, policy.Evaluate(policyInput{
+			ChainID:       clientCtx.ChainID,
+			Key:           key,
+			Msgs:          msgs,
+			Memo:          protoTx.Body.Memo,
+			TimeoutHeight: protoTx.Body.TimeoutHeight,
+			Outflow:       getOutflow(msgs, key.Address.String()),
+			Spent:         spent,
+		})...)
+	}
+	decision.Allowed = len(decision.Violations) == 0
+	return decision, nil
+}
+
+// checkPolicy refuses to sign if the policy denies the transaction for any
+// of the signing keys, the decision being returned as the error.
+func checkPolicy(clientCtx client.Context, cmd *cobra.Command, ledger *spendingLedger, tx sdk.Tx, signers []localSigner, keys []signingKey) (PolicyDecision, error) {
+	policy, err := loadPolicyFromFlags(clientCtx, cmd)
+	if err != nil {
+		return PolicyDecision{}, err
+	}
+	decision, err := evaluatePolicy(clientCtx, policy, ledger, tx, keys, signingOutflowIDs(signers, keys))
+	if err != nil {
+		return PolicyDecision{}, err
+	}
+	if !decision.Allowed {
+		return decision, decision
+	}
+	return decision, nil
+}
+
+// GetPolicyCommand returns the policy command.
+func GetPolicyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                        "policy",
+		Short:                      "Signing policy subcommands",
+		DisableFlagParsing:         false,
+		SuggestionsMinimumDistance: 2,
+		RunE:                       client.ValidateCmd,
+	}
+
+	cmd.AddCommand(
+		GetPolicyTestCommand(),
+	)
+
+	return cmd
+}
+
+// GetPolicyTestCommand returns the policy test command.
+func GetPolicyTestCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "test [tx-file]",
+		Short: "Dry-run the signing policy against a transaction",
+		Long: `Evaluate the signing policy against the transaction in [tx-file], as tx sign
+would do, for the --from key and the --chain-id chain, and print the decision
+along with the denial reasons. The command fails if the transaction is denied.
+
+The policy is a JSON file with a list of rules, loaded from --policy or from
+config/policy.json in the home directory:
+
+{
+  "rules": [
+    {
+      "name": "treasury",
+      "keys": ["treasury"],
+      "chain_ids": ["cosmoshub-4"],
+      "allowed_msg_types": ["/cosmos.bank.v1beta1.MsgSend"],
+      "max_amounts": [{"denom": "uatom", "amount": "100000000"}],
+      "allowed_recipients": ["cosmos1..."],
+      "require_memo": true,
+      "require_timeout_height": true,
+      "forbidden_chain_ids": ["theta-testnet-001"],
+      "spending_limits": [
+        {"window": "24h", "max_amounts": [{"denom": "uatom", "amount": "100000000000"}]}
+      ],
+      "conditions": [
+        "size(msgs) <= 10",
+        "msgs.all(m, m.type_url != '/cosmos.staking.v1beta1.MsgUndelegate')",
+        "!('uatom' in total) || total['uatom'] <= 1000000 || memo.startsWith('approved:')"
+      ]
+    }
+  ]
+}
+
+The conditions are CEL expressions (https://github.com/google/cel-spec)
+which must all evaluate to true. They are evaluated with the variables:
+
+  chain_id        the chain-id
+  key             the signing key, a map with its name and address
+  memo            the memo
+  timeout_height  the timeout height, 0 if none
+  msgs            the messages, maps with their type_url, signers, senders,
+                  recipients, amount (a map of denom to amount) and msgs,
+                  the messages wrapped by authz MsgExec
+  total           the amounts of the messages, a map of denom to amount
+  outflow         the funds leaving the key, a map of denom to amount
+
+A condition which cannot be evaluated, e.g. because of a missing map key,
+denies the transaction.
+
+Spending limits cap the outflows of a key (bank sends, IBC transfers and
+delegations, including those wrapped in authz MsgExec, which are charged to
+the grantee signing it) per chain and denom over a rolling window. The signed
+outflows are recorded in a ledger in the data directory of the home, and the
+transactions signed there are taken into account, once per account number and
+sequence signed: signing the same sign bytes again is not counted twice, but
+the same transaction signed at another sequence is.
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientTxContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			pluginsDir, err := cmd.Flags().GetString(flagPluginsDir)
+			if err != nil {
+				return err
+			}
+			tx, err := readTxFile(clientCtx, pluginsDir, args[0])
+			if err != nil {
+				return err
+			}
+
+			policy, err := loadPolicyFromFlags(clientCtx, cmd)
+			if err != nil {
+				return err
+			}
+			if policy == nil {
+				return errors.New("no signing policy found")
+			}
+			ledger, err := openLedger(clientCtx.HomeDir)
+			if err != nil {
+				return err
+			}
+			defer ledger.Close()
+
+			decision, err := evaluatePolicy(clientCtx, policy, ledger, tx, []signingKey{{Name: clientCtx.FromName, Address: clientCtx.FromAddress}}, nil)
+			if err != nil {
+				return err
+			}
+
+			bz, err := json.MarshalIndent(decision, "", "  ")
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(bz))
+			if !decision.Allowed {
+				return decision
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String(flags.FlagFrom, "", "Name or address of the key to evaluate the policy for")
+	cmd.Flags().String(flags.FlagChainID, "", "The network chain ID")
+	cmd.Flags().String(flagPluginsDir, "", "The directory to search for plugin files")
+	cmd.Flags().String(flagPolicy, "", "The policy file, config/policy.json in the home directory by default")
+	flags.AddKeyringFlags(cmd.Flags())
+	_ = cmd.MarkFlagRequired(flags.FlagFrom)
+	_ = cmd.MarkFlagRequired(flagPluginsDir)
+
+	return cmd
+}
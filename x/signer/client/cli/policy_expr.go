@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// newConditionEnv returns the CEL environment the conditions of the policy
+// rules are compiled in. The variables are:
+//
+//	chain_id        string
+//	key             map(string, string), with the name and address of the key
+//	memo            string
+//	timeout_height  int
+//	msgs            list of the messages, each a map with type_url, signers,
+//	                senders, recipients, amount and the wrapped msgs
+//	total           map(string, int), the amounts of the messages per denom
+//	outflow         map(string, int), the funds leaving the key per denom
+func newConditionEnv() (*cel.Env, error) {
+	coins := cel.MapType(cel.StringType, cel.IntType)
+	return cel.NewEnv(
+		cel.Variable("chain_id", cel.StringType),
+		cel.Variable("key", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("memo", cel.StringType),
+		cel.Variable("timeout_height", cel.IntType),
+		cel.Variable("msgs", cel.ListType(cel.MapType(cel.StringType, cel.DynType))),
+		cel.Variable("total", coins),
+		cel.Variable("outflow", coins),
+	)
+}
+
+// compile compiles the conditions of the rule.
+func (r *PolicyRule) compile(env *cel.Env) error {
+	r.programs = make([]cel.Program, len(r.Conditions))
+	for i, condition := range r.Conditions {
+		ast, iss := env.Compile(condition)
+		if iss.Err() != nil {
+			return fmt.Errorf("rule %q: condition %q: %w", r.Name, condition, iss.Err())
+		}
+		if ast.OutputType() != cel.BoolType {
+			return fmt.Errorf("rule %q: condition %q is not a boolean expression", r.Name, condition)
+		}
+		program, err := env.Program(ast)
+		if err != nil {
+			return fmt.Errorf("rule %q: condition %q: %w", r.Name, condition, err)
+		}
+		r.programs[i] = program
+	}
+	return nil
+}
+
+// checkConditions evaluates the conditions of the rule, returning the reasons
+// of the conditions which are not satisfied. A condition which cannot be
+// evaluated is not satisfied.
+func (r PolicyRule) checkConditions(in policyInput, total sdk.Coins) []string {
+	if len(r.Conditions) == 0 {
+		return nil
+	}
+	if len(r.programs) != len(r.Conditions) {
+		return []string{"conditions are not compiled"}
+	}
+	vars, err := conditionVars(in, total)
+	if err != nil {
+		return []string{fmt.Sprintf("conditions cannot be evaluated: %v", err)}
+	}
+
+	var reasons []string
+	for i, program := range r.programs {
+		out, _, err := program.Eval(vars)
+		switch {
+		case err != nil:
+			reasons = append(reasons, fmt.Sprintf("condition %q cannot be evaluated: %v", r.Conditions[i], err))
+		case out.Value() != true:
+			reasons = append(reasons, fmt.Sprintf("condition %q is not satisfied", r.Conditions[i]))
+		}
+	}
+	return reasons
+}
+
+// conditionVars returns the variables of the conditions for the input.
+func conditionVars(in policyInput, total sdk.Coins) (map[string]any, error) {
+	msgs, err := conditionMsgs(in.Msgs)
+	if err != nil {
+		return nil, err
+	}
+	totalVar, err := conditionCoins(total)
+	if err != nil {
+		return nil, err
+	}
+	outflow, err := conditionCoins(in.Outflow)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"chain_id":       in.ChainID,
+		"key":            map[string]string{"name": in.Key.Name, "address": in.Key.Address.String()},
+		"memo":           in.Memo,
+		"timeout_height": int64(in.TimeoutHeight),
+		"msgs":           msgs,
+		"total":          totalVar,
+		"outflow":        outflow,
+	}, nil
+}
+
+func conditionMsgs(msgs []msgSummary) ([]any, error) {
+	list := make([]any, len(msgs))
+	for i, msg := range msgs {
+		amount, err := conditionCoins(msg.Amount)
+		if err != nil {
+			return nil, err
+		}
+		inner, err := conditionMsgs(msg.Msgs)
+		if err != nil {
+			return nil, err
+		}
+		list[i] = map[string]any{
+			"type_url":   msg.TypeURL,
+			"signers":    append([]string{}, msg.Signers...),
+			"senders":    append([]string{}, msg.Senders...),
+			"recipients": append([]string{}, msg.Recipients...),
+			"amount":     amount,
+			"msgs":       inner,
+		}
+	}
+	return list, nil
+}
+
+func conditionCoins(coins sdk.Coins) (map[string]int64, error) {
+	m := make(map[string]int64, len(coins))
+	for _, coin := range coins {
+		if !coin.Amount.IsInt64() {
+			return nil, fmt.Errorf("amount %s is too large", coin)
+		}
+		m[coin.Denom] = coin.Amount.Int64()
+	}
+	return m, nil
+}
@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func writeTestPolicy(t *testing.T, policy string) (*Policy, error) {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(file, []byte(policy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return LoadPolicy(file)
+}
+
+func TestLoadPolicyInvalidConditions(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition string
+		wantErr   string
+	}{
+		{name: "syntax error", condition: "size(msgs) <", wantErr: "Syntax error"},
+		{name: "unknown variable", condition: "fee > 10", wantErr: "undeclared reference"},
+		{name: "not a boolean", condition: "size(msgs)", wantErr: "not a boolean"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := writeTestPolicy(t, `{"rules": [{"name": "r", "conditions": [`+quoteJSON(tt.condition)+`]}]}`)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestPolicyEvaluate(t *testing.T) {
+	key := signingKey{Name: "treasury", Address: sdk.AccAddress("treasury____________")}
+	other := sdk.AccAddress("other_______________").String()
+	send := msgSummary{
+		TypeURL:    "/cosmos.bank.v1beta1.MsgSend",
+		Senders:    []string{key.Address.String()},
+		Recipients: []string{other},
+		Amount:     sdk.NewCoins(sdk.NewInt64Coin("uatom", 600)),
+	}
+	undelegate := msgSummary{TypeURL: "/cosmos.staking.v1beta1.MsgUndelegate"}
+	exec := msgSummary{TypeURL: "/cosmos.authz.v1beta1.MsgExec", Msgs: []msgSummary{send, send}}
+
+	tests := []struct {
+		name    string
+		rule    string
+		in      policyInput
+		reasons []string
+	}{
+		{
+			name: "out of scope key",
+			rule: `{"keys": ["other"], "require_memo": true}`,
+			in:   policyInput{Key: key},
+		},
+		{
+			name: "out of scope chain",
+			rule: `{"chain_ids": ["other-chain"], "require_memo": true}`,
+			in:   policyInput{Key: key, ChainID: testChainID},
+		},
+		{
+			name:    "required memo and timeout height",
+			rule:    `{"require_memo": true, "require_timeout_height": true}`,
+			in:      policyInput{Key: key, Memo: " "},
+			reasons: []string{"a memo is required", "a timeout height is required"},
+		},
+		{
+			name:    "forbidden chain",
+			rule:    `{"forbidden_chain_ids": ["test-chain"]}`,
+			in:      policyInput{Key: key, ChainID: testChainID},
+			reasons: []string{"chain-id test-chain is forbidden"},
+		},
+		{
+			name:    "message type and recipient",
+			rule:    `{"allowed_msg_types": ["/cosmos.bank.v1beta1.MsgSend"], "allowed_recipients": ["cosmos1x"]}`,
+			in:      policyInput{Key: key, Msgs: []msgSummary{send, undelegate}},
+			reasons: []string{"recipient " + other + " is not allowed", "type /cosmos.staking.v1beta1.MsgUndelegate is not allowed"},
+		},
+		{
+			name:    "max amount summed over wrapped messages",
+			rule:    `{"max_amounts": [{"denom": "uatom", "amount": "1000"}]}`,
+			in:      policyInput{Key: key, Msgs: []msgSummary{exec}},
+			reasons: []string{"total amount 1200uatom exceeds the maximum 1000uatom"},
+		},
+		{
+			name: "spending limit",
+			rule: `{"spending_limits": [{"window": "24h", "max_amounts": [{"denom": "uatom", "amount": "1000"}]}]}`,
+			in: policyInput{
+				Key:     key,
+				Outflow: sdk.NewCoins(sdk.NewInt64Coin("uatom", 600)),
+				Spent:   map[Duration]sdk.Coins{Duration(24 * time.Hour): sdk.NewCoins(sdk.NewInt64Coin("uatom", 500))},
+			},
+			reasons: []string{"outflow of 1100uatom over 24h0m0s exceeds the limit 1000uatom"},
+		},
+		{
+			name: "satisfied conditions",
+			rule: `{"conditions": [
+				"size(msgs) <= 2",
+				"msgs.all(m, m.type_url != '/cosmos.staking.v1beta1.MsgUndelegate')",
+				"total['uatom'] <= 1200 && outflow['uatom'] == 600",
+				"key.name == 'treasury' && chain_id == 'test-chain' && timeout_height == 0"
+			]}`,
+			in: policyInput{
+				Key:     key,
+				ChainID: testChainID,
+				Msgs:    []msgSummary{exec},
+				Outflow: sdk.NewCoins(sdk.NewInt64Coin("uatom", 600)),
+			},
+		},
+		{
+			name:    "unsatisfied condition",
+			rule:    `{"conditions": ["msgs.all(m, m.type_url != '/cosmos.staking.v1beta1.MsgUndelegate')"]}`,
+			in:      policyInput{Key: key, Msgs: []msgSummary{undelegate}},
+			reasons: []string{"is not satisfied"},
+		},
+		{
+			name:    "wrapped messages in conditions",
+			rule:    `{"conditions": ["msgs.all(m, m.msgs.all(w, w.amount['uatom'] < 500))"]}`,
+			in:      policyInput{Key: key, Msgs: []msgSummary{exec}},
+			reasons: []string{"is not satisfied"},
+		},
+		{
+			name:    "condition which cannot be evaluated",
+			rule:    `{"conditions": ["total['uatom'] < 10"]}`,
+			in:      policyInput{Key: key},
+			reasons: []string{"cannot be evaluated"},
+		},
+		{
+			name:    "condition exempting approved memos",
+			rule:    `{"conditions": ["!('uatom' in total) || total['uatom'] <= 100 || memo.startsWith('approved:')"]}`,
+			in:      policyInput{Key: key, Msgs: []msgSummary{send}, Memo: "approved: ticket 42"},
+			reasons: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := writeTestPolicy(t, `{"rules": [`+tt.rule+`]}`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			violations := policy.Evaluate(tt.in)
+			if len(violations) != len(tt.reasons) {
+				t.Fatalf("got violations %v, want %v", violations, tt.reasons)
+			}
+			for i, reason := range tt.reasons {
+				if !strings.Contains(violations[i].Reason, reason) {
+					t.Fatalf("got violation %q, want %q", violations[i].Reason, reason)
+				}
+				if violations[i].Key != key.Address.String() {
+					t.Fatalf("got key %s, want %s", violations[i].Key, key.Address)
+				}
+			}
+		})
+	}
+}
+
+func quoteJSON(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
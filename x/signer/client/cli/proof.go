@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cometbft/cometbft/crypto/merkle"
+
+	"cosmossdk.io/store/rootmulti"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+)
+
+const (
+	flagTrustedAppHash = "trusted-app-hash"
+	flagTrustedHeight  = "trusted-height"
+)
+
+// accountStoreKey returns the key of the account of addr in the auth store.
+func accountStoreKey(addr []byte) []byte {
+	return append(append([]byte{}, authtypes.AddressStoreKeyPrefix.Bytes()...), addr...)
+}
+
+// accountKeyPath returns the merkle path of the account of addr, from the
+// app hash through the auth store.
+func accountKeyPath(addr []byte) string {
+	return merkle.KeyPath{}.
+		AppendKey([]byte(authtypes.StoreKey), merkle.KeyEncodingURL).
+		AppendKey(accountStoreKey(addr), merkle.KeyEncodingURL).
+		String()
+}
+
+// queryAccountProof queries the auth store value of the account of addr at
+// height, along with its ICS23 proof.
+func queryAccountProof(clientCtx client.Context, addr sdk.AccAddress, height int64) (abci.ResponseQuery, error) {
+	res, err := clientCtx.QueryABCI(abci.RequestQuery{
+		Path:   "/store/" + authtypes.StoreKey + "/key",
+		Data:   accountStoreKey(addr),
+		Height: height,
+		Prove:  true,
+	})
+	if err != nil {
+		return res, err
+	}
+	if len(res.Value) == 0 {
+		return res, fmt.Errorf("account %s not found at height %d", addr, height)
+	}
+	if res.ProofOps == nil {
+		return res, fmt.Errorf("no proof for account %s at height %d", addr, height)
+	}
+	return res, nil
+}
+
+// verify verifies the proof of the account against the app hash, and that
+// the proven value matches the account number, sequence and public key of
+// the snapshot.
+func (a AccountSnapshot) verify(clientCtx client.Context, appHash []byte) error {
+	if len(a.Value) == 0 || a.Proof == nil {
+		return errors.New("no proof")
+	}
+	_, addr, err := bech32.DecodeAndConvert(a.Address)
+	if err != nil {
+		return err
+	}
+	if err := rootmulti.DefaultProofRuntime().VerifyValue(a.Proof, appHash, accountKeyPath(addr), a.Value); err != nil {
+		return fmt.Errorf("invalid proof: %w", err)
+	}
+
+	var account sdk.AccountI
+	if err := clientCtx.Codec.UnmarshalInterface(a.Value, &account); err != nil {
+		return fmt.Errorf("invalid account value: %w", err)
+	}
+	switch {
+	case !bytes.Equal(account.GetAddress(), addr):
+		return fmt.Errorf("proven address %s does not match", account.GetAddress())
+	case account.GetAccountNumber() != a.AccountNumber:
+		return fmt.Errorf("proven account number %d does not match %d", account.GetAccountNumber(), a.AccountNumber)
+	case account.GetSequence() != a.Sequence:
+		return fmt.Errorf("proven sequence %d does not match %d", account.GetSequence(), a.Sequence)
+	}
+	if len(a.PubKey) > 0 {
+		var pubKey cryptotypes.PubKey
+		if err := clientCtx.Codec.UnmarshalInterfaceJSON(a.PubKey, &pubKey); err != nil {
+			return err
+		}
+		if account.GetPubKey() == nil || !account.GetPubKey().Equals(pubKey) {
+			return errors.New("proven public key does not match")
+		}
+	}
+	return nil
+}
+
+// verifySnapshot verifies every account of the snapshot against the trusted
+// app hash, read by the operator from the header of the block at
+// trustedHeight. The state at a height being committed in the app hash of
+// the following block, the snapshot height is bound to the proofs by
+// trustedHeight. Accounts that cannot be verified are refused.
+func verifySnapshot(clientCtx client.Context, snapshot *AccountsSnapshot, trustedAppHash string, trustedHeight int64) error {
+	appHash, err := hex.DecodeString(trustedAppHash)
+	if err != nil {
+		return fmt.Errorf("invalid --%s: %w", flagTrustedAppHash, err)
+	}
+	if trustedHeight <= 0 {
+		return fmt.Errorf("--%s is required with --%s", flagTrustedHeight, flagTrustedAppHash)
+	}
+	if snapshot.Height+1 != trustedHeight {
+		return fmt.Errorf("the accounts snapshot is at height %d, its app hash is of block %d, not of the trusted block %d",
+			snapshot.Height, snapshot.Height+1, trustedHeight)
+	}
+	if len(snapshot.AppHash) > 0 && !bytes.Equal(snapshot.AppHash, appHash) {
+		return fmt.Errorf("the app hash %s of the accounts snapshot does not match the trusted app hash %X", snapshot.AppHash, appHash)
+	}
+	if len(snapshot.Accounts) == 0 {
+		return errors.New("the accounts snapshot has no accounts to verify")
+	}
+	for _, account := range snapshot.Accounts {
+		if err := account.verify(clientCtx, appHash); err != nil {
+			return fmt.Errorf("cannot verify account %s of the accounts snapshot: %w", account.Address, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	dbm "github.com/cosmos/cosmos-db"
+
+	"cosmossdk.io/log"
+	"cosmossdk.io/store/metrics"
+	"cosmossdk.io/store/rootmulti"
+	storetypes "cosmossdk.io/store/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+)
+
+// newTestSnapshot commits the accounts of addrs to an auth store, next to a
+// bank store, and returns their snapshot at the committed height, with
+// their ICS23 proofs, and the app hash.
+func newTestSnapshot(t *testing.T, addrs ...sdk.AccAddress) (*AccountsSnapshot, []byte) {
+	t.Helper()
+	clientCtx := newTestClientCtx(t)
+	store := rootmulti.NewStore(dbm.NewMemDB(), log.NewNopLogger(), metrics.NewNoOpMetrics())
+	authKey := storetypes.NewKVStoreKey(authtypes.StoreKey)
+	store.MountStoreWithDB(authKey, storetypes.StoreTypeIAVL, nil)
+	store.MountStoreWithDB(storetypes.NewKVStoreKey(banktypes.StoreKey), storetypes.StoreTypeIAVL, nil)
+	if err := store.LoadLatestVersion(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, addr := range addrs {
+		account := authtypes.NewBaseAccount(addr, nil, uint64(i+10), uint64(i+20))
+		bz, err := clientCtx.Codec.MarshalInterface(sdk.AccountI(account))
+		if err != nil {
+			t.Fatal(err)
+		}
+		store.GetCommitKVStore(authKey).Set(accountStoreKey(addr), bz)
+	}
+	commitID := store.Commit()
+
+	snapshot := &AccountsSnapshot{ChainID: testChainID, Height: commitID.Version}
+	for i, addr := range addrs {
+		res, err := store.Query(&storetypes.RequestQuery{
+			Path:   "/" + authtypes.StoreKey + "/key",
+			Data:   accountStoreKey(addr),
+			Height: commitID.Version,
+			Prove:  true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		snapshot.Accounts = append(snapshot.Accounts, AccountSnapshot{
+			Address:       addr.String(),
+			AccountNumber: uint64(i + 10),
+			Sequence:      uint64(i + 20),
+			Value:         res.Value,
+			Proof:         res.ProofOps,
+		})
+	}
+	return snapshot, commitID.Hash
+}
+
+func TestVerifySnapshot(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	bob := newTestKey(t, clientCtx, "bob")
+
+	tests := []struct {
+		name    string
+		modify  func(s *AccountsSnapshot, appHash []byte) ([]byte, int64)
+		wantErr string
+	}{
+		{
+			name: "verified",
+		},
+		{
+			name: "tampered sequence",
+			modify: func(s *AccountsSnapshot, appHash []byte) ([]byte, int64) {
+				s.Accounts[1].Sequence++
+				return appHash, s.Height + 1
+			},
+			wantErr: "proven sequence",
+		},
+		{
+			name: "tampered value",
+			modify: func(s *AccountsSnapshot, appHash []byte) ([]byte, int64) {
+				s.Accounts[0].Value = s.Accounts[1].Value
+				return appHash, s.Height + 1
+			},
+			wantErr: "invalid proof",
+		},
+		{
+			name: "other app hash",
+			modify: func(s *AccountsSnapshot, appHash []byte) ([]byte, int64) {
+				other := append([]byte{}, appHash...)
+				other[0] ^= 1
+				return other, s.Height + 1
+			},
+			wantErr: "invalid proof",
+		},
+		{
+			name: "tampered height",
+			modify: func(s *AccountsSnapshot, appHash []byte) ([]byte, int64) {
+				trustedHeight := s.Height + 1
+				s.Height += 100
+				return appHash, trustedHeight
+			},
+			wantErr: "not of the trusted block",
+		},
+		{
+			name: "no trusted height",
+			modify: func(s *AccountsSnapshot, appHash []byte) ([]byte, int64) {
+				return appHash, 0
+			},
+			wantErr: "--trusted-height is required",
+		},
+		{
+			name: "no accounts",
+			modify: func(s *AccountsSnapshot, appHash []byte) ([]byte, int64) {
+				s.Accounts = nil
+				return appHash, s.Height + 1
+			},
+			wantErr: "no accounts",
+		},
+		{
+			name: "no proof",
+			modify: func(s *AccountsSnapshot, appHash []byte) ([]byte, int64) {
+				s.Accounts[0].Proof = nil
+				return appHash, s.Height + 1
+			},
+			wantErr: "no proof",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshot, appHash := newTestSnapshot(t, alice, bob)
+			trustedHeight := snapshot.Height + 1
+			if tt.modify != nil {
+				appHash, trustedHeight = tt.modify(snapshot, appHash)
+			}
+			err := verifySnapshot(clientCtx, snapshot, hex.EncodeToString(appHash), trustedHeight)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,786 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	kmultisig "github.com/cosmos/cosmos-sdk/crypto/keys/multisig"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	"github.com/cosmos/cosmos-sdk/crypto/types/multisig"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+)
+
+const (
+	pstVersion = 1
+
+	// pstSignMode is the sign mode of the partially signed transactions.
+	// Its sign bytes do not depend on the signer infos, so that the
+	// signatures can be collected in any order, and it is the only sign
+	// mode of multisig accounts.
+	pstSignMode = signing.SignMode_SIGN_MODE_LEGACY_AMINO_JSON
+)
+
+// PartiallySignedTx is an unsigned transaction along with everything its
+// signers need to sign it and the signatures collected so far, for
+// multi-party signing flows. Accounts is the accounts snapshot it was
+// created from, if any, the reference height of the timeout height.
+type PartiallySignedTx struct {
+	Version  int               `json:"version"`
+	ChainID  string            `json:"chain_id"`
+	Tx       json.RawMessage   `json:"tx"`
+	Accounts *AccountsSnapshot `json:"accounts,omitempty"`
+	Signers  []PSTSigner       `json:"signers"`
+}
+
+// PSTSigner is a required signer of a partially signed transaction. PubKey
+// is the public key of the account, a multisig public key for multisig
+// accounts, and is learnt from the first signature if unknown. Threshold is
+// the number of signatures the signer requires.
+type PSTSigner struct {
+	Address       string          `json:"address"`
+	AccountNumber uint64          `json:"account_number,string"`
+	Sequence      uint64          `json:"sequence,string"`
+	PubKey        json.RawMessage `json:"pub_key,omitempty"`
+	Threshold     uint32          `json:"threshold"`
+	Signatures    []PSTSignature  `json:"signatures"`
+}
+
+// PSTSignature is a signature of a signer, or of a member of a multisig
+// signer.
+type PSTSignature struct {
+	PubKey    json.RawMessage `json:"pub_key"`
+	Signature []byte          `json:"signature"`
+}
+
+// pubKey returns the public key of the signer, nil if unknown, and checks
+// that it is the key of its address.
+func (s PSTSigner) pubKey(clientCtx client.Context) (cryptotypes.PubKey, error) {
+	if len(s.PubKey) == 0 {
+		return nil, nil
+	}
+	var pubKey cryptotypes.PubKey
+	if err := clientCtx.Codec.UnmarshalInterfaceJSON(s.PubKey, &pubKey); err != nil {
+		return nil, fmt.Errorf("invalid public key of signer %s: %w", s.Address, err)
+	}
+	if addr := sdk.AccAddress(pubKey.Address()).String(); addr != s.Address {
+		return nil, fmt.Errorf("the public key of signer %s is the key of %s", s.Address, addr)
+	}
+	return pubKey, nil
+}
+
+// complete tells whether the signer has enough signatures.
+func (s PSTSigner) complete() bool {
+	return len(s.Signatures) >= int(s.Threshold)
+}
+
+// pstSignBytes returns the bytes the signer, or the members of the multisig
+// signer, sign.
+func pstSignBytes(ctx context.Context, clientCtx client.Context, tx sdk.Tx, chainID string, signer PSTSigner) ([]byte, error) {
+	return authsigning.GetSignBytesAdapter(ctx, clientCtx.TxConfig.SignModeHandler(), pstSignMode, authsigning.SignerData{
+		ChainID:       chainID,
+		AccountNumber: signer.AccountNumber,
+		Sequence:      signer.Sequence,
+		Address:       signer.Address,
+	}, tx)
+}
+
+// newPSTSigner returns the signer of address, with the public key found in
+// the snapshot or the keyring, if any.
+func newPSTSigner(clientCtx client.Context, address string, accountNumber, sequence uint64, snapshot *AccountsSnapshot) (PSTSigner, error) {
+	signer := PSTSigner{
+		Address:       address,
+		AccountNumber: accountNumber,
+		Sequence:      sequence,
+		Threshold:     1,
+	}
+	addr, err := sdk.AccAddressFromBech32(address)
+	if err != nil {
+		return signer, err
+	}
+	if snapshot != nil {
+		if account, ok := snapshot.Account(addr); ok && len(account.PubKey) > 0 {
+			signer.PubKey = account.PubKey
+		}
+	}
+	if len(signer.PubKey) == 0 {
+		if record, err := clientCtx.Keyring.KeyByAddress(addr); err == nil {
+			pubKey, err := record.GetPubKey()
+			if err != nil {
+				return signer, err
+			}
+			if signer.PubKey, err = clientCtx.Codec.MarshalInterfaceJSON(pubKey); err != nil {
+				return signer, err
+			}
+		}
+	}
+	pubKey, err := signer.pubKey(clientCtx)
+	if err != nil {
+		return signer, err
+	}
+	if multisigPubKey, ok := pubKey.(*kmultisig.LegacyAminoPubKey); ok {
+		signer.Threshold = multisigPubKey.Threshold
+	}
+	return signer, nil
+}
+
+// addSignature verifies the signature of pubKey, the key of the signer or
+// of a member of the multisig signer, and adds it to the signer. The public
+// key of the signer is learnt from its first signature if unknown, and
+// signatures already present are left alone.
+func (p *PartiallySignedTx) addSignature(ctx context.Context, clientCtx client.Context, tx sdk.Tx, i int, pubKey cryptotypes.PubKey, sig []byte) error {
+	signer := &p.Signers[i]
+	accountPubKey, err := signer.pubKey(clientCtx)
+	if err != nil {
+		return err
+	}
+	pubKeyJSON, err := clientCtx.Codec.MarshalInterfaceJSON(pubKey)
+	if err != nil {
+		return err
+	}
+	if accountPubKey == nil {
+		if sdk.AccAddress(pubKey.Address()).String() != signer.Address {
+			return fmt.Errorf("the public key of signer %s is unknown, and %s is not its key", signer.Address, sdk.AccAddress(pubKey.Address()))
+		}
+		signer.PubKey = pubKeyJSON
+		accountPubKey = pubKey
+	}
+
+	if multisigPubKey, ok := accountPubKey.(*kmultisig.LegacyAminoPubKey); ok {
+		if !slices.ContainsFunc(multisigPubKey.GetPubKeys(), pubKey.Equals) {
+			return fmt.Errorf("%s is not a member of the multisig signer %s", sdk.AccAddress(pubKey.Address()), signer.Address)
+		}
+	} else if !accountPubKey.Equals(pubKey) {
+		return fmt.Errorf("%s is not the key of signer %s", sdk.AccAddress(pubKey.Address()), signer.Address)
+	}
+	for _, existing := range signer.Signatures {
+		if bytes.Equal(existing.PubKey, pubKeyJSON) {
+			return nil
+		}
+	}
+
+	signBytes, err := pstSignBytes(ctx, clientCtx, tx, p.ChainID, *signer)
+	if err != nil {
+		return err
+	}
+	if !pubKey.VerifySignature(signBytes, sig) {
+		return fmt.Errorf("invalid signature of %s for signer %s", sdk.AccAddress(pubKey.Address()), signer.Address)
+	}
+	signer.Signatures = append(signer.Signatures, PSTSignature{PubKey: pubKeyJSON, Signature: sig})
+	return nil
+}
+
+// merge verifies the signatures of other and adds them.
+func (p *PartiallySignedTx) merge(ctx context.Context, clientCtx client.Context, tx sdk.Tx, other *PartiallySignedTx) error {
+	for i, signer := range other.Signers {
+		if len(signer.PubKey) > 0 && len(p.Signers[i].PubKey) == 0 {
+			pubKey, err := signer.pubKey(clientCtx)
+			if err != nil {
+				return err
+			}
+			p.Signers[i].PubKey = signer.PubKey
+			if multisigPubKey, ok := pubKey.(*kmultisig.LegacyAminoPubKey); ok {
+				p.Signers[i].Threshold = multisigPubKey.Threshold
+			}
+		}
+		for _, sig := range signer.Signatures {
+			var pubKey cryptotypes.PubKey
+			if err := clientCtx.Codec.UnmarshalInterfaceJSON(sig.PubKey, &pubKey); err != nil {
+				return fmt.Errorf("invalid signature public key of signer %s: %w", signer.Address, err)
+			}
+			if err := p.addSignature(ctx, clientCtx, tx, i, pubKey, sig.Signature); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// LoadPST loads a partially signed transaction from file, decodes its
+// transaction and verifies its signers and signatures.
+func LoadPST(ctx context.Context, clientCtx client.Context, pluginsDir, file string) (*PartiallySignedTx, sdk.Tx, error) {
+	bz, err := os.ReadFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	var loaded PartiallySignedTx
+	if err := json.Unmarshal(bz, &loaded); err != nil {
+		return nil, nil, fmt.Errorf("invalid partially signed transaction %s: %w", file, err)
+	}
+	if loaded.Version != pstVersion {
+		return nil, nil, fmt.Errorf("unsupported partially signed transaction version %d", loaded.Version)
+	}
+	if loaded.Accounts != nil && loaded.Accounts.ChainID != loaded.ChainID {
+		return nil, nil, fmt.Errorf("invalid partially signed transaction %s: accounts of chain %s", file, loaded.Accounts.ChainID)
+	}
+	tx, err := decodeTxJSON(clientCtx, pluginsDir, loaded.Tx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("JSON decode %s: %v", file, err)
+	}
+	txSigners, err := getTxSigners(clientCtx, tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(txSigners) != len(loaded.Signers) {
+		return nil, nil, fmt.Errorf("invalid partially signed transaction %s: %d signers, the transaction has %d", file, len(loaded.Signers), len(txSigners))
+	}
+
+	// the signatures are verified as they are added back
+	p := loaded
+	p.Signers = make([]PSTSigner, len(loaded.Signers))
+	for i, signer := range loaded.Signers {
+		if signer.Address != txSigners[i] {
+			return nil, nil, fmt.Errorf("invalid partially signed transaction %s: signer %d is %s, not %s", file, i, signer.Address, txSigners[i])
+		}
+		p.Signers[i] = signer
+		p.Signers[i].Signatures = nil
+		pubKey, err := signer.pubKey(clientCtx)
+		if err != nil {
+			return nil, nil, err
+		}
+		p.Signers[i].Threshold = 1
+		if multisigPubKey, ok := pubKey.(*kmultisig.LegacyAminoPubKey); ok {
+			p.Signers[i].Threshold = multisigPubKey.Threshold
+		}
+	}
+	if err := p.merge(ctx, clientCtx, tx, &loaded); err != nil {
+		return nil, nil, fmt.Errorf("invalid partially signed transaction %s: %w", file, err)
+	}
+	return &p, tx, nil
+}
+
+// finalize returns the transaction with the signatures of the signers, once
+// they all have enough signatures.
+func (p *PartiallySignedTx) finalize(clientCtx client.Context, tx sdk.Tx) (client.TxBuilder, error) {
+	var incomplete []string
+	for _, signer := range p.Signers {
+		if !signer.complete() {
+			incomplete = append(incomplete, fmt.Sprintf("%s (%d/%d)", signer.Address, len(signer.Signatures), signer.Threshold))
+		}
+	}
+	if len(incomplete) > 0 {
+		return nil, fmt.Errorf("missing signatures of %s", strings.Join(incomplete, ", "))
+	}
+
+	sigs := make([]signing.SignatureV2, len(p.Signers))
+	for i, signer := range p.Signers {
+		pubKey, err := signer.pubKey(clientCtx)
+		if err != nil {
+			return nil, err
+		}
+		sigs[i] = signing.SignatureV2{PubKey: pubKey, Sequence: signer.Sequence}
+
+		multisigPubKey, ok := pubKey.(*kmultisig.LegacyAminoPubKey)
+		if !ok {
+			sigs[i].Data = &signing.SingleSignatureData{SignMode: pstSignMode, Signature: signer.Signatures[0].Signature}
+			continue
+		}
+		multisigData := multisig.NewMultisig(len(multisigPubKey.GetPubKeys()))
+		for _, sig := range signer.Signatures[:signer.Threshold] {
+			var memberPubKey cryptotypes.PubKey
+			if err := clientCtx.Codec.UnmarshalInterfaceJSON(sig.PubKey, &memberPubKey); err != nil {
+				return nil, err
+			}
+			err := multisig.AddSignatureV2(multisigData, signing.SignatureV2{
+				PubKey: memberPubKey,
+				Data:   &signing.SingleSignatureData{SignMode: pstSignMode, Signature: sig.Signature},
+			}, multisigPubKey.GetPubKeys())
+			if err != nil {
+				return nil, err
+			}
+		}
+		sigs[i].Data = multisigData
+	}
+
+	txBuilder, err := clientCtx.TxConfig.WrapTxBuilder(tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := txBuilder.SetSignatures(sigs...); err != nil {
+		return nil, err
+	}
+	return txBuilder, nil
+}
+
+// printPST prints the partially signed transaction.
+func printPST(cmd *cobra.Command, p *PartiallySignedTx) error {
+	bz, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return printOutput(cmd, bz)
+}
+
+// GetPSTCommand returns the pst command.
+func GetPSTCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pst",
+		Short: "Partially signed transaction subcommands",
+		Long: `Coordinate the signature of a transaction by several parties, e.g. the
+members of a multisig account or the signers of a transaction with several
+signers, with a partially signed transaction: a self-describing JSON document
+holding the unsigned transaction, the chain-id, the account number, sequence,
+public key and threshold of every required signer, and the signatures
+collected so far.
+
+The document is passed around, 'pst sign' adds the signatures of local keys,
+'pst combine' merges the signatures of copies signed in parallel, 'pst
+inspect' reviews it and 'pst finalize' outputs the signed transaction once
+every signer has enough signatures. Every signature is verified when it is
+added and whenever the document is read.
+
+Signatures are made in SIGN_MODE_LEGACY_AMINO_JSON, whose sign bytes do not
+depend on the other signers, so that they can be collected in any order.
+`,
+		DisableFlagParsing:         false,
+		SuggestionsMinimumDistance: 2,
+		RunE:                       client.ValidateCmd,
+	}
+
+	cmd.AddCommand(
+		GetPSTCreateCommand(),
+		GetPSTSignCommand(),
+		GetPSTCombineCommand(),
+		GetPSTFinalizeCommand(),
+		GetPSTInspectCommand(),
+	)
+
+	return cmd
+}
+
+// GetPSTCreateCommand returns the pst create command.
+func GetPSTCreateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create [file]",
+		Short: "Create a partially signed transaction",
+		Long: `Create a partially signed transaction of the unsigned transaction in [file].
+
+The account numbers and sequences of the required signers of the transaction
+are given with --account-number and --sequence, in the order of the signers,
+or read from the accounts snapshot given with --accounts-snapshot, see
+'accounts export --help'. The public keys of the signers, and the thresholds
+of multisig signers, are read from the snapshot or from the keys, including
+multisig and offline keys, of the keyring. Unknown public keys of single key
+signers are learnt from their first signature.
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientTxContext(cmd)
+			if err != nil {
+				return err
+			}
+			f := cmd.Flags()
+			pluginsDir, err := f.GetString(flagPluginsDir)
+			if err != nil {
+				return err
+			}
+			accountNumbers, err := f.GetUintSlice(flags.FlagAccountNumber)
+			if err != nil {
+				return err
+			}
+			sequences, err := f.GetUintSlice(flags.FlagSequence)
+			if err != nil {
+				return err
+			}
+
+			tx, err := readTxFile(clientCtx, pluginsDir, args[0])
+			if err != nil {
+				return err
+			}
+			if sigs, err := tx.(authsigning.SigVerifiableTx).GetSignaturesV2(); err != nil {
+				return err
+			} else if len(sigs) > 0 {
+				return fmt.Errorf("the transaction in %s is already signed", args[0])
+			}
+			snapshot, err := loadSnapshotFromFlags(clientCtx, cmd)
+			if err != nil {
+				return err
+			}
+			txSigners, err := getTxSigners(clientCtx, tx)
+			if err != nil {
+				return err
+			}
+			fromSnapshot := snapshot != nil && len(accountNumbers) == 0 && len(sequences) == 0
+			if !fromSnapshot && (len(accountNumbers) != len(txSigners) || len(sequences) != len(txSigners)) {
+				return fmt.Errorf("expected one --%s and one --%s for each of the %d signers, or --%s, got %d and %d",
+					flags.FlagAccountNumber, flags.FlagSequence, len(txSigners), flagAccountsSnapshot, len(accountNumbers), len(sequences))
+			}
+
+			txJSON, err := clientCtx.TxConfig.TxJSONEncoder()(tx)
+			if err != nil {
+				return err
+			}
+			p := &PartiallySignedTx{
+				Version:  pstVersion,
+				ChainID:  clientCtx.ChainID,
+				Tx:       txJSON,
+				Accounts: snapshot,
+			}
+			for i, address := range txSigners {
+				var accountNumber, sequence uint64
+				if fromSnapshot {
+					addr, err := sdk.AccAddressFromBech32(address)
+					if err != nil {
+						return err
+					}
+					account, ok := snapshot.Account(addr)
+					if !ok {
+						return fmt.Errorf("signer %s is not in the accounts snapshot", address)
+					}
+					accountNumber, sequence = account.AccountNumber, account.Sequence
+				} else {
+					accountNumber, sequence = uint64(accountNumbers[i]), uint64(sequences[i])
+				}
+				signer, err := newPSTSigner(clientCtx, address, accountNumber, sequence, snapshot)
+				if err != nil {
+					return err
+				}
+				p.Signers = append(p.Signers, signer)
+			}
+			return printPST(cmd, p)
+		},
+	}
+
+	cmd.Flags().String(flagPluginsDir, "", "The directory to search for plugin files")
+	cmd.Flags().String(flags.FlagChainID, "", "The network chain ID")
+	cmd.Flags().UintSliceP(flags.FlagAccountNumber, "a", nil, "The account number of each signer, in the order of the signers")
+	cmd.Flags().UintSliceP(flags.FlagSequence, "s", nil, "The sequence of each signer, in the order of the signers")
+	cmd.Flags().String(flagAccountsSnapshot, "", "The accounts snapshot file, giving the account numbers, sequences and public keys of the signers")
+	addSnapshotCheckFlags(cmd)
+	cmd.Flags().String(flags.FlagOutputDocument, "", "The document will be written to the given file instead of STDOUT")
+	flags.AddKeyringFlags(cmd.Flags())
+	_ = cmd.MarkFlagRequired(flagPluginsDir)
+	_ = cmd.MarkFlagRequired(flags.FlagChainID)
+
+	return cmd
+}
+
+// GetPSTSignCommand returns the pst sign command.
+func GetPSTSignCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sign [file]",
+		Short: "Add the signatures of local keys to a partially signed transaction",
+		Long: `Sign the partially signed transaction in [file] with the --from keys, which
+can be repeated, for the signers they are the key of or a member of the
+multisig key of, and print it with the added signatures.
+
+The accounts snapshot of the partially signed transaction, if any, is
+checked as with 'tx sign --accounts-snapshot', its proofs verified against
+--trusted-app-hash and --trusted-height unless --allow-unverified-snapshot is
+set, before it is used as the reference height of the timeout rule.
+
+The transaction is then checked against the address book, the chain profile,
+the timeout rule, the signing policy and the pre-sign hooks, and the sign
+bytes against the approvals of protected keys and the sequence journal,
+before any key is used. The signatures are recorded in the sequence journal
+and the audit log, the outflows in the spending ledger, and the post-sign
+hooks receive the transaction without its signatures, which are only in the
+partially signed transaction. See 'tx sign --help'.
+
+Unlike 'tx sign', the account numbers and sequences are those of the
+partially signed transaction, and the signatures are always made with
+SIGN_MODE_LEGACY_AMINO_JSON.
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientTxContext(cmd)
+			if err != nil {
+				return err
+			}
+			f := cmd.Flags()
+			pluginsDir, err := f.GetString(flagPluginsDir)
+			if err != nil {
+				return err
+			}
+			froms, err := f.GetStringArray(flags.FlagFrom)
+			if err != nil {
+				return err
+			}
+			ctx := cmd.Context()
+			p, tx, err := LoadPST(ctx, clientCtx, pluginsDir, args[0])
+			if err != nil {
+				return err
+			}
+			clientCtx = clientCtx.WithChainID(p.ChainID)
+			if p.Accounts != nil {
+				if err := checkSnapshot(clientCtx, cmd, p.Accounts, args[0]); err != nil {
+					return err
+				}
+			}
+
+			// the signers each --from key signs for
+			type pendingSignature struct {
+				signer int
+				local  localSigner
+			}
+			var (
+				pending []pendingSignature
+				signers []localSigner
+				keys    []signingKey
+			)
+			for _, from := range froms {
+				addr, name, _, err := client.GetFromFields(clientCtx, clientCtx.Keyring, from)
+				if err != nil {
+					return fmt.Errorf("error getting account from keybase: %w", err)
+				}
+				record, err := clientCtx.Keyring.Key(name)
+				if err != nil {
+					return err
+				}
+				pubKey, err := record.GetPubKey()
+				if err != nil {
+					return err
+				}
+				var found bool
+				for i, signer := range p.Signers {
+					accountPubKey, err := signer.pubKey(clientCtx)
+					if err != nil {
+						return err
+					}
+					multisigPubKey, isMultisig := accountPubKey.(*kmultisig.LegacyAminoPubKey)
+					if signer.Address != addr.String() && (!isMultisig || !slices.ContainsFunc(multisigPubKey.GetPubKeys(), pubKey.Equals)) {
+						continue
+					}
+					found = true
+					local := localSigner{Name: name, Address: addr, PubKey: pubKey, AccountNumber: signer.AccountNumber, Sequence: signer.Sequence}
+					pending = append(pending, pendingSignature{signer: i, local: local})
+					signers = append(signers, local)
+					account, err := sdk.AccAddressFromBech32(signer.Address)
+					if err != nil {
+						return err
+					}
+					keys = append(keys, signingKey{Name: name, Address: account})
+				}
+				if !found {
+					return fmt.Errorf("key %s is not the key of a signer, nor a member of a multisig signer", name)
+				}
+			}
+
+			guard, err := newSignGuard(clientCtx, cmd, tx, p.Accounts, signers, keys)
+			if err != nil {
+				return err
+			}
+			defer guard.Close()
+
+			// the sign bytes are checked before any key is used
+			sigs := make([]journalSignature, len(pending))
+			for i, s := range pending {
+				signBytes, err := pstSignBytes(ctx, clientCtx, tx, p.ChainID, p.Signers[s.signer])
+				if err != nil {
+					return err
+				}
+				sigs[i] = journalSignature{
+					Signer:        s.local.Address.String(),
+					Account:       p.Signers[s.signer].Address,
+					AccountNumber: s.local.AccountNumber,
+					Sequence:      s.local.Sequence,
+					SignMode:      pstSignMode,
+					SignBytes:     signBytes,
+				}
+			}
+			if err := guard.checkSignatures(sigs); err != nil {
+				return err
+			}
+			for i, s := range pending {
+				sig, _, err := clientCtx.Keyring.Sign(s.local.Name, sigs[i].SignBytes, pstSignMode)
+				if err != nil {
+					return err
+				}
+				if err := p.addSignature(ctx, clientCtx, tx, s.signer, s.local.PubKey, sig); err != nil {
+					return err
+				}
+			}
+			txHash, err := guard.record(tx, sigs)
+			if err != nil {
+				return err
+			}
+			if err := printPST(cmd, p); err != nil {
+				return err
+			}
+			return guard.postSign(p.Tx, txHash)
+		},
+	}
+
+	cmd.Flags().String(flagPluginsDir, "", "The directory to search for plugin files")
+	cmd.Flags().StringArray(flags.FlagFrom, nil, "Name or address of a key to sign with, can be repeated")
+	cmd.Flags().Bool(flagRequireKnownRecipients, false, "Refuse to sign if a recipient is not known in the address book")
+	cmd.Flags().Bool(flagAllowHighFee, false, "Sign even if the fee exceeds the bounds of the chain profile, printing a warning")
+	cmd.Flags().String(flagPolicy, "", "The signing policy file, config/policy.json in the home directory by default")
+	cmd.Flags().StringArray(flagApproval, nil, "An approval file produced by the approve command, can be repeated")
+	addSnapshotCheckFlags(cmd)
+	cmd.Flags().String(flags.FlagOutputDocument, "", "The document will be written to the given file instead of STDOUT")
+	flags.AddKeyringFlags(cmd.Flags())
+	_ = cmd.MarkFlagRequired(flagPluginsDir)
+	_ = cmd.MarkFlagRequired(flags.FlagFrom)
+
+	return cmd
+}
+
+// GetPSTCombineCommand returns the pst combine command.
+func GetPSTCombineCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "combine [file] [file]...",
+		Short: "Combine the signatures of copies of a partially signed transaction",
+		Long: `Combine the signatures of the copies of a partially signed transaction, signed
+in parallel by different parties. The copies must be of the same transaction,
+chain and signers, and their signatures are verified.
+`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx := client.GetClientContextFromCmd(cmd)
+			pluginsDir, err := cmd.Flags().GetString(flagPluginsDir)
+			if err != nil {
+				return err
+			}
+			ctx := cmd.Context()
+			p, tx, err := LoadPST(ctx, clientCtx, pluginsDir, args[0])
+			if err != nil {
+				return err
+			}
+			txJSON, err := clientCtx.TxConfig.TxJSONEncoder()(tx)
+			if err != nil {
+				return err
+			}
+			for _, file := range args[1:] {
+				other, otherTx, err := LoadPST(ctx, clientCtx, pluginsDir, file)
+				if err != nil {
+					return err
+				}
+				otherJSON, err := clientCtx.TxConfig.TxJSONEncoder()(otherTx)
+				if err != nil {
+					return err
+				}
+				switch {
+				case other.ChainID != p.ChainID:
+					return fmt.Errorf("%s is for chain %s, not %s", file, other.ChainID, p.ChainID)
+				case !bytes.Equal(otherJSON, txJSON):
+					return fmt.Errorf("%s is not a partially signed transaction of the same transaction as %s", file, args[0])
+				}
+				for i, signer := range other.Signers {
+					if signer.AccountNumber != p.Signers[i].AccountNumber || signer.Sequence != p.Signers[i].Sequence {
+						return fmt.Errorf("%s has the account number %d and sequence %d for signer %s, not %d and %d", file,
+							signer.AccountNumber, signer.Sequence, signer.Address, p.Signers[i].AccountNumber, p.Signers[i].Sequence)
+					}
+				}
+				if err := p.merge(ctx, clientCtx, tx, other); err != nil {
+					return fmt.Errorf("combine %s: %w", file, err)
+				}
+			}
+			return printPST(cmd, p)
+		},
+	}
+
+	cmd.Flags().String(flagPluginsDir, "", "The directory to search for plugin files")
+	cmd.Flags().String(flags.FlagOutputDocument, "", "The document will be written to the given file instead of STDOUT")
+	_ = cmd.MarkFlagRequired(flagPluginsDir)
+
+	return cmd
+}
+
+// GetPSTFinalizeCommand returns the pst finalize command.
+func GetPSTFinalizeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "finalize [file]",
+		Short: "Output the signed transaction of a partially signed transaction",
+		Long: `Output the signed transaction of the partially signed transaction in [file],
+once every signer has enough signatures. The signatures of the members of
+multisig signers are combined into multisig signatures.
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx := client.GetClientContextFromCmd(cmd)
+			pluginsDir, err := cmd.Flags().GetString(flagPluginsDir)
+			if err != nil {
+				return err
+			}
+			p, tx, err := LoadPST(cmd.Context(), clientCtx, pluginsDir, args[0])
+			if err != nil {
+				return err
+			}
+			txBuilder, err := p.finalize(clientCtx, tx)
+			if err != nil {
+				return err
+			}
+			json, err := clientCtx.TxConfig.TxJSONEncoder()(txBuilder.GetTx())
+			if err != nil {
+				return err
+			}
+			return printOutput(cmd, json)
+		},
+	}
+
+	cmd.Flags().String(flagPluginsDir, "", "The directory to search for plugin files")
+	cmd.Flags().String(flags.FlagOutputDocument, "", "The document will be written to the given file instead of STDOUT")
+	_ = cmd.MarkFlagRequired(flagPluginsDir)
+
+	return cmd
+}
+
+// GetPSTInspectCommand returns the pst inspect command.
+func GetPSTInspectCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "inspect [file]",
+		Short: "Print a human readable review of a partially signed transaction",
+		Long: `Print the review of the transaction of the partially signed transaction in
+[file], as 'tx review' does, followed by the signatures collected for every
+signer.
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx := client.GetClientContextFromCmd(cmd)
+			pluginsDir, err := cmd.Flags().GetString(flagPluginsDir)
+			if err != nil {
+				return err
+			}
+			p, tx, err := LoadPST(cmd.Context(), clientCtx, pluginsDir, args[0])
+			if err != nil {
+				return err
+			}
+			clientCtx = clientCtx.WithChainID(p.ChainID)
+			book, err := LoadAddressBook(addressBookPath(clientCtx.HomeDir))
+			if err != nil {
+				return err
+			}
+			review, err := newTxReview(clientCtx, cmd, tx, book, p.Accounts)
+			if err != nil {
+				return err
+			}
+
+			var b strings.Builder
+			fmt.Fprintf(&b, "%s\nSignatures:\n", review)
+			for _, signer := range p.Signers {
+				state := "incomplete"
+				if signer.complete() {
+					state = "complete"
+				}
+				fmt.Fprintf(&b, "  %s\n     account %d, sequence %d: %d/%d signatures, %s\n", book.Annotate(signer.Address),
+					signer.AccountNumber, signer.Sequence, len(signer.Signatures), signer.Threshold, state)
+				for _, sig := range signer.Signatures {
+					var pubKey cryptotypes.PubKey
+					if err := clientCtx.Codec.UnmarshalInterfaceJSON(sig.PubKey, &pubKey); err != nil {
+						return err
+					}
+					fmt.Fprintf(&b, "     signed by %s\n", book.Annotate(sdk.AccAddress(pubKey.Address()).String()))
+				}
+			}
+			return printOutput(cmd, []byte(strings.TrimSuffix(b.String(), "\n")))
+		},
+	}
+
+	cmd.Flags().String(flagPluginsDir, "", "The directory to search for plugin files")
+	cmd.Flags().String(flagPolicy, "", "The signing policy file, config/policy.json in the home directory by default")
+	cmd.Flags().String(flags.FlagOutputDocument, "", "The document will be written to the given file instead of STDOUT")
+	_ = cmd.MarkFlagRequired(flagPluginsDir)
+
+	return cmd
+}
@@ -0,0 +1,275 @@
+package cli
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// writeTestPST writes the partially signed transaction of tx, with the
+// account number i+1 and sequence i+5 for its signer i, and returns its
+// file.
+func writeTestPST(t *testing.T, clientCtx client.Context, tx sdk.Tx, accounts *AccountsSnapshot) string {
+	t.Helper()
+	txJSON, err := clientCtx.TxConfig.TxJSONEncoder()(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txSigners, err := getTxSigners(clientCtx, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &PartiallySignedTx{Version: pstVersion, ChainID: testChainID, Tx: txJSON, Accounts: accounts}
+	for i, address := range txSigners {
+		signer, err := newPSTSigner(clientCtx, address, uint64(i+1), uint64(i+5), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Signers = append(p.Signers, signer)
+	}
+	return writeTestJSON(t, p)
+}
+
+// writeTestJSON writes v as JSON to a temporary file and returns it.
+func writeTestJSON(t *testing.T, v any) string {
+	t.Helper()
+	bz, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "doc.json")
+	if err := os.WriteFile(file, bz, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// runTestPSTCommand runs cmd with args and the context of clientCtx, and
+// returns the partially signed transaction it outputs.
+func runTestPSTCommand(t *testing.T, clientCtx client.Context, cmd *cobra.Command, args ...string) (*PartiallySignedTx, error) {
+	t.Helper()
+	pluginsDir := t.TempDir()
+	out := filepath.Join(t.TempDir(), "out.json")
+	cmd.SetContext(context.WithValue(context.Background(), client.ClientContextKey, &clientCtx))
+	cmd.SetArgs(append(args, "--"+flagPluginsDir, pluginsDir, "--"+flags.FlagOutputDocument, out))
+	cmd.SilenceUsage, cmd.SilenceErrors = true, true
+	if err := cmd.Execute(); err != nil {
+		if _, statErr := os.Stat(out); statErr == nil {
+			t.Fatalf("got output %s despite error %v", out, err)
+		}
+		return nil, err
+	}
+	p, _, err := LoadPST(context.Background(), clientCtx, pluginsDir, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, nil
+}
+
+// pstSignatureCounts returns the number of signatures of every signer.
+func pstSignatureCounts(p *PartiallySignedTx) []int {
+	counts := make([]int, len(p.Signers))
+	for i, signer := range p.Signers {
+		counts[i] = len(signer.Signatures)
+	}
+	return counts
+}
+
+func TestPSTSign(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	bob := newTestKey(t, clientCtx, "bob")
+	carol := newTestKey(t, clientCtx, "carol")
+	tx := newTestTx(t, clientCtx, nil, 200000, newTestSend(alice, bob, 10), newTestSend(bob, alice, 5))
+
+	// the sign bytes of alice, the first signer of the transaction
+	signBytes, err := pstSignBytes(context.Background(), clientCtx, tx, testChainID, PSTSigner{Address: alice.String(), AccountNumber: 1, Sequence: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	approval := writeTestJSON(t, newTestApproval(t, clientCtx, "carol", alice, signBytes))
+	snapshot, appHash := newTestSnapshot(t, alice, bob)
+	snapshot.Time = time.Now()
+
+	writeConfig := func(t *testing.T, home, name, config string) {
+		t.Helper()
+		file := filepath.Join(home, "config", name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(config), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	requireApproval := func(t *testing.T, home string) {
+		writeConfig(t, home, approvalsFile, `{"requirements": [{"keys": ["alice"], "threshold": 1, "approvers": ["`+carol.String()+`"]}]}`)
+	}
+
+	tests := []struct {
+		name       string
+		accounts   *AccountsSnapshot
+		setup      func(t *testing.T, home string)
+		args       []string
+		wantCounts []int
+		wantErr    string
+	}{
+		{name: "signed", args: []string{"--from", "alice"}, wantCounts: []int{1, 0}},
+		{name: "signed by both signers", args: []string{"--from", "alice", "--from", "bob"}, wantCounts: []int{1, 1}},
+		{name: "not a signer", args: []string{"--from", "carol"}, wantErr: "not the key of a signer"},
+		{name: "approval required", setup: requireApproval, args: []string{"--from", "alice"}, wantErr: "key alice requires 1 approvals"},
+		{name: "approved", setup: requireApproval, args: []string{"--from", "alice", "--" + flagApproval, approval}, wantCounts: []int{1, 0}},
+		{
+			name:    "vetoed by a pre-sign hook",
+			setup:   func(t *testing.T, home string) { writeConfig(t, home, hooksFile, `{"pre_sign": [["false"]]}`) },
+			args:    []string{"--from", "alice"},
+			wantErr: "vetoed by pre-sign hook",
+		},
+		{
+			name: "sequence used by another transaction",
+			setup: func(t *testing.T, home string) {
+				journal, err := openJournal(home)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer journal.Close()
+				if err := journal.Record(testChainID, []journalSignature{{Signer: alice.String(), Account: alice.String(), AccountNumber: 1, Sequence: 5, SignBytes: []byte("other")}}); err != nil {
+					t.Fatal(err)
+				}
+			},
+			args:    []string{"--from", "alice"},
+			wantErr: "the next unused sequence is 6",
+		},
+		{name: "unverified snapshot", accounts: snapshot, args: []string{"--from", "alice"}, wantErr: "is not verified"},
+		{name: "allowed unverified snapshot", accounts: snapshot, args: []string{"--from", "alice", "--" + flagAllowUnverifiedSnapshot}, wantCounts: []int{1, 0}},
+		{
+			name:       "verified snapshot",
+			accounts:   snapshot,
+			args:       []string{"--from", "alice", "--" + flagTrustedAppHash, hex.EncodeToString(appHash), "--" + flagTrustedHeight, "2"},
+			wantCounts: []int{1, 0},
+		},
+		{
+			name:     "snapshot of another block",
+			accounts: snapshot,
+			args:     []string{"--from", "alice", "--" + flagTrustedAppHash, hex.EncodeToString(appHash), "--" + flagTrustedHeight, "3"},
+			wantErr:  "not of the trusted block",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientCtx := clientCtx.WithHomeDir(t.TempDir())
+			if tt.setup != nil {
+				tt.setup(t, clientCtx.HomeDir)
+			}
+			file := writeTestPST(t, clientCtx, tx, tt.accounts)
+
+			p, err := runTestPSTCommand(t, clientCtx, GetPSTSignCommand(), append([]string{file}, tt.args...)...)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			case tt.wantErr != "":
+				return
+			}
+			if counts := pstSignatureCounts(p); !slices.Equal(counts, tt.wantCounts) {
+				t.Fatalf("got signature counts %v, want %v", counts, tt.wantCounts)
+			}
+		})
+	}
+}
+
+func TestPSTCombine(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	bob := newTestKey(t, clientCtx, "bob")
+	tx := newTestTx(t, clientCtx, nil, 200000, newTestSend(alice, bob, 10), newTestSend(bob, alice, 5))
+	otherTx := newTestTx(t, clientCtx, nil, 200000, newTestSend(alice, bob, 11), newTestSend(bob, alice, 5))
+
+	// sign returns the file of the partially signed transaction of tx signed
+	// by the from keys
+	sign := func(t *testing.T, tx sdk.Tx, from ...string) string {
+		t.Helper()
+		file := writeTestPST(t, clientCtx, tx, nil)
+		var args []string
+		for _, name := range from {
+			args = append(args, "--from", name)
+		}
+		p, err := runTestPSTCommand(t, clientCtx.WithHomeDir(t.TempDir()), GetPSTSignCommand(), append([]string{file}, args...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return writeTestJSON(t, p)
+	}
+	// otherSequence returns the file of an unsigned copy with another
+	// sequence for bob
+	otherSequence := func(t *testing.T) string {
+		t.Helper()
+		p, _, err := LoadPST(context.Background(), clientCtx, t.TempDir(), writeTestPST(t, clientCtx, tx, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Signers[1].Sequence++
+		return writeTestJSON(t, p)
+	}
+
+	tests := []struct {
+		name       string
+		files      func(t *testing.T) []string
+		wantCounts []int
+		wantErr    string
+	}{
+		{
+			name:       "parallel signers",
+			files:      func(t *testing.T) []string { return []string{sign(t, tx, "alice"), sign(t, tx, "bob")} },
+			wantCounts: []int{1, 1},
+		},
+		{
+			name:       "same signatures",
+			files:      func(t *testing.T) []string { return []string{sign(t, tx, "alice"), sign(t, tx, "alice", "bob")} },
+			wantCounts: []int{1, 1},
+		},
+		{
+			name:       "unsigned copy",
+			files:      func(t *testing.T) []string { return []string{writeTestPST(t, clientCtx, tx, nil), sign(t, tx, "bob")} },
+			wantCounts: []int{0, 1},
+		},
+		{
+			name:    "other transaction",
+			files:   func(t *testing.T) []string { return []string{sign(t, tx, "alice"), sign(t, otherTx, "bob")} },
+			wantErr: "not a partially signed transaction of the same transaction",
+		},
+		{
+			name:    "other sequence",
+			files:   func(t *testing.T) []string { return []string{sign(t, tx, "alice"), otherSequence(t)} },
+			wantErr: "has the account number 2 and sequence 7 for signer",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := runTestPSTCommand(t, clientCtx, GetPSTCombineCommand(), tt.files(t)...)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			case tt.wantErr != "":
+				return
+			}
+			if counts := pstSignatureCounts(p); !slices.Equal(counts, tt.wantCounts) {
+				t.Fatalf("got signature counts %v, want %v", counts, tt.wantCounts)
+			}
+		})
+	}
+}
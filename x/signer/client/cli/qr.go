@@ -0,0 +1,501 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client/flags"
+)
+
+const (
+	flagChunkSize   = "chunk-size"
+	flagPNGDir      = "png-dir"
+	flagPayloads    = "payloads"
+	flagInterval    = "interval"
+	flagExtraFrames = "extra-frames"
+
+	// qrFramePrefix starts the payload of every QR code frame.
+	qrFramePrefix = "cosmos-signer:"
+	qrPNGSize     = 512
+
+	// qrMaxFragments bounds the number of fragments of a document, which are
+	// allocated from the first frame read.
+	qrMaxFragments = 10000
+)
+
+// qrFrame is a frame of a document, in a QR code. The document is split in
+// Total fragments of the same size, the last one padded with zeros, and
+// encoded with a fountain code, as the multipart UR of the Blockchain Commons:
+// the frames 1 to Total are the fragments, and every following frame is the
+// XOR of a pseudo-random set of fragments, derived from its sequence number
+// and the digest of the document. The document is reassembled from any
+// sufficient set of frames, so that the missed frames of an animation need
+// not come round again. Length is the length of the document, and Digest
+// identifies it, so that frames of different documents are not mixed. Its
+// payload is:
+//
+//	cosmos-signer:<seq>-<total>:<length>:<digest>:<crc32>:<base64url data>
+//
+// where the CRC-32 covers the sequence, the total, the length, the digest and
+// the data.
+type qrFrame struct {
+	Seq    int
+	Total  int
+	Length int
+	Digest string
+	Data   []byte
+}
+
+// qrDigest returns the digest of a document, the first 8 bytes of its
+// SHA-256 in hex.
+func qrDigest(bz []byte) string {
+	hash := sha256.Sum256(bz)
+	return hex.EncodeToString(hash[:8])
+}
+
+func (f qrFrame) body() string {
+	return fmt.Sprintf("%d-%d:%d:%s:", f.Seq, f.Total, f.Length, f.Digest)
+}
+
+func (f qrFrame) crc() uint32 {
+	return crc32.ChecksumIEEE(append([]byte(f.body()), f.Data...))
+}
+
+// String returns the payload of the frame.
+func (f qrFrame) String() string {
+	return fmt.Sprintf("%s%d-%d:%d:%s:%08x:%s", qrFramePrefix, f.Seq, f.Total, f.Length, f.Digest, f.crc(),
+		base64.RawURLEncoding.EncodeToString(f.Data))
+}
+
+// parseQRFrame parses and checks the payload of a frame.
+func parseQRFrame(payload string) (qrFrame, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(payload), qrFramePrefix)
+	if !ok {
+		return qrFrame{}, fmt.Errorf("not a %s frame", strings.TrimSuffix(qrFramePrefix, ":"))
+	}
+	parts := strings.Split(rest, ":")
+	if len(parts) != 5 {
+		return qrFrame{}, fmt.Errorf("invalid frame %q", payload)
+	}
+
+	var (
+		f   qrFrame
+		err error
+	)
+	seq, total, ok := strings.Cut(parts[0], "-")
+	if !ok {
+		return qrFrame{}, fmt.Errorf("invalid frame sequence %q", parts[0])
+	}
+	if f.Seq, err = strconv.Atoi(seq); err != nil {
+		return qrFrame{}, fmt.Errorf("invalid frame sequence %q", parts[0])
+	}
+	if f.Total, err = strconv.Atoi(total); err != nil || f.Seq < 1 || f.Total < 1 {
+		return qrFrame{}, fmt.Errorf("invalid frame sequence %q", parts[0])
+	}
+	if f.Length, err = strconv.Atoi(parts[1]); err != nil || f.Length < 0 {
+		return qrFrame{}, fmt.Errorf("invalid frame %d length %q", f.Seq, parts[1])
+	}
+	f.Digest = parts[2]
+	if f.Data, err = base64.RawURLEncoding.DecodeString(parts[4]); err != nil {
+		return qrFrame{}, fmt.Errorf("invalid frame %d data: %w", f.Seq, err)
+	}
+	if crc := fmt.Sprintf("%08x", f.crc()); crc != parts[3] {
+		return qrFrame{}, fmt.Errorf("invalid frame %d: checksum %s does not match %s", f.Seq, parts[3], crc)
+	}
+	if len(f.Data) == 0 {
+		return qrFrame{}, fmt.Errorf("invalid frame %d: no data", f.Seq)
+	}
+	if f.Total > qrMaxFragments {
+		return qrFrame{}, fmt.Errorf("invalid frame %d: %d fragments, more than %d", f.Seq, f.Total, qrMaxFragments)
+	}
+	// an empty document still has a fragment
+	fragments := f.Length / len(f.Data)
+	if f.Length%len(f.Data) != 0 {
+		fragments++
+	}
+	if f.Total != max(1, fragments) {
+		return qrFrame{}, fmt.Errorf("invalid frame %d: %d fragments of %d bytes for a document of %d bytes", f.Seq, f.Total, len(f.Data), f.Length)
+	}
+	return f, nil
+}
+
+// qrFragments returns the indexes of the fragments mixed in frame seq of a
+// document of total fragments. Past the fragments themselves, the number of
+// fragments mixed is d with a probability proportional to 1/d, and the
+// fragments are drawn with a SHA-256 based generator seeded with the digest
+// and seq.
+func qrFragments(seq, total int, digest string) []int {
+	if seq <= total {
+		return []int{seq - 1}
+	}
+	var (
+		seed    = []byte(fmt.Sprintf("%s:%d:", digest, seq))
+		counter uint64
+	)
+	// next returns a pseudo-random number in [0, n)
+	next := func(n int) int {
+		hash := sha256.Sum256(binary.BigEndian.AppendUint64(seed, counter))
+		counter++
+		return int(binary.BigEndian.Uint64(hash[:8]) % uint64(n))
+	}
+
+	// the weights 1/d are approximated by integers on a fixed scale
+	const scale = 1 << 20
+	var sum int
+	for d := 1; d <= total; d++ {
+		sum += scale / d
+	}
+	r, degree := next(sum), 1
+	for ; degree < total; degree++ {
+		if r < scale/degree {
+			break
+		}
+		r -= scale / degree
+	}
+
+	// a partial Fisher-Yates shuffle draws degree distinct fragments
+	indexes := make([]int, total)
+	for i := range indexes {
+		indexes[i] = i
+	}
+	for i := 0; i < degree; i++ {
+		j := i + next(total-i)
+		indexes[i], indexes[j] = indexes[j], indexes[i]
+	}
+	fragments := indexes[:degree]
+	slices.Sort(fragments)
+	return fragments
+}
+
+// qrEncoder encodes a document into frames of chunkSize bytes.
+type qrEncoder struct {
+	digest    string
+	length    int
+	fragments [][]byte
+}
+
+func newQREncoder(bz []byte, chunkSize int) *qrEncoder {
+	total := max(1, (len(bz)+chunkSize-1)/chunkSize)
+	padded := make([]byte, total*chunkSize)
+	copy(padded, bz)
+	e := &qrEncoder{digest: qrDigest(bz), length: len(bz), fragments: make([][]byte, total)}
+	for i := range e.fragments {
+		e.fragments[i] = padded[i*chunkSize : (i+1)*chunkSize]
+	}
+	return e
+}
+
+// frame returns the frame seq, from 1.
+func (e *qrEncoder) frame(seq int) qrFrame {
+	data := make([]byte, len(e.fragments[0]))
+	for _, i := range qrFragments(seq, len(e.fragments), e.digest) {
+		xorBytes(data, e.fragments[i])
+	}
+	return qrFrame{Seq: seq, Total: len(e.fragments), Length: e.length, Digest: e.digest, Data: data}
+}
+
+// frames returns the fragment frames, followed by extra mixed frames.
+func (e *qrEncoder) frames(extra int) []qrFrame {
+	frames := make([]qrFrame, len(e.fragments)+extra)
+	for i := range frames {
+		frames[i] = e.frame(i + 1)
+	}
+	return frames
+}
+
+func xorBytes(dst, src []byte) {
+	for i := range dst {
+		dst[i] ^= src[i]
+	}
+}
+
+// qrPart is a frame being decoded, the XOR of the fragments it still mixes.
+type qrPart struct {
+	fragments []int
+	data      []byte
+}
+
+// joinQRFrames reassembles a document from its frames, in any order and
+// possibly repeated, and checks its digest. The mixed frames are reduced by
+// the fragments already known until they hold a single fragment.
+func joinQRFrames(frames []qrFrame) ([]byte, error) {
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("no frame")
+	}
+	var (
+		first     = frames[0]
+		fragments = make([][]byte, first.Total)
+		pending   []qrPart
+		known     int
+	)
+	// reduce removes the known fragments from part
+	reduce := func(part *qrPart) {
+		remaining := part.fragments[:0]
+		for _, i := range part.fragments {
+			if fragments[i] != nil {
+				xorBytes(part.data, fragments[i])
+				continue
+			}
+			remaining = append(remaining, i)
+		}
+		part.fragments = remaining
+	}
+	for _, f := range frames {
+		if f.Digest != first.Digest || f.Total != first.Total || f.Length != first.Length || len(f.Data) != len(first.Data) {
+			return nil, fmt.Errorf("frame %d of document %s does not belong to document %s", f.Seq, f.Digest, first.Digest)
+		}
+		queue := []qrPart{{fragments: qrFragments(f.Seq, f.Total, f.Digest), data: append([]byte{}, f.Data...)}}
+		for len(queue) > 0 {
+			part := queue[0]
+			queue = queue[1:]
+			reduce(&part)
+			switch len(part.fragments) {
+			case 0:
+				continue
+			case 1:
+			default:
+				pending = append(pending, part)
+				continue
+			}
+			fragments[part.fragments[0]] = part.data
+			known++
+			// the new fragment may reduce the pending frames to single
+			// fragments
+			var rest []qrPart
+			for _, p := range pending {
+				if slices.Contains(p.fragments, part.fragments[0]) {
+					queue = append(queue, p)
+					continue
+				}
+				rest = append(rest, p)
+			}
+			pending = rest
+		}
+	}
+	if known < first.Total {
+		var missing []string
+		for i, fragment := range fragments {
+			if fragment == nil {
+				missing = append(missing, strconv.Itoa(i+1))
+			}
+		}
+		return nil, fmt.Errorf("missing fragments %s of %d, scan more frames", strings.Join(missing, ", "), first.Total)
+	}
+	bz := bytes.Join(fragments, nil)[:first.Length]
+	if digest := qrDigest(bz); digest != first.Digest {
+		return nil, fmt.Errorf("document digest %s does not match %s", digest, first.Digest)
+	}
+	return bz, nil
+}
+
+// writeQRTerminal renders the frames on w, once or, with interval, in a loop
+// showing the fragments and then new mixed frames until interrupted.
+func writeQRTerminal(cmd *cobra.Command, w io.Writer, e *qrEncoder, extra int, interval time.Duration) error {
+	render := func(f qrFrame) (string, error) {
+		code, err := qrcode.New(f.String(), qrcode.Medium)
+		if err != nil {
+			return "", err
+		}
+		return code.ToSmallString(false), nil
+	}
+	total := len(e.fragments)
+	if interval <= 0 {
+		for seq := 1; seq <= total+extra; seq++ {
+			code, err := render(e.frame(seq))
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "Frame %d (%d fragments)\n%s\n", seq, total, code)
+		}
+		return nil
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for seq := 1; ; seq++ {
+		code, err := render(e.frame(seq))
+		if err != nil {
+			return err
+		}
+		// clear the screen
+		fmt.Fprintf(w, "\033[H\033[2J%s\nFrame %d (%d fragments)\n", code, seq, total)
+		select {
+		case <-cmd.Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetExportQRCommand returns the transaction export-qr command.
+func GetExportQRCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export-qr [file]",
+		Short: "Encode a document as a sequence of QR codes",
+		Long: `Encode the document in [file], an unsigned or signed transaction, a signing
+bundle or a signature, as a sequence of QR code frames of --chunk-size bytes,
+for a camera-based transfer between the online and the air-gapped machines.
+
+The frames are rendered on the terminal, one after the other or, with
+--interval, as an animation looping over the frames until interrupted. With
+--png-dir they are written as PNG files instead, and with --payloads their
+text payloads are printed, one per line.
+
+The document is encoded with a fountain code, as the multipart UR of the
+Blockchain Commons: the first frames are the fragments of the document, and
+the following ones mix pseudo-random sets of fragments, so that the document
+is reassembled from any sufficient set of frames. The animation goes on with
+new mixed frames after the fragments, and --extra-frames mixed frames follow
+the fragments otherwise.
+
+Every frame payload holds its sequence number, the number of fragments, the
+length and digest of the document and a checksum:
+
+  cosmos-signer:<seq>-<total>:<length>:<digest>:<crc32>:<base64url data>
+
+so that 'tx import-qr' reassembles the document from the scanned payloads, in
+any order.
+`,
+		Args:        cobra.ExactArgs(1),
+		Annotations: map[string]string{rawOutputAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f := cmd.Flags()
+			chunkSize, err := f.GetInt(flagChunkSize)
+			if err != nil {
+				return err
+			}
+			if chunkSize <= 0 {
+				return fmt.Errorf("invalid --%s %d", flagChunkSize, chunkSize)
+			}
+			pngDir, err := f.GetString(flagPNGDir)
+			if err != nil {
+				return err
+			}
+			payloads, err := f.GetBool(flagPayloads)
+			if err != nil {
+				return err
+			}
+			interval, err := f.GetDuration(flagInterval)
+			if err != nil {
+				return err
+			}
+			extra, err := f.GetInt(flagExtraFrames)
+			if err != nil {
+				return err
+			}
+			if extra < 0 {
+				return fmt.Errorf("invalid --%s %d", flagExtraFrames, extra)
+			}
+
+			bz, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			encoder := newQREncoder(bz, chunkSize)
+			frames := encoder.frames(extra)
+
+			switch {
+			case payloads:
+				lines := make([]string, len(frames))
+				for i, frame := range frames {
+					lines[i] = frame.String()
+				}
+				return printOutput(cmd, []byte(strings.Join(lines, "\n")))
+			case pngDir != "":
+				if err := os.MkdirAll(pngDir, 0o755); err != nil {
+					return err
+				}
+				for _, frame := range frames {
+					file := filepath.Join(pngDir, fmt.Sprintf("frame-%03d.png", frame.Seq))
+					if err := qrcode.WriteFile(frame.String(), qrcode.Medium, qrPNGSize, file); err != nil {
+						return err
+					}
+				}
+				cmd.PrintErrf("%d frames written to %s\n", len(frames), pngDir)
+				return nil
+			default:
+				return writeQRTerminal(cmd, cmd.OutOrStdout(), encoder, extra, interval)
+			}
+		},
+	}
+
+	cmd.Flags().Int(flagChunkSize, 300, "The number of document bytes per QR code frame")
+	cmd.Flags().String(flagPNGDir, "", "Write the frames as PNG files in this directory")
+	cmd.Flags().Bool(flagPayloads, false, "Print the text payloads of the frames instead of the QR codes")
+	cmd.Flags().Duration(flagInterval, 0, "Animate the frames on the terminal, showing each for this duration")
+	cmd.Flags().Int(flagExtraFrames, 0, "The number of mixed frames following the fragments, unless animated")
+	cmd.Flags().String(flags.FlagOutputDocument, "", "The document will be written to the given file instead of STDOUT")
+
+	return cmd
+}
+
+// GetImportQRCommand returns the transaction import-qr command.
+func GetImportQRCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import-qr [file]",
+		Short: "Reassemble a document from scanned QR code payloads",
+		Long: `Reassemble the document encoded by 'tx export-qr' from the frame payloads
+decoded by a QR code scanner, read one per line from [file], or from the
+standard input if [file] is '-'. The frames can be in any order and repeated,
+as scanned from an animation, and the fragments missed are recovered from the
+mixed frames. Every frame checksum and the document digest are verified, and
+the fragments still missing are reported.
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var r io.Reader = cmd.InOrStdin()
+			if args[0] != "-" {
+				file, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				r = file
+			}
+
+			var frames []qrFrame
+			scanner := bufio.NewScanner(r)
+			scanner.Buffer(nil, 1<<20)
+			for scanner.Scan() {
+				line := strings.TrimSpace(scanner.Text())
+				if line == "" {
+					continue
+				}
+				frame, err := parseQRFrame(line)
+				if err != nil {
+					return err
+				}
+				frames = append(frames, frame)
+			}
+			if err := scanner.Err(); err != nil {
+				return err
+			}
+
+			bz, err := joinQRFrames(frames)
+			if err != nil {
+				return err
+			}
+			return printOutput(cmd, bytes.TrimSuffix(bz, []byte("\n")))
+		},
+	}
+
+	cmd.Flags().String(flags.FlagOutputDocument, "", "The document will be written to the given file instead of STDOUT")
+
+	return cmd
+}
@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestQRFramesRoundTrip(t *testing.T) {
+	doc := bytes.Repeat([]byte(`{"body":{"messages":[]}}`), 40)
+	other := newQREncoder([]byte("another document"), 100).frames(0)
+
+	// drop returns the frames without the given sequence numbers
+	drop := func(frames []qrFrame, seqs ...int) []qrFrame {
+		var kept []qrFrame
+		for _, f := range frames {
+			if !containsInt(seqs, f.Seq) {
+				kept = append(kept, f)
+			}
+		}
+		return kept
+	}
+	reverse := func(frames []qrFrame) []qrFrame {
+		reversed := make([]qrFrame, len(frames))
+		for i, f := range frames {
+			reversed[len(frames)-1-i] = f
+		}
+		return reversed
+	}
+
+	tests := []struct {
+		name      string
+		doc       []byte
+		chunkSize int
+		extra     int
+		frames    func([]qrFrame) []qrFrame
+		wantErr   string
+	}{
+		{name: "empty document", doc: []byte{}, chunkSize: 100},
+		{name: "single frame", doc: []byte("document"), chunkSize: 100},
+		{name: "exact fragments", doc: doc[:800], chunkSize: 100},
+		{name: "fragments", doc: doc, chunkSize: 100},
+		{name: "reversed and repeated", doc: doc, chunkSize: 100, frames: func(f []qrFrame) []qrFrame { return append(reverse(f), f...) }},
+		{name: "missed fragments recovered", doc: doc, chunkSize: 100, extra: 30, frames: func(f []qrFrame) []qrFrame { return drop(f, 2, 5, 9) }},
+		{name: "mixed frames only", doc: doc, chunkSize: 100, extra: 60, frames: func(f []qrFrame) []qrFrame { return f[10:] }},
+		{name: "missed fragments", doc: doc, chunkSize: 100, frames: func(f []qrFrame) []qrFrame { return drop(f, 2, 5) }, wantErr: "missing fragments 2, 5 of 10"},
+		{name: "other document", doc: doc, chunkSize: 100, frames: func(f []qrFrame) []qrFrame { return append(f, other...) }, wantErr: "does not belong"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frames := newQREncoder(tt.doc, tt.chunkSize).frames(tt.extra)
+			// the frames go through their payloads, as scanned
+			for i, f := range frames {
+				parsed, err := parseQRFrame(f.String())
+				if err != nil {
+					t.Fatal(err)
+				}
+				frames[i] = parsed
+			}
+			if tt.frames != nil {
+				frames = tt.frames(frames)
+			}
+			bz, err := joinQRFrames(frames)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			case tt.wantErr == "" && !bytes.Equal(bz, tt.doc):
+				t.Fatalf("got document %q, want %q", bz, tt.doc)
+			}
+		})
+	}
+}
+
+func TestParseQRFrame(t *testing.T) {
+	frame := newQREncoder([]byte("document"), 4).frame(3)
+	payload := frame.String()
+	inconsistent := frame
+	inconsistent.Length = 20
+	oversized := qrFrame{Seq: 1, Total: qrMaxFragments + 1, Length: (qrMaxFragments + 1) * 4, Digest: frame.Digest, Data: frame.Data}
+	empty := qrFrame{Seq: 1, Total: 2, Digest: frame.Digest, Data: frame.Data}
+	noData := qrFrame{Seq: 1, Total: 1, Digest: frame.Digest}
+
+	tests := []struct {
+		name    string
+		payload string
+		wantErr string
+	}{
+		{name: "valid", payload: payload},
+		{name: "other prefix", payload: "ur:bytes/1-2/abc", wantErr: "not a cosmos-signer frame"},
+		{name: "corrupted data", payload: payload[:len(payload)-1] + "A", wantErr: "checksum"},
+		{name: "corrupted length", payload: strings.Replace(payload, "-2:8:", "-2:7:", 1), wantErr: "checksum"},
+		{name: "corrupted sequence", payload: strings.Replace(payload, ":3-2:", ":4-2:", 1), wantErr: "checksum"},
+		{name: "inconsistent length", payload: inconsistent.String(), wantErr: "2 fragments of 4 bytes"},
+		{name: "too many fragments", payload: oversized.String(), wantErr: "more than 10000"},
+		{name: "fragments of an empty document", payload: empty.String(), wantErr: "2 fragments of 4 bytes for a document of 0 bytes"},
+		{name: "no data", payload: noData.String(), wantErr: "no data"},
+		{name: "truncated", payload: payload[:20], wantErr: "invalid frame"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseQRFrame(tt.payload)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func containsInt(s []int, v int) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func TestImportQRBundle(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	bob := newTestKey(t, clientCtx, "bob")
+	bz, trusted := prepareTestBundle(t, clientCtx, newTestTx(t, clientCtx, sdk.NewCoins(sdk.NewInt64Coin("uatom", 2000)), 200000, newTestSend(alice, bob, 10)))
+
+	// the frames are scanned in reverse order, the second one missed
+	frames := newQREncoder(bz, 100).frames(10)
+	var lines []string
+	for i := len(frames) - 1; i >= 0; i-- {
+		if frames[i].Seq != 2 {
+			lines = append(lines, frames[i].String())
+		}
+	}
+	dir := t.TempDir()
+	payloadsFile, bundleFile := filepath.Join(dir, "payloads.txt"), filepath.Join(dir, "bundle.json")
+	if err := os.WriteFile(payloadsFile, []byte(strings.Join(lines, "\n")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cmd := newTestTxCommand(GetImportQRCommand())
+	cmd.SetArgs([]string{"import-qr", payloadsFile, "--" + flags.FlagOutputDocument, bundleFile})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	args := append([]string{"sign", "--" + flagBundle, bundleFile, "--from", "alice"}, trusted...)
+	bz, err := runTestCommand(t, clientCtx, newTestTxCommand(GetSignCommand()), args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyTestSignatures(t, clientCtx, bz, 10)
+}
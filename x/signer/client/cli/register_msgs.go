@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"debug/elf"
 	"fmt"
 	"path/filepath"
 	"plugin"
@@ -12,9 +13,17 @@ import (
 	cdctypes "github.com/cosmos/cosmos-sdk/codec/types"
 )
 
+const (
+	symbolSuffixRegisterLegacyAminoCodec = "_RegisterLegacyAminoCodec"
+	symbolSuffixRegisterInterfaces       = "_RegisterInterfaces"
+)
+
+// registeredPackages keeps track of the plugin packages already registered
+// in the current process, as the legacy amino codec panics on duplicate
+// registrations.
+var registeredPackages = make(map[string]struct{})
+
 func RegisterTypes(ctx client.Context, pluginsDir string, unregisteredTypes map[string]struct{}) error {
-	legacyAminoCodec := ctx.LegacyAmino
-	registry := ctx.Codec.InterfaceRegistry()
 	files, err := filepath.Glob(filepath.Join(pluginsDir, "*.so"))
 	if err != nil {
 		return err
@@ -29,29 +38,11 @@ func RegisterTypes(ctx client.Context, pluginsDir string, unregisteredTypes map[
 			if err != nil {
 				return err
 			}
-			packageName := sanitizeSymbolName(symbolName)
-			symRegisterLegacyAminoCodec := fmt.Sprintf("%s_RegisterLegacyAminoCodec", packageName)
-			symRegisterLegacyAminoCodecObj, err := p.Lookup(symRegisterLegacyAminoCodec)
-			if err != nil {
-				continue
-			}
-			registerLegacyAminoCodec, ok := symRegisterLegacyAminoCodecObj.(*func(*codec.LegacyAmino))
-			if !ok {
-				return fmt.Errorf("failed to load %s", symRegisterLegacyAminoCodec)
-			}
-			(*registerLegacyAminoCodec)(legacyAminoCodec)
-
-			symRegisterInterfaces := fmt.Sprintf("%s_RegisterInterfaces", packageName)
-			symRegisterInterfacesObj, err := p.Lookup(symRegisterInterfaces)
+			found, err := registerPackage(ctx, p, sanitizeSymbolName(symbolName))
 			if err != nil {
-				continue
-			}
-			registerInterfaces, ok := symRegisterInterfacesObj.(*func(cdctypes.InterfaceRegistry))
-			if !ok {
-				return fmt.Errorf("failed to load %s", symRegisterInterfaces)
+				return err
 			}
-			(*registerInterfaces)(registry)
-			symbolFound = true
+			symbolFound = symbolFound || found
 		}
 		if !symbolFound {
 			return fmt.Errorf("failed to lookup symbol %s", symbolName)
@@ -61,6 +52,113 @@ func RegisterTypes(ctx client.Context, pluginsDir string, unregisteredTypes map[
 	return nil
 }
 
+// RegisterAllTypes registers every package exported by the plugins found in
+// pluginsDir. It is needed when the transaction does not carry proto type URLs,
+// like legacy amino JSON transactions, and it is not possible to know in
+// advance which plugin packages are required.
+func RegisterAllTypes(ctx client.Context, pluginsDir string) error {
+	files, err := filepath.Glob(filepath.Join(pluginsDir, "*.so"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		packageNames, err := getPluginPackages(file)
+		if err != nil {
+			return err
+		}
+		p, err := plugin.Open(file)
+		if err != nil {
+			return err
+		}
+		for _, packageName := range packageNames {
+			if _, err := registerPackage(ctx, p, packageName); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// registerPackage registers the legacy amino codec and the interfaces of the
+// package exported by the plugin under packageName. It returns false if the
+// plugin does not export the package.
+func registerPackage(ctx client.Context, p *plugin.Plugin, packageName string) (bool, error) {
+	symRegisterLegacyAminoCodec := packageName + symbolSuffixRegisterLegacyAminoCodec
+	symRegisterLegacyAminoCodecObj, err := p.Lookup(symRegisterLegacyAminoCodec)
+	if err != nil {
+		return false, nil
+	}
+	registerLegacyAminoCodec, ok := symRegisterLegacyAminoCodecObj.(*func(*codec.LegacyAmino))
+	if !ok {
+		return false, fmt.Errorf("failed to load %s", symRegisterLegacyAminoCodec)
+	}
+
+	symRegisterInterfaces := packageName + symbolSuffixRegisterInterfaces
+	symRegisterInterfacesObj, err := p.Lookup(symRegisterInterfaces)
+	if err != nil {
+		return false, nil
+	}
+	registerInterfaces, ok := symRegisterInterfacesObj.(*func(cdctypes.InterfaceRegistry))
+	if !ok {
+		return false, fmt.Errorf("failed to load %s", symRegisterInterfaces)
+	}
+
+	if err := registerFuncs(ctx, packageName, *registerLegacyAminoCodec, *registerInterfaces); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// registerFuncs calls the registration functions of a plugin package, once
+// per process. The legacy amino codec and the interface registry panic when
+// a name or a type URL is already registered to another type, e.g. when a
+// plugin reuses an amino name of the application, which is returned as an
+// error.
+func registerFuncs(ctx client.Context, packageName string, registerLegacyAminoCodec func(*codec.LegacyAmino), registerInterfaces func(cdctypes.InterfaceRegistry)) (err error) {
+	if _, ok := registeredPackages[packageName]; ok {
+		return nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to register plugin package %s, it conflicts with a registered type: %v", packageName, r)
+		}
+	}()
+	registerLegacyAminoCodec(ctx.LegacyAmino)
+	registerInterfaces(ctx.Codec.InterfaceRegistry())
+	registeredPackages[packageName] = struct{}{}
+	return nil
+}
+
+// getPluginPackages lists the package names exported by a plugin, reading
+// the `<package>_RegisterLegacyAminoCodec` symbols from its dynamic symbol
+// table, as the plugin package itself does not allow to enumerate symbols.
+func getPluginPackages(file string) ([]string, error) {
+	f, err := elf.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open plugin %s: %w", file, err)
+	}
+	defer f.Close()
+
+	symbols, err := f.DynamicSymbols()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read symbols of plugin %s: %w", file, err)
+	}
+
+	var packageNames []string
+	for _, symbol := range symbols {
+		// exported symbols are qualified by the plugin path, e.g.
+		// `plugin/unnamed-1234.Govgen_gov_v1beta1_RegisterLegacyAminoCodec`
+		name := symbol.Name[strings.LastIndex(symbol.Name, ".")+1:]
+		if packageName, ok := strings.CutSuffix(name, symbolSuffixRegisterLegacyAminoCodec); ok && packageName != "" {
+			packageNames = append(packageNames, packageName)
+		}
+	}
+
+	return packageNames, nil
+}
+
 func findUnregisteredTypes(clientCtx client.Context, messages []map[string]any) (map[string]struct{}, error) {
 	registry := clientCtx.Codec.InterfaceRegistry()
 	unregisteredTypes := make(map[string]struct{})
@@ -75,8 +173,9 @@ func findUnregisteredTypes(clientCtx client.Context, messages []map[string]any)
 				continue
 			}
 			switch x := v.(type) {
-			case []map[string]any:
-				for _, m := range x {
+			case []any:
+				// e.g. the messages wrapped by authz MsgExec
+				for _, m := range asObjects(x) {
 					tmpUnregisteredTypes, err := findUnregisteredTypes(clientCtx, []map[string]any{m})
 					if err != nil {
 						return nil, err
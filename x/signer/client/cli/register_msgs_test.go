@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/codec"
+	cdctypes "github.com/cosmos/cosmos-sdk/codec/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+)
+
+// testAminoMsg is a type unknown to the amino codec of the tests.
+type testAminoMsg struct {
+	Value string
+}
+
+func TestRegisterFuncsConflict(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	noInterfaces := func(cdctypes.InterfaceRegistry) {}
+
+	tests := []struct {
+		name        string
+		packageName string
+		amino       func(*codec.LegacyAmino)
+		interfaces  func(cdctypes.InterfaceRegistry)
+		wantErr     string
+	}{
+		{
+			name:        "new amino name",
+			packageName: "Test_ok_v1",
+			amino: func(cdc *codec.LegacyAmino) {
+				cdc.RegisterConcrete(&testAminoMsg{}, "test/Msg", nil)
+			},
+			interfaces: noInterfaces,
+		},
+		{
+			name:        "amino name of the application",
+			packageName: "Test_conflict_v1",
+			amino: func(cdc *codec.LegacyAmino) {
+				cdc.RegisterConcrete(&banktypes.MsgSend{}, "cosmos-sdk/MsgSend", nil)
+			},
+			interfaces: noInterfaces,
+			wantErr:    "Test_conflict_v1",
+		},
+		{
+			name:        "registered once per process",
+			packageName: "Test_ok_v1",
+			amino: func(*codec.LegacyAmino) {
+				panic("registered twice")
+			},
+			interfaces: noInterfaces,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := registerFuncs(clientCtx, tt.packageName, tt.amino, tt.interfaces)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
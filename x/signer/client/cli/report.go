@@ -0,0 +1,316 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const (
+	flagSince = "since"
+	flagUntil = "until"
+
+	// ibcDenomsFile is the local table of IBC denom traces, in the config
+	// directory of the home.
+	ibcDenomsFile = "ibc_denoms.json"
+)
+
+// DenomTrace is the origin of an IBC denom.
+type DenomTrace struct {
+	Path      string `json:"path"`
+	BaseDenom string `json:"base_denom"`
+}
+
+// reportRow is a message of a signed transaction. TxHash is the hash of the
+// transaction as signed, which is the hash of the broadcast transaction only
+// if the signature completed it: it is not for the signatures of PSTs or on
+// behalf of multisig accounts, which are Partial.
+type reportRow struct {
+	Time           time.Time `json:"time"`
+	ChainID        string    `json:"chain_id"`
+	Signers        []string  `json:"signers"`
+	TxHash         string    `json:"tx_hash"`
+	Partial        bool      `json:"partial"`
+	MsgType        string    `json:"msg_type"`
+	Counterparties []string  `json:"counterparties"`
+	Amount         sdk.Coins `json:"amount"`
+	DisplayAmount  string    `json:"display_amount"`
+	Fee            sdk.Coins `json:"fee"`
+	DisplayFee     string    `json:"display_fee"`
+}
+
+// denomResolver resolves the display denoms of the chains, through the
+// assets of the chain profiles and the local table of IBC denom traces.
+type denomResolver struct {
+	profiles map[string]*ChainProfile
+	traces   map[string]DenomTrace
+}
+
+func newDenomResolver(home string) (*denomResolver, error) {
+	profiles, err := LoadChainProfiles(home)
+	if err != nil {
+		return nil, err
+	}
+	traces := make(map[string]DenomTrace)
+	file := filepath.Join(home, "config", ibcDenomsFile)
+	bz, err := os.ReadFile(file)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(bz, &traces); err != nil {
+			return nil, fmt.Errorf("invalid IBC denoms %s: %w", file, err)
+		}
+	}
+	return &denomResolver{profiles: profiles, traces: traces}, nil
+}
+
+// lookup returns the asset of denom on chainID. IBC denoms are resolved
+// with their trace, the base denom being looked up on every chain.
+func (r *denomResolver) lookup(chainID, denom string) (Asset, string, bool) {
+	if profile, ok := r.profiles[chainID]; ok && profile != nil {
+		if asset, ok := profile.asset(denom); ok {
+			return asset, "", true
+		}
+	}
+	trace, ok := r.traces[denom]
+	if !ok {
+		return Asset{}, "", false
+	}
+	for _, profile := range r.profiles {
+		if profile == nil {
+			continue
+		}
+		if asset, ok := profile.asset(trace.BaseDenom); ok {
+			return asset, trace.Path, true
+		}
+	}
+	return Asset{Base: trace.BaseDenom, Display: trace.BaseDenom}, trace.Path, true
+}
+
+// display returns the coins in their display denoms.
+func (r *denomResolver) display(chainID string, coins sdk.Coins) string {
+	displayed := make([]string, len(coins))
+	for i, coin := range coins {
+		asset, path, ok := r.lookup(chainID, coin.Denom)
+		if !ok {
+			displayed[i] = coin.String()
+			continue
+		}
+		amount, symbol := asset.displayAmount(coin.Amount)
+		displayed[i] = formatDec(amount) + " " + symbol
+		if path != "" {
+			displayed[i] += " (" + path + ")"
+		}
+	}
+	return strings.Join(displayed, ", ")
+}
+
+// formatDec formats a decimal without its trailing zeros.
+func formatDec(d math.LegacyDec) string {
+	s := d.String()
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// parseReportTime parses a --since or --until date, in RFC 3339 or
+// YYYY-MM-DD format.
+func parseReportTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, s)
+}
+
+// buildReport returns the rows of the transactions signed between since and
+// until. The records of the signers of the same transaction, signed in one
+// pass, are merged.
+func buildReport(entries []auditEntry, resolver *denomResolver, chainID string, since, until time.Time) ([]reportRow, error) {
+	var (
+		rows = []reportRow{}
+		prev AuditRecord
+	)
+	for _, entry := range entries {
+		var record AuditRecord
+		if err := json.Unmarshal(entry.Record, &record); err != nil {
+			return nil, fmt.Errorf("entry %d: %w", entry.Index, err)
+		}
+		if record.operation() != auditOperationSign {
+			continue
+		}
+		if (chainID != "" && record.ChainID != chainID) || record.Time.Before(since) || (!until.IsZero() && !record.Time.Before(until)) {
+			continue
+		}
+		if record.TxHash == prev.TxHash && record.Time.Equal(prev.Time) {
+			for i := len(rows) - 1; i >= 0 && rows[i].TxHash == record.TxHash; i-- {
+				rows[i].Signers = append(rows[i].Signers, record.Signer)
+			}
+			continue
+		}
+		prev = record
+
+		for i, msg := range flattenMsgs(record.Msgs) {
+			row := reportRow{
+				Time:           record.Time,
+				ChainID:        record.ChainID,
+				Signers:        []string{record.Signer},
+				TxHash:         record.TxHash,
+				Partial:        record.partial(),
+				MsgType:        msg.TypeURL,
+				Counterparties: msg.Recipients,
+				Amount:         msg.Amount,
+				DisplayAmount:  resolver.display(record.ChainID, msg.Amount),
+			}
+			// the fee is reported once per transaction
+			if i == 0 {
+				row.Fee = record.Fee
+				row.DisplayFee = resolver.display(record.ChainID, record.Fee)
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// partial reports whether the signature is known not to complete the
+// transaction, its hash then not being the hash of the broadcast transaction.
+func (r AuditRecord) partial() bool {
+	return strings.Contains(r.Command, " pst ") || r.Account != r.Signer
+}
+
+func exportReportCSV(rows []reportRow) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"date", "chain_id", "signers", "tx_hash", "partial", "msg_type", "counterparties", "amount", "display_amount", "fee", "display_fee"})
+	for _, row := range rows {
+		_ = w.Write([]string{
+			row.Time.Format(time.RFC3339),
+			row.ChainID,
+			strings.Join(row.Signers, " "),
+			row.TxHash,
+			strconv.FormatBool(row.Partial),
+			row.MsgType,
+			strings.Join(row.Counterparties, " "),
+			row.Amount.String(),
+			row.DisplayAmount,
+			row.Fee.String(),
+			row.DisplayFee,
+		})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// GetReportCommand returns the report command.
+func GetReportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Report the signed transactions for accounting",
+		Long: `Report the messages of the transactions signed between --since and --until,
+from the audit log, with their date, chain, signers, type, counterparties,
+amounts and fees, in JSON or CSV.
+
+The amounts are also given in display denoms, resolved with the assets of the
+chain profiles (the "assets" of config/chains/<chain-id>.json, as in the
+assetlist.json files of the chain registry). IBC denoms are resolved through
+the local table of denom traces config/ibc_denoms.json, e.g.:
+
+{
+  "ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2": {
+    "path": "transfer/channel-0",
+    "base_denom": "uatom"
+  }
+}
+
+The tx_hash is the hash of the transaction as signed. It is the hash of the
+broadcast transaction only if the signature completed the transaction: the
+signatures of PSTs (pst sign) and on behalf of multisig accounts are marked
+partial, their transaction being completed, and hashed again, later. The
+records of the audit logs written before the operations were recorded are
+read as signatures, except for the approvals and policy denials.
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			clientCtx := client.GetClientContextFromCmd(cmd)
+
+			f := cmd.Flags()
+			format, err := f.GetString(flagFormat)
+			if err != nil {
+				return err
+			}
+			// the chain-id flag has a default value in the client config
+			var chainID string
+			if f.Changed(flags.FlagChainID) {
+				if chainID, err = f.GetString(flags.FlagChainID); err != nil {
+					return err
+				}
+			}
+			var since, until time.Time
+			if s, _ := f.GetString(flagSince); s != "" {
+				if since, err = parseReportTime(s); err != nil {
+					return fmt.Errorf("invalid --%s: %w", flagSince, err)
+				}
+			}
+			if s, _ := f.GetString(flagUntil); s != "" {
+				if until, err = parseReportTime(s); err != nil {
+					return fmt.Errorf("invalid --%s: %w", flagUntil, err)
+				}
+			}
+
+			entries, err := readAuditLog(auditLogPath(clientCtx.HomeDir))
+			if err != nil {
+				return err
+			}
+			if err := verifyAuditLog(entries); err != nil {
+				return fmt.Errorf("audit log verification failed: %w", err)
+			}
+			resolver, err := newDenomResolver(clientCtx.HomeDir)
+			if err != nil {
+				return err
+			}
+			rows, err := buildReport(entries, resolver, chainID, since, until)
+			if err != nil {
+				return err
+			}
+
+			var bz []byte
+			switch format {
+			case formatJSON:
+				bz, err = json.MarshalIndent(rows, "", "  ")
+			case formatCSV:
+				bz, err = exportReportCSV(rows)
+			default:
+				return fmt.Errorf("invalid --%s %q, expected %s or %s", flagFormat, format, formatJSON, formatCSV)
+			}
+			if err != nil {
+				return err
+			}
+			return printOutput(cmd, bytes.TrimSuffix(bz, []byte("\n")))
+		},
+	}
+
+	cmd.Flags().String(flagFormat, formatCSV, "The report format (json|csv)")
+	cmd.Flags().String(flagSince, "", "Report the transactions signed from this date (YYYY-MM-DD or RFC 3339)")
+	cmd.Flags().String(flagUntil, "", "Report the transactions signed before this date (YYYY-MM-DD or RFC 3339)")
+	cmd.Flags().String(flags.FlagChainID, "", "Report the transactions of this chain only")
+	cmd.Flags().String(flags.FlagOutputDocument, "", "The document will be written to the given file instead of STDOUT")
+
+	return cmd
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestBuildReport(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	send := `[{"type_url": "/cosmos.bank.v1beta1.MsgSend", "recipients": ["cosmos1bob"], "amount": [{"denom": "uatom", "amount": "10"}]}]`
+	record := func(fields string) json.RawMessage {
+		return json.RawMessage(`{"time": "` + at.Format(time.RFC3339) + `", "chain_id": "test-chain", "account_number": "1", "sequence": "2", "msgs": ` + send + `, ` + fields + `}`)
+	}
+
+	tests := []struct {
+		name    string
+		record  json.RawMessage
+		signer  string
+		partial bool
+	}{
+		{name: "signature", record: record(`"operation": "sign", "command": "cosmos-signer tx sign", "signer": "alice", "account": "alice", "tx_hash": "A"`), signer: "alice"},
+		{name: "legacy signature", record: record(`"command": "cosmos-signer tx sign", "signer": "alice", "account": "alice", "tx_hash": "A", "policy": {"allowed": true}`), signer: "alice"},
+		{name: "approval", record: record(`"operation": "approve", "command": "cosmos-signer approve", "signer": "carol", "account": "alice", "tx_hash": "A"`)},
+		{name: "legacy approval", record: record(`"command": "cosmos-signer approve", "signer": "carol", "account": "alice", "tx_hash": "A"`)},
+		{name: "legacy denial", record: record(`"command": "cosmos-signer tx sign", "signer": "alice", "account": "alice", "tx_hash": "A", "policy": {"allowed": false}`)},
+		{name: "PST signature", record: record(`"operation": "sign", "command": "cosmos-signer pst sign", "signer": "alice", "account": "alice", "tx_hash": "A"`), signer: "alice", partial: true},
+		{name: "multisig signature", record: record(`"operation": "sign", "command": "cosmos-signer tx sign", "signer": "alice", "account": "multisig", "tx_hash": "A"`), signer: "alice", partial: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := buildReport([]auditEntry{{Record: tt.record}}, &denomResolver{}, "", time.Time{}, time.Time{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.signer == "" {
+				if len(rows) != 0 {
+					t.Fatalf("got rows %v, want none", rows)
+				}
+				return
+			}
+			if len(rows) != 1 {
+				t.Fatalf("got %d rows, want 1", len(rows))
+			}
+			if rows[0].Signers[0] != tt.signer || rows[0].Partial != tt.partial || rows[0].Amount.String() != "10uatom" {
+				t.Fatalf("got row %+v, want signer %s and partial %t", rows[0], tt.signer, tt.partial)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const flagForce = "force"
+
+// getMsgSigners returns the addresses of the signers of msg, as defined by
+// its cosmos.msg.v1.signer annotation or, for messages defined before it was
+// introduced, by its GetSigners method.
+func getMsgSigners(clientCtx client.Context, msg sdk.Msg) ([]string, error) {
+	signers, _, err := clientCtx.Codec.GetMsgV1Signers(msg)
+	if err != nil {
+		legacyMsg, ok := msg.(sdk.LegacyMsg)
+		if !ok {
+			return nil, fmt.Errorf("failed to resolve the signers of %s: %w", sdk.MsgTypeURL(msg), err)
+		}
+		var addrs []string
+		for _, signer := range legacyMsg.GetSigners() {
+			addrs = append(addrs, signer.String())
+		}
+		return addrs, nil
+	}
+
+	addrs := make([]string, len(signers))
+	for i, signer := range signers {
+		addrs[i] = sdk.AccAddress(signer).String()
+	}
+	return addrs, nil
+}
+
+// getTxSigners returns the addresses of the required signers of the
+// transaction, in the order the signer infos must follow: the signers of each
+// message in order, without duplicates, followed by the fee payer, if it is
+// set and not a signer already.
+func getTxSigners(clientCtx client.Context, tx sdk.Tx) ([]string, error) {
+	var (
+		signers []string
+		seen    = make(map[string]struct{})
+	)
+	addSigner := func(signer string) {
+		if _, ok := seen[signer]; !ok {
+			seen[signer] = struct{}{}
+			signers = append(signers, signer)
+		}
+	}
+
+	for _, msg := range tx.GetMsgs() {
+		msgSigners, err := getMsgSigners(clientCtx, msg)
+		if err != nil {
+			return nil, err
+		}
+		for _, signer := range msgSigners {
+			addSigner(signer)
+		}
+	}
+
+	protoTx, err := getProtoTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	if payer := protoTx.AuthInfo.GetFee().GetPayer(); payer != "" {
+		addSigner(payer)
+	}
+
+	return signers, nil
+}
+
+// checkRequiredSigners makes sure that every signing key is a required
+// signer of the transaction. With --force, a warning is printed instead of
+// refusing to sign.
+func checkRequiredSigners(cmd *cobra.Command, txSigners []string, keys []signingKey) error {
+	force, err := cmd.Flags().GetBool(flagForce)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		addr := key.Address
+		if slices.Contains(txSigners, addr.String()) {
+			continue
+		}
+		msg := fmt.Sprintf("%s is not a required signer of the transaction, expected one of %s", addr, strings.Join(txSigners, ", "))
+		if !force {
+			return fmt.Errorf("%s; use --%s to sign anyway", msg, flagForce)
+		}
+		cmd.PrintErrf("WARNING: %s\n", msg)
+	}
+	return nil
+}
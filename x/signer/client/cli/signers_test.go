@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+)
+
+func TestGetTxSigners(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	bob := newTestKey(t, clientCtx, "bob")
+	carol := newTestKey(t, clientCtx, "carol")
+
+	tests := []struct {
+		name  string
+		msgs  []sdk.Msg
+		payer sdk.AccAddress
+		want  []string
+	}{
+		{
+			name: "message order",
+			msgs: []sdk.Msg{newTestSend(bob, alice, 1), newTestSend(alice, bob, 1), newTestSend(bob, carol, 1)},
+			want: []string{bob.String(), alice.String()},
+		},
+		{
+			name:  "fee payer last",
+			msgs:  []sdk.Msg{newTestSend(alice, bob, 1)},
+			payer: carol,
+			want:  []string{alice.String(), carol.String()},
+		},
+		{
+			name:  "fee payer signing",
+			msgs:  []sdk.Msg{newTestSend(alice, bob, 1), newTestSend(carol, bob, 1)},
+			payer: alice,
+			want:  []string{alice.String(), carol.String()},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txBuilder := clientCtx.TxConfig.NewTxBuilder()
+			if err := txBuilder.SetMsgs(tt.msgs...); err != nil {
+				t.Fatal(err)
+			}
+			txBuilder.SetFeePayer(tt.payer)
+			got, err := getTxSigners(clientCtx, txBuilder.GetTx())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("got signers %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignRequiredSigners(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	bob := newTestKey(t, clientCtx, "bob")
+	file := writeTestTx(t, clientCtx, newTestTx(t, clientCtx, nil, 200000, newTestSend(alice, bob, 10)))
+
+	tests := []struct {
+		name        string
+		args        []string
+		wantSigs    int
+		wantWarning bool
+		wantErr     string
+	}{
+		{name: "required signer", args: []string{"--from", "alice", "-a", "1", "-s", "5"}, wantSigs: 1},
+		{name: "not a signer", args: []string{"--from", "bob", "-a", "2", "-s", "6"}, wantErr: "is not a required signer"},
+		{name: "not a signer forced", args: []string{"--from", "bob", "-a", "2", "-s", "6", "--" + flagForce}, wantSigs: 1, wantWarning: true},
+		{name: "one of several keys", args: []string{"--from", "alice", "--from", "bob", "-a", "1", "-a", "2", "-s", "5", "-s", "6"}, wantErr: "is not a required signer"},
+		{
+			name:        "one of several keys forced",
+			args:        []string{"--from", "alice", "--from", "bob", "-a", "1", "-a", "2", "-s", "5", "-s", "6", "--" + flagForce},
+			wantSigs:    2,
+			wantWarning: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientCtx := clientCtx.WithHomeDir(t.TempDir())
+			cmd := GetSignCommand()
+			stderr := &bytes.Buffer{}
+			cmd.SetErr(stderr)
+			bz, err := runTestCommand(t, clientCtx, cmd, append([]string{file, "--" + flags.FlagOffline}, tt.args...)...)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			case tt.wantErr != "":
+				return
+			}
+
+			tx, err := clientCtx.TxConfig.TxJSONDecoder()(bz)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sigs, err := tx.(authsigning.Tx).GetSignaturesV2()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sigs) != tt.wantSigs {
+				t.Fatalf("got %d signatures, want %d", len(sigs), tt.wantSigs)
+			}
+			if got := strings.Contains(stderr.String(), "WARNING: "+bob.String()+" is not a required signer"); got != tt.wantWarning {
+				t.Fatalf("got warning %t, want %t: %q", got, tt.wantWarning, stderr)
+			}
+		})
+	}
+}
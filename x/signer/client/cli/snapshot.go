@@ -0,0 +1,303 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	cmtbytes "github.com/cometbft/cometbft/libs/bytes"
+	cmtcrypto "github.com/cometbft/cometbft/proto/tendermint/crypto"
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+)
+
+const (
+	flagAccountsSnapshot        = "accounts-snapshot"
+	flagSnapshotMaxAge          = "snapshot-max-age"
+	flagAllowUnverifiedSnapshot = "allow-unverified-snapshot"
+
+	defaultSnapshotMaxAge = 24 * time.Hour
+)
+
+// AccountsSnapshot is the state of accounts, exported on the online side
+// with 'accounts export' and carried to the signer, at a reference height.
+// AppHash is the app hash of the block following the reference height, the
+// accounts are proven against.
+type AccountsSnapshot struct {
+	ChainID  string            `json:"chain_id"`
+	Height   int64             `json:"height,string"`
+	Time     time.Time         `json:"time"`
+	AppHash  cmtbytes.HexBytes `json:"app_hash,omitempty"`
+	Accounts []AccountSnapshot `json:"accounts"`
+}
+
+// AccountSnapshot is the state of an account. PubKey is nil if the account
+// never signed a transaction. Value is the account in the auth store, and
+// Proof its ICS23 proof up to the app hash.
+type AccountSnapshot struct {
+	Address       string              `json:"address"`
+	AccountNumber uint64              `json:"account_number,string"`
+	Sequence      uint64              `json:"sequence,string"`
+	PubKey        json.RawMessage     `json:"pub_key,omitempty"`
+	Value         []byte              `json:"value,omitempty"`
+	Proof         *cmtcrypto.ProofOps `json:"proof,omitempty"`
+}
+
+// LoadAccountsSnapshot loads an accounts snapshot from file.
+func LoadAccountsSnapshot(file string) (*AccountsSnapshot, error) {
+	bz, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot AccountsSnapshot
+	if err := json.Unmarshal(bz, &snapshot); err != nil {
+		return nil, fmt.Errorf("invalid accounts snapshot %s: %w", file, err)
+	}
+	if snapshot.Height <= 0 {
+		return nil, fmt.Errorf("invalid accounts snapshot %s: invalid height %d", file, snapshot.Height)
+	}
+	return &snapshot, nil
+}
+
+// loadSnapshotFromFlags loads the snapshot set with --accounts-snapshot,
+// nil if not set, and checks it.
+func loadSnapshotFromFlags(clientCtx client.Context, cmd *cobra.Command) (*AccountsSnapshot, error) {
+	file, err := cmd.Flags().GetString(flagAccountsSnapshot)
+	if err != nil || file == "" {
+		return nil, err
+	}
+	snapshot, err := LoadAccountsSnapshot(file)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSnapshot(clientCtx, cmd, snapshot, file); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// addSnapshotCheckFlags adds the flags checking the accounts snapshots.
+func addSnapshotCheckFlags(cmd *cobra.Command) {
+	cmd.Flags().Duration(flagSnapshotMaxAge, defaultSnapshotMaxAge, "Refuse accounts snapshots older than this duration, 0 to disable")
+	cmd.Flags().String(flagTrustedAppHash, "", "The hex app hash, of the block following the snapshot height, to verify the accounts snapshot against")
+	cmd.Flags().Int64(flagTrustedHeight, 0, "The height of the block the --trusted-app-hash was read from")
+	cmd.Flags().Bool(flagAllowUnverifiedSnapshot, false, "Use the accounts snapshot without --trusted-app-hash, printing a warning")
+}
+
+// checkSnapshot checks that the snapshot read from file is of the chain
+// being signed for, not older than --snapshot-max-age and proven against
+// --trusted-app-hash, unless --allow-unverified-snapshot is set.
+func checkSnapshot(clientCtx client.Context, cmd *cobra.Command, snapshot *AccountsSnapshot, file string) error {
+	maxAge, err := cmd.Flags().GetDuration(flagSnapshotMaxAge)
+	if err != nil {
+		return err
+	}
+	if snapshot.ChainID != clientCtx.ChainID {
+		return fmt.Errorf("accounts snapshot %s is for chain %s, not %s", file, snapshot.ChainID, clientCtx.ChainID)
+	}
+	if age := time.Since(snapshot.Time); maxAge > 0 && age > maxAge {
+		return fmt.Errorf("accounts snapshot %s is stale: taken at height %d on %s, more than %s ago",
+			file, snapshot.Height, snapshot.Time.Format(time.RFC3339), maxAge)
+	}
+	trustedAppHash, err := cmd.Flags().GetString(flagTrustedAppHash)
+	if err != nil {
+		return err
+	}
+	trustedHeight, err := cmd.Flags().GetInt64(flagTrustedHeight)
+	if err != nil {
+		return err
+	}
+	allowUnverified, err := cmd.Flags().GetBool(flagAllowUnverifiedSnapshot)
+	if err != nil {
+		return err
+	}
+	switch {
+	case trustedAppHash != "":
+		return verifySnapshot(clientCtx, snapshot, trustedAppHash, trustedHeight)
+	case allowUnverified:
+		cmd.PrintErrf("WARNING: the accounts snapshot %s is not verified, its accounts and height %d are used as is\n", file, snapshot.Height)
+		return nil
+	default:
+		return fmt.Errorf("the accounts snapshot %s is not verified, use --%s and --%s, or --%s",
+			file, flagTrustedAppHash, flagTrustedHeight, flagAllowUnverifiedSnapshot)
+	}
+}
+
+// Account returns the state of addr, matched by address bytes.
+func (s *AccountsSnapshot) Account(addr sdk.AccAddress) (AccountSnapshot, bool) {
+	for _, account := range s.Accounts {
+		_, bz, err := bech32.DecodeAndConvert(account.Address)
+		if err == nil && bytes.Equal(bz, addr) {
+			return account, true
+		}
+	}
+	return AccountSnapshot{}, false
+}
+
+// snapshotAccount returns the state of addr in the snapshot. The snapshot is
+// refused if it is older than the signatures of the sequence journal, or if
+// its public key does not match pubKey.
+func snapshotAccount(clientCtx client.Context, journal *sequenceJournal, snapshot *AccountsSnapshot, addr sdk.AccAddress, pubKey cryptotypes.PubKey) (AccountSnapshot, error) {
+	account, ok := snapshot.Account(addr)
+	if !ok {
+		return AccountSnapshot{}, fmt.Errorf("account %s not found in the accounts snapshot", addr)
+	}
+	next, ok, err := journal.NextSequence(snapshot.ChainID, addr.String())
+	if err != nil {
+		return AccountSnapshot{}, err
+	}
+	if ok && next > account.Sequence+1 {
+		return AccountSnapshot{}, fmt.Errorf("accounts snapshot is stale: sequence %d of account %s was already signed, the snapshot sequence is %d",
+			next-1, addr, account.Sequence)
+	}
+	if len(account.PubKey) > 0 && pubKey != nil {
+		var snapshotPubKey cryptotypes.PubKey
+		if err := clientCtx.Codec.UnmarshalInterfaceJSON(account.PubKey, &snapshotPubKey); err != nil {
+			return AccountSnapshot{}, fmt.Errorf("invalid public key of account %s in the accounts snapshot: %w", addr, err)
+		}
+		if !snapshotPubKey.Equals(pubKey) {
+			return AccountSnapshot{}, fmt.Errorf("the public key of account %s in the accounts snapshot does not match the key", addr)
+		}
+	}
+	return account, nil
+}
+
+// exportAccounts queries the node of clientCtx for the state of the accounts
+// and their proofs, at the height of clientCtx or the latest height with a
+// following block.
+func exportAccounts(ctx context.Context, clientCtx client.Context, addrs []sdk.AccAddress) (*AccountsSnapshot, error) {
+	node, err := clientCtx.GetNode()
+	if err != nil {
+		return nil, err
+	}
+
+	// the state at a height is committed in the app hash of the following
+	// block
+	height := clientCtx.Height
+	if height == 0 {
+		status, err := node.Status(ctx)
+		if err != nil {
+			return nil, err
+		}
+		height = status.SyncInfo.LatestBlockHeight - 1
+	}
+	block, err := node.Block(ctx, &height)
+	if err != nil {
+		return nil, err
+	}
+	nextHeight := height + 1
+	nextBlock, err := node.Block(ctx, &nextHeight)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &AccountsSnapshot{
+		ChainID: block.Block.ChainID,
+		Height:  height,
+		Time:    block.Block.Time.UTC(),
+		AppHash: nextBlock.Block.AppHash,
+	}
+
+	clientCtx = clientCtx.WithHeight(height)
+	for _, addr := range addrs {
+		account, err := authtypes.AccountRetriever{}.GetAccount(clientCtx, addr)
+		if err != nil {
+			return nil, fmt.Errorf("query account %s: %w", addr, err)
+		}
+		res, err := queryAccountProof(clientCtx, addr, height)
+		if err != nil {
+			return nil, err
+		}
+		entry := AccountSnapshot{
+			Address:       addr.String(),
+			AccountNumber: account.GetAccountNumber(),
+			Sequence:      account.GetSequence(),
+			Value:         res.Value,
+			Proof:         res.ProofOps,
+		}
+		if pubKey := account.GetPubKey(); pubKey != nil {
+			if entry.PubKey, err = clientCtx.Codec.MarshalInterfaceJSON(pubKey); err != nil {
+				return nil, err
+			}
+		}
+		if err := entry.verify(clientCtx, snapshot.AppHash); err != nil {
+			return nil, fmt.Errorf("verify account %s: %w", addr, err)
+		}
+		snapshot.Accounts = append(snapshot.Accounts, entry)
+	}
+	return snapshot, nil
+}
+
+// GetAccountsCommand returns the accounts command.
+func GetAccountsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                        "accounts",
+		Short:                      "Account snapshot subcommands",
+		DisableFlagParsing:         false,
+		SuggestionsMinimumDistance: 2,
+		RunE:                       client.ValidateCmd,
+	}
+
+	cmd.AddCommand(
+		GetAccountsExportCommand(),
+	)
+
+	return cmd
+}
+
+// GetAccountsExportCommand returns the accounts export command.
+func GetAccountsExportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export [address...]",
+		Short: "Export a snapshot of accounts from a node",
+		Long: `Query a node for the account numbers, sequences and public keys of the given
+addresses, all at the same height, and write them to an accounts snapshot
+along with the chain-id, the height and the block time.
+
+Every account is exported with its ICS23 proof up to the app hash of the
+following block, so that the offline machine can verify it against the app
+hash of a block header confirmed by the operator, see 'tx sign --help'. The
+latest height with a following block is used unless --height is set.
+
+The snapshot is meant for the offline machine, where 'tx sign
+--accounts-snapshot' reads the account number and sequence of every signer
+from it instead of --account-number and --sequence.
+`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+			addrs := make([]sdk.AccAddress, len(args))
+			for i, arg := range args {
+				if addrs[i], err = sdk.AccAddressFromBech32(arg); err != nil {
+					return err
+				}
+			}
+			snapshot, err := exportAccounts(cmd.Context(), clientCtx, addrs)
+			if err != nil {
+				return err
+			}
+
+			bz, err := json.MarshalIndent(snapshot, "", "  ")
+			if err != nil {
+				return err
+			}
+			return printOutput(cmd, bz)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+	cmd.Flags().String(flags.FlagOutputDocument, "", "The document will be written to the given file instead of STDOUT")
+
+	return cmd
+}
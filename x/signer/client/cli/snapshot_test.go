@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+)
+
+// testPubKey returns the public key of the key name of the keyring.
+func testPubKey(t *testing.T, clientCtx client.Context, name string) cryptotypes.PubKey {
+	t.Helper()
+	record, err := clientCtx.Keyring.Key(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubKey, err := record.GetPubKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pubKey
+}
+
+// newTestAccountSnapshot returns the snapshot of the account of pubKey.
+func newTestAccountSnapshot(t *testing.T, clientCtx client.Context, pubKey cryptotypes.PubKey, accountNumber, sequence uint64) AccountSnapshot {
+	t.Helper()
+	bz, err := clientCtx.Codec.MarshalInterfaceJSON(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return AccountSnapshot{
+		Address:       sdk.AccAddress(pubKey.Address()).String(),
+		AccountNumber: accountNumber,
+		Sequence:      sequence,
+		PubKey:        bz,
+	}
+}
+
+func TestSnapshotAccount(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	bob := newTestKey(t, clientCtx, "bob")
+	carol := newTestKey(t, clientCtx, "carol")
+	alicePubKey, bobPubKey := testPubKey(t, clientCtx, "alice"), testPubKey(t, clientCtx, "bob")
+	osmoBob, err := bech32.ConvertAndEncode("osmo", bob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot := &AccountsSnapshot{
+		ChainID: testChainID,
+		Height:  1000,
+		Accounts: []AccountSnapshot{
+			newTestAccountSnapshot(t, clientCtx, alicePubKey, 3, 7),
+			// an account which never signed, listed with another prefix
+			{Address: osmoBob, AccountNumber: 4, Sequence: 0},
+			// an account with the public key of another one
+			newTestAccountSnapshot(t, clientCtx, alicePubKey, 5, 0),
+		},
+	}
+	snapshot.Accounts[2].Address = carol.String()
+
+	journal, err := openJournal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	tests := []struct {
+		name              string
+		addr              sdk.AccAddress
+		pubKey            cryptotypes.PubKey
+		signed            uint64
+		wantAccountNumber uint64
+		wantErr           string
+	}{
+		{name: "account", addr: alice, pubKey: alicePubKey, wantAccountNumber: 3},
+		{name: "signed at the snapshot", addr: alice, pubKey: alicePubKey, signed: 7, wantAccountNumber: 3},
+		{name: "signed after the snapshot", addr: alice, pubKey: alicePubKey, signed: 8, wantErr: "sequence 8 of account " + alice.String() + " was already signed"},
+		{name: "no public key", addr: bob, pubKey: bobPubKey, wantAccountNumber: 4},
+		{name: "other public key", addr: carol, pubKey: testPubKey(t, clientCtx, "carol"), wantErr: "does not match the key"},
+		{name: "unknown account", addr: sdk.AccAddress("unknown_____________"), wantErr: "not found in the accounts snapshot"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.signed > 0 {
+				sig := journalSignature{Account: tt.addr.String(), Sequence: tt.signed, SignBytes: []byte(tt.name)}
+				if err := journal.Record(testChainID, []journalSignature{sig}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			account, err := snapshotAccount(clientCtx, journal, snapshot, tt.addr, tt.pubKey)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			case tt.wantErr != "":
+				return
+			}
+			if account.AccountNumber != tt.wantAccountNumber {
+				t.Fatalf("got account number %d, want %d", account.AccountNumber, tt.wantAccountNumber)
+			}
+		})
+	}
+}
+
+func TestSignAccountsSnapshot(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	bob := newTestKey(t, clientCtx, "bob")
+	account := newTestAccountSnapshot(t, clientCtx, testPubKey(t, clientCtx, "alice"), 3, 7)
+	file := writeTestTx(t, clientCtx, newTestTx(t, clientCtx, nil, 200000, newTestSend(alice, bob, 10)))
+	verified, appHash := newTestSnapshot(t, alice)
+	verified.Time = time.Now()
+	trusted := []string{"--" + flagTrustedAppHash, hex.EncodeToString(appHash), "--" + flagTrustedHeight, strconv.FormatInt(verified.Height+1, 10)}
+	unverified := []string{"--" + flagAllowUnverifiedSnapshot}
+
+	tests := []struct {
+		name              string
+		snapshot          *AccountsSnapshot
+		args              []string
+		wantAccountNumber uint64
+		wantSequence      uint64
+		wantErr           string
+	}{
+		{name: "verified snapshot", snapshot: verified, args: trusted, wantAccountNumber: 10, wantSequence: 20},
+		{
+			name:              "unverified snapshot allowed",
+			snapshot:          &AccountsSnapshot{ChainID: testChainID, Height: 1000, Time: time.Now(), Accounts: []AccountSnapshot{account}},
+			args:              unverified,
+			wantAccountNumber: 3,
+			wantSequence:      7,
+		},
+		{
+			name:     "unverified snapshot",
+			snapshot: &AccountsSnapshot{ChainID: testChainID, Height: 1000, Time: time.Now(), Accounts: []AccountSnapshot{account}},
+			wantErr:  "is not verified",
+		},
+		{
+			name:     "stale snapshot",
+			snapshot: &AccountsSnapshot{ChainID: testChainID, Height: 1000, Time: time.Now().Add(-25 * time.Hour), Accounts: []AccountSnapshot{account}},
+			args:     unverified,
+			wantErr:  "is stale",
+		},
+		{
+			name:     "other chain",
+			snapshot: &AccountsSnapshot{ChainID: "other-chain", Height: 1000, Time: time.Now(), Accounts: []AccountSnapshot{account}},
+			args:     unverified,
+			wantErr:  "is for chain other-chain",
+		},
+		{
+			name:     "missing account",
+			snapshot: &AccountsSnapshot{ChainID: testChainID, Height: 1000, Time: time.Now()},
+			args:     unverified,
+			wantErr:  "not found in the accounts snapshot",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientCtx := clientCtx.WithHomeDir(t.TempDir())
+			bz, err := json.Marshal(tt.snapshot)
+			if err != nil {
+				t.Fatal(err)
+			}
+			snapshotFile := filepath.Join(t.TempDir(), "snapshot.json")
+			if err := os.WriteFile(snapshotFile, bz, 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			args := append([]string{file, "--" + flags.FlagOffline, "--from", "alice", "--" + flagAccountsSnapshot, snapshotFile}, tt.args...)
+			bz, err = runTestCommand(t, clientCtx, GetSignCommand(), args...)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			case tt.wantErr != "":
+				return
+			}
+			sigs := verifyTestSignatures(t, clientCtx, bz, tt.wantAccountNumber)
+			if sigs[0].Sequence != tt.wantSequence {
+				t.Fatalf("got sequence %d, want %d", sigs[0].Sequence, tt.wantSequence)
+			}
+		})
+	}
+}
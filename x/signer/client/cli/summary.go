@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"cosmossdk.io/math"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+var (
+	// senderFields are the JSON fields of the messages holding the addresses
+	// funds are taken from.
+	senderFields = []string{"from_address", "sender", "delegator_address", "depositor", "granter"}
+	// recipientFields are the JSON fields of the messages holding the
+	// addresses funds are sent or delegated to.
+	recipientFields = []string{"to_address", "receiver", "recipient", "validator_address", "validator_dst_address", "grantee"}
+	// amountFields are the JSON fields of the messages holding the coins
+	// leaving the signer account.
+	amountFields = []string{"amount", "token", "value", "funds", "initial_deposit"}
+)
+
+// msgSummary is a chain agnostic description of a message. It is built from
+// the message JSON encoding, so that it also covers the types registered by
+// the plugins, which are not known at build time.
+type msgSummary struct {
+	TypeURL    string       `json:"type_url"`
+	Signers    []string     `json:"signers,omitempty"`
+	Senders    []string     `json:"senders,omitempty"`
+	Recipients []string     `json:"recipients,omitempty"`
+	Amount     sdk.Coins    `json:"amount,omitempty"`
+	Msgs       []msgSummary `json:"msgs,omitempty"` // messages wrapped by authz MsgExec
+}
+
+// summarizeMsgs summarizes the messages of the transaction.
+func summarizeMsgs(clientCtx client.Context, tx sdk.Tx) ([]msgSummary, error) {
+	var summaries []msgSummary
+	for _, msg := range tx.GetMsgs() {
+		bz, err := clientCtx.Codec.MarshalJSON(msg)
+		if err != nil {
+			return nil, err
+		}
+		var fields map[string]any
+		if err := json.Unmarshal(bz, &fields); err != nil {
+			return nil, err
+		}
+		summary, err := summarizeMsgJSON(sdk.MsgTypeURL(msg), fields)
+		if err != nil {
+			return nil, err
+		}
+		if summary.Signers, err = getMsgSigners(clientCtx, msg); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+func summarizeMsgJSON(typeURL string, fields map[string]any) (msgSummary, error) {
+	summary := msgSummary{TypeURL: typeURL}
+
+	for _, field := range senderFields {
+		if addr, ok := fields[field].(string); ok && addr != "" {
+			summary.Senders = append(summary.Senders, addr)
+		}
+	}
+	// bank MsgMultiSend
+	for _, input := range asObjects(fields["inputs"]) {
+		if addr, ok := input["address"].(string); ok {
+			summary.Senders = append(summary.Senders, addr)
+		}
+	}
+
+	for _, field := range recipientFields {
+		if field == "grantee" && fields["msgs"] != nil {
+			// the grantee of authz MsgExec is the executor
+			continue
+		}
+		if addr, ok := fields[field].(string); ok && addr != "" {
+			summary.Recipients = append(summary.Recipients, addr)
+		}
+	}
+	// bank MsgMultiSend
+	for _, output := range asObjects(fields["outputs"]) {
+		if addr, ok := output["address"].(string); ok {
+			summary.Recipients = append(summary.Recipients, addr)
+		}
+	}
+
+	for _, field := range amountFields {
+		coins, err := parseCoinsJSON(fields[field])
+		if err != nil {
+			return msgSummary{}, fmt.Errorf("%s: invalid %s: %w", typeURL, field, err)
+		}
+		summary.Amount = summary.Amount.Add(coins...)
+	}
+	for _, input := range asObjects(fields["inputs"]) {
+		coins, err := parseCoinsJSON(input["coins"])
+		if err != nil {
+			return msgSummary{}, fmt.Errorf("%s: invalid inputs: %w", typeURL, err)
+		}
+		summary.Amount = summary.Amount.Add(coins...)
+	}
+
+	// authz MsgExec
+	for _, msg := range asObjects(fields["msgs"]) {
+		innerTypeURL, _ := msg["@type"].(string)
+		inner, err := summarizeMsgJSON(innerTypeURL, msg)
+		if err != nil {
+			return msgSummary{}, err
+		}
+		summary.Msgs = append(summary.Msgs, inner)
+	}
+
+	return summary, nil
+}
+
+// parseCoinsJSON parses a JSON decoded coin or list of coins. Values that
+// are not coins are ignored.
+func parseCoinsJSON(v any) (sdk.Coins, error) {
+	var coins sdk.Coins
+	objects := asObjects(v)
+	if obj, ok := v.(map[string]any); ok {
+		objects = []map[string]any{obj}
+	}
+	for _, obj := range objects {
+		denom, ok := obj["denom"].(string)
+		if !ok {
+			continue
+		}
+		amountStr, ok := obj["amount"].(string)
+		if !ok {
+			continue
+		}
+		amount, ok := math.NewIntFromString(amountStr)
+		if !ok {
+			return nil, fmt.Errorf("invalid amount %q", amountStr)
+		}
+		coin := sdk.Coin{Denom: denom, Amount: amount}
+		if err := coin.Validate(); err != nil {
+			return nil, err
+		}
+		coins = coins.Add(coin)
+	}
+	return coins, nil
+}
+
+// asObjects returns the JSON objects of a JSON decoded list.
+func asObjects(v any) []map[string]any {
+	list, ok := v.([]any)
+	if !ok {
+		return nil
+	}
+	var objects []map[string]any
+	for _, item := range list {
+		if obj, ok := item.(map[string]any); ok {
+			objects = append(objects, obj)
+		}
+	}
+	return objects
+}
+
+// flattenMsgs returns the summaries with the messages wrapped by authz
+// MsgExec in place of their wrapper.
+func flattenMsgs(summaries []msgSummary) []msgSummary {
+	var flat []msgSummary
+	for _, summary := range summaries {
+		if len(summary.Msgs) > 0 {
+			flat = append(flat, flattenMsgs(summary.Msgs)...)
+			continue
+		}
+		flat = append(flat, summary)
+	}
+	return flat
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	moduletestutil "github.com/cosmos/cosmos-sdk/types/module/testutil"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	authzmodule "github.com/cosmos/cosmos-sdk/x/authz/module"
+	"github.com/cosmos/cosmos-sdk/x/bank"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+)
+
+const testChainID = "test-chain"
+
+// newTestClientCtx returns a client context with the auth, bank and authz
+// types registered, an in-memory keyring and a temporary home.
+func newTestClientCtx(t *testing.T) client.Context {
+	t.Helper()
+	encCfg := moduletestutil.MakeTestEncodingConfig(auth.AppModuleBasic{}, bank.AppModuleBasic{}, authzmodule.AppModuleBasic{})
+	return client.Context{}.
+		WithCodec(encCfg.Codec).
+		WithInterfaceRegistry(encCfg.InterfaceRegistry).
+		WithTxConfig(encCfg.TxConfig).
+		WithLegacyAmino(encCfg.Amino).
+		WithKeyring(keyring.NewInMemory(encCfg.Codec)).
+		WithChainID(testChainID).
+		WithHomeDir(t.TempDir())
+}
+
+// newTestKey adds a new secp256k1 key to the keyring of clientCtx and
+// returns its address.
+func newTestKey(t *testing.T, clientCtx client.Context, name string) sdk.AccAddress {
+	t.Helper()
+	record, _, err := clientCtx.Keyring.NewMnemonic(name, keyring.English, sdk.FullFundraiserPath, keyring.DefaultBIP39Passphrase, hd.Secp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err := record.GetAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addr
+}
+
+// newTestSend returns a bank MsgSend of amount uatom.
+func newTestSend(from, to sdk.AccAddress, amount int64) *banktypes.MsgSend {
+	return banktypes.NewMsgSend(from, to, sdk.NewCoins(sdk.NewInt64Coin("uatom", amount)))
+}
+
+// newTestTx returns an unsigned transaction of msgs.
+func newTestTx(t *testing.T, clientCtx client.Context, fee sdk.Coins, gas uint64, msgs ...sdk.Msg) sdk.Tx {
+	t.Helper()
+	txBuilder := clientCtx.TxConfig.NewTxBuilder()
+	if err := txBuilder.SetMsgs(msgs...); err != nil {
+		t.Fatal(err)
+	}
+	txBuilder.SetFeeAmount(fee)
+	txBuilder.SetGasLimit(gas)
+	return txBuilder.GetTx()
+}
+
+// writeTestTx writes the JSON of tx to a temporary file and returns its
+// name.
+func writeTestTx(t *testing.T, clientCtx client.Context, tx sdk.Tx) string {
+	t.Helper()
+	bz, err := clientCtx.TxConfig.TxJSONEncoder()(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "tx.json")
+	if err := os.WriteFile(file, bz, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// runTestCommand executes cmd with args in clientCtx, with a temporary
+// plugins directory and output document, and returns the output.
+func runTestCommand(t *testing.T, clientCtx client.Context, cmd *cobra.Command, args ...string) ([]byte, error) {
+	t.Helper()
+	out := filepath.Join(t.TempDir(), "out.json")
+	cmd.SetContext(context.WithValue(context.Background(), client.ClientContextKey, &clientCtx))
+	cmd.SetArgs(append(args, "--"+flagPluginsDir, t.TempDir(), "--"+flags.FlagOutputDocument, out))
+	cmd.SilenceUsage, cmd.SilenceErrors = true, true
+	if err := cmd.Execute(); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(out)
+}
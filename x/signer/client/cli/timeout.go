@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// TimeoutRule bounds the timeout height of the transactions, so that a
+// signed transaction cannot be broadcast long after it was signed. The
+// bound is relative to the reference height of the accounts snapshot.
+//
+// Cosmos SDK v0.50 transactions have no timeout timestamp, the rule only
+// applies to the timeout height.
+type TimeoutRule struct {
+	Required  bool  `json:"required"`
+	MaxBlocks int64 `json:"max_blocks,omitempty"`
+}
+
+// getTimeoutRule returns the timeout rule of the chain profile, or of the
+// policy otherwise, nil if there is none.
+func getTimeoutRule(policy *Policy, profile *ChainProfile) *TimeoutRule {
+	if profile != nil && profile.Timeout != nil {
+		return profile.Timeout
+	}
+	if policy != nil {
+		return policy.Timeout
+	}
+	return nil
+}
+
+// check returns the reasons the timeout height breaks the rule.
+func (r TimeoutRule) check(timeoutHeight uint64, snapshot *AccountsSnapshot) []string {
+	if timeoutHeight == 0 {
+		if r.Required {
+			return []string{"a timeout height is required"}
+		}
+		return nil
+	}
+	if r.MaxBlocks <= 0 {
+		return nil
+	}
+	if snapshot == nil {
+		return []string{fmt.Sprintf("a reference height is required to check the timeout height, use --%s", flagAccountsSnapshot)}
+	}
+	height := int64(timeoutHeight)
+	if height <= snapshot.Height {
+		return []string{fmt.Sprintf("timeout height %d is not after the reference height %d", height, snapshot.Height)}
+	}
+	if height > snapshot.Height+r.MaxBlocks {
+		return []string{fmt.Sprintf("timeout height %d is %d blocks after the reference height %d, more than the maximum of %d blocks",
+			height, height-snapshot.Height, snapshot.Height, r.MaxBlocks)}
+	}
+	return nil
+}
+
+// getTimeoutCheck returns the reasons the timeout height of the transaction
+// breaks the timeout rule.
+func getTimeoutCheck(clientCtx client.Context, cmd *cobra.Command, tx sdk.Tx, snapshot *AccountsSnapshot) ([]string, error) {
+	policy, err := loadPolicyFromFlags(clientCtx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := loadChainProfile(clientCtx)
+	if err != nil {
+		return nil, err
+	}
+	rule := getTimeoutRule(policy, profile)
+	if rule == nil {
+		return nil, nil
+	}
+	protoTx, err := getProtoTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	return rule.check(protoTx.Body.TimeoutHeight, snapshot), nil
+}
+
+// checkTimeout refuses transactions breaking the timeout rule.
+func checkTimeout(clientCtx client.Context, cmd *cobra.Command, tx sdk.Tx, snapshot *AccountsSnapshot) error {
+	reasons, err := getTimeoutCheck(clientCtx, cmd, tx, snapshot)
+	if err != nil {
+		return err
+	}
+	if len(reasons) > 0 {
+		return fmt.Errorf("unsafe timeout height: %s", strings.Join(reasons, ", "))
+	}
+	return nil
+}
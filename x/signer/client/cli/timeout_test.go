@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/client/flags"
+)
+
+func TestTimeoutRule(t *testing.T) {
+	snapshot := &AccountsSnapshot{ChainID: testChainID, Height: 1000}
+	bounded := TimeoutRule{Required: true, MaxBlocks: 100}
+
+	tests := []struct {
+		name          string
+		rule          TimeoutRule
+		timeoutHeight uint64
+		snapshot      *AccountsSnapshot
+		want          string
+	}{
+		{name: "required", rule: bounded, snapshot: snapshot, want: "a timeout height is required"},
+		{name: "not required", rule: TimeoutRule{MaxBlocks: 100}, snapshot: snapshot},
+		{name: "unbounded", rule: TimeoutRule{Required: true}, timeoutHeight: 1000000},
+		{name: "within bounds", rule: bounded, timeoutHeight: 1050, snapshot: snapshot},
+		{name: "maximum", rule: bounded, timeoutHeight: 1100, snapshot: snapshot},
+		{name: "too far", rule: bounded, timeoutHeight: 1101, snapshot: snapshot, want: "101 blocks after the reference height 1000"},
+		{name: "expired", rule: bounded, timeoutHeight: 1000, snapshot: snapshot, want: "is not after the reference height 1000"},
+		{name: "no reference height", rule: bounded, timeoutHeight: 1050, want: "a reference height is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.Join(tt.rule.check(tt.timeoutHeight, tt.snapshot), ", ")
+			if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetTimeoutRule(t *testing.T) {
+	policyRule := &TimeoutRule{Required: true}
+	profileRule := &TimeoutRule{MaxBlocks: 10}
+
+	tests := []struct {
+		name    string
+		policy  *Policy
+		profile *ChainProfile
+		want    *TimeoutRule
+	}{
+		{name: "none"},
+		{name: "policy", policy: &Policy{Timeout: policyRule}, profile: &ChainProfile{}, want: policyRule},
+		{name: "chain profile", policy: &Policy{Timeout: policyRule}, profile: &ChainProfile{Timeout: profileRule}, want: profileRule},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getTimeoutRule(tt.policy, tt.profile); got != tt.want {
+				t.Fatalf("got rule %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignTimeout(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	bob := newTestKey(t, clientCtx, "bob")
+
+	tests := []struct {
+		name          string
+		profile       string
+		timeoutHeight uint64
+		wantErr       string
+	}{
+		{name: "no timeout height", wantErr: "a timeout height is required"},
+		{name: "timeout height", timeoutHeight: 1050},
+		{name: "chain profile override", profile: `{"chain_id": "test-chain", "timeout": {"required": false}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientCtx := clientCtx.WithHomeDir(t.TempDir())
+			policyFile := filepath.Join(clientCtx.HomeDir, "config", defaultPolicyFile)
+			if err := os.MkdirAll(filepath.Dir(policyFile), 0o700); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(policyFile, []byte(`{"rules": [], "timeout": {"required": true}}`), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if tt.profile != "" {
+				writeTestChainProfile(t, clientCtx, tt.profile)
+			}
+			txBuilder := clientCtx.TxConfig.NewTxBuilder()
+			if err := txBuilder.SetMsgs(newTestSend(alice, bob, 10)); err != nil {
+				t.Fatal(err)
+			}
+			txBuilder.SetTimeoutHeight(tt.timeoutHeight)
+			file := writeTestTx(t, clientCtx, txBuilder.GetTx())
+
+			args := []string{file, "--" + flags.FlagOffline, "--from", "alice", "-a", "1", "-s", "5"}
+			_, err := runTestCommand(t, clientCtx, GetSignCommand(), args...)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+)
+
+const (
+	flagSignature = "signature"
+	flagHex       = "hex"
+)
+
+// GetAttachSignatureCommand returns the transaction attach-signature command.
+func GetAttachSignatureCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "attach-signature [file]",
+		Short: "Attach an externally produced signature to a transaction",
+		Long: `Attach a raw signature produced outside of the signer, e.g. by an HSM over
+the bytes printed by the sign-bytes command, to the transaction in [file].
+
+The signature is verified against the sign bytes of the transaction for the
+given signer, which must be identified with the same flags used with the
+sign-bytes command, and the signed transaction is printed. The checks of
+sign-bytes are run again, and as with tx sign, the signature is checked
+against the sequence journal, recorded in the audit log and the spending
+ledger, and the post-sign hooks are run.
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientTxContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			sigBytes, err := readSignatureFlag(cmd)
+			if err != nil {
+				return err
+			}
+
+			txBuilder, signer, err := readTxAndSigner(clientCtx, cmd, args[0])
+			if err != nil {
+				return err
+			}
+			overwrite, err := cmd.Flags().GetBool(flagOverwrite)
+			if err != nil {
+				return err
+			}
+			guard, err := newExternalSignGuard(clientCtx, cmd, txBuilder.GetTx(), signer)
+			if err != nil {
+				return err
+			}
+			defer guard.Close()
+
+			signBytes, err := getSignBytes(cmd.Context(), clientCtx, txBuilder, signer, overwrite)
+			if err != nil {
+				return err
+			}
+			if !signer.PubKey.VerifySignature(signBytes, sigBytes) {
+				return errors.New("signature verification failed: the signature does not match the sign bytes and public key")
+			}
+			sigs := externalSignatures(signer, signBytes)
+			if err := guard.checkSignatures(sigs); err != nil {
+				return err
+			}
+			if err := setSignature(txBuilder, signer, sigBytes); err != nil {
+				return err
+			}
+			txHash, err := guard.record(txBuilder.GetTx(), sigs)
+			if err != nil {
+				return err
+			}
+
+			json, err := clientCtx.TxConfig.TxJSONEncoder()(txBuilder.GetTx())
+			if err != nil {
+				return err
+			}
+			if err := printOutput(cmd, json); err != nil {
+				return err
+			}
+			return guard.postSign(json, txHash)
+		},
+	}
+
+	cmd.Flags().String(flagSignature, "", "The raw signature, base64 encoded")
+	cmd.Flags().BoolP(flagHex, "x", false, "Treat the signature as hexadecimal instead of base64")
+	_ = cmd.MarkFlagRequired(flagSignature)
+	addSignerFlags(cmd)
+	addSignGuardFlags(cmd)
+
+	return cmd
+}
+
+func readSignatureFlag(cmd *cobra.Command) ([]byte, error) {
+	signature, err := cmd.Flags().GetString(flagSignature)
+	if err != nil {
+		return nil, err
+	}
+	useHex, err := cmd.Flags().GetBool(flagHex)
+	if err != nil {
+		return nil, err
+	}
+
+	var sigBytes []byte
+	if useHex {
+		sigBytes, err = hex.DecodeString(signature)
+	} else {
+		sigBytes, err = base64.StdEncoding.DecodeString(signature)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid --%s: %w", flagSignature, err)
+	}
+	return sigBytes, nil
+}
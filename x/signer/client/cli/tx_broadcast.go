@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	authtx "github.com/cosmos/cosmos-sdk/x/auth/tx"
+)
+
+const (
+	flagWaitTimeout = "wait-timeout"
+
+	// broadcastModeCommit broadcasts in sync mode and waits for the
+	// inclusion of the transaction in a block.
+	broadcastModeCommit = "commit"
+
+	pollInterval = time.Second
+)
+
+// readSignedTx reads a signed transaction, JSON or base64 protobuf encoded,
+// and returns its protobuf encoding.
+func readSignedTx(clientCtx client.Context, pluginsDir, filename string) ([]byte, error) {
+	bz, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	bz = bytes.TrimSpace(bz)
+	if bytes.HasPrefix(bz, []byte("{")) {
+		tx, err := decodeTxJSON(clientCtx, pluginsDir, bz)
+		if err != nil {
+			return nil, fmt.Errorf("JSON decode %s: %v", filename, err)
+		}
+		return clientCtx.TxConfig.TxEncoder()(tx)
+	}
+
+	txBytes, err := base64.StdEncoding.DecodeString(string(bz))
+	if err != nil {
+		return nil, fmt.Errorf("base64 decode %s: %v", filename, err)
+	}
+	// decoding checks the transaction and registers its types
+	if _, err := decodeTxBytes(clientCtx, pluginsDir, txBytes); err != nil {
+		return nil, fmt.Errorf("decode %s: %v", filename, err)
+	}
+	return txBytes, nil
+}
+
+// txServiceClient returns the client of the tx service, through gRPC with
+// --grpc-addr, or through the CometBFT RPC otherwise.
+func txServiceClient(clientCtx client.Context) txtypes.ServiceClient {
+	if clientCtx.GRPCClient != nil {
+		return txtypes.NewServiceClient(clientCtx.GRPCClient)
+	}
+	return txtypes.NewServiceClient(clientCtx)
+}
+
+// queryTx queries a transaction by hash, through gRPC with --grpc-addr, or
+// through the CometBFT RPC otherwise.
+func queryTx(ctx context.Context, clientCtx client.Context, hash string) (*sdk.TxResponse, error) {
+	if clientCtx.GRPCClient == nil {
+		return authtx.QueryTx(clientCtx, hash)
+	}
+	res, err := txServiceClient(clientCtx).GetTx(ctx, &txtypes.GetTxRequest{Hash: hash})
+	if err != nil {
+		return nil, err
+	}
+	return res.TxResponse, nil
+}
+
+// waitForTx polls the node until the transaction is included in a block, or
+// timeout.
+func waitForTx(ctx context.Context, clientCtx client.Context, hash string, timeout time.Duration) (*sdk.TxResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		res, err := queryTx(ctx, clientCtx, hash)
+		if err == nil {
+			return res, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("transaction %s not included after %s: %w", hash, timeout, err)
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetBroadcastCommand returns the transaction broadcast command.
+func GetBroadcastCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "broadcast [file]",
+		Short: "Broadcast a signed transaction to a node",
+		Long: `Broadcast the signed transaction in [file], JSON or base64 protobuf encoded,
+to the CometBFT RPC endpoint --node, or to the gRPC endpoint --grpc-addr.
+The message types are registered from the plugins found in --plugins-dir.
+
+With --broadcast-mode sync (the default) the result of the check of the
+transaction is reported, with async nothing is checked, and with commit the
+node is polled until the transaction is included in a block, up to
+--wait-timeout, and the result of its execution is reported.
+
+The command fails if the transaction is rejected or fails.
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+			f := cmd.Flags()
+			pluginsDir, err := f.GetString(flagPluginsDir)
+			if err != nil {
+				return err
+			}
+			mode, err := f.GetString(flags.FlagBroadcastMode)
+			if err != nil {
+				return err
+			}
+			timeout, err := f.GetDuration(flagWaitTimeout)
+			if err != nil {
+				return err
+			}
+
+			var txMode txtypes.BroadcastMode
+			switch mode {
+			case flags.BroadcastSync, broadcastModeCommit:
+				txMode = txtypes.BroadcastMode_BROADCAST_MODE_SYNC
+			case flags.BroadcastAsync:
+				txMode = txtypes.BroadcastMode_BROADCAST_MODE_ASYNC
+			default:
+				return fmt.Errorf("invalid --%s %q, expected %s, %s or %s", flags.FlagBroadcastMode, mode,
+					flags.BroadcastSync, flags.BroadcastAsync, broadcastModeCommit)
+			}
+
+			txBytes, err := readSignedTx(clientCtx, pluginsDir, args[0])
+			if err != nil {
+				return err
+			}
+			broadcastRes, err := txServiceClient(clientCtx).BroadcastTx(cmd.Context(), &txtypes.BroadcastTxRequest{
+				TxBytes: txBytes,
+				Mode:    txMode,
+			})
+			if err != nil {
+				return err
+			}
+			res := broadcastRes.TxResponse
+			if mode == broadcastModeCommit && res.Code == 0 {
+				if res, err = waitForTx(cmd.Context(), clientCtx, res.TxHash, timeout); err != nil {
+					return err
+				}
+			}
+
+			bz, err := clientCtx.Codec.MarshalJSON(res)
+			if err != nil {
+				return err
+			}
+			if err := printOutput(cmd, bz); err != nil {
+				return err
+			}
+			if res.Code != 0 {
+				return fmt.Errorf("transaction %s failed with code %d (codespace %s): %s", res.TxHash, res.Code, res.Codespace, res.RawLog)
+			}
+			return nil
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+	cmd.Flags().StringP(flags.FlagBroadcastMode, "b", flags.BroadcastSync, "Transaction broadcasting mode (sync|async|commit)")
+	cmd.Flags().Duration(flagWaitTimeout, time.Minute, "How long to wait for the inclusion of the transaction with --broadcast-mode commit")
+	cmd.Flags().String(flagPluginsDir, "", "The directory to search for plugin files")
+	cmd.Flags().String(flags.FlagOutputDocument, "", "The document will be written to the given file instead of STDOUT")
+	_ = cmd.MarkFlagRequired(flagPluginsDir)
+
+	return cmd
+}
@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	rpctypes "github.com/cometbft/cometbft/rpc/jsonrpc/types"
+	cmttypes "github.com/cometbft/cometbft/types"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+)
+
+// testRPCNode is a stand-in for the CometBFT RPC of a node, checking the
+// broadcast transactions with checkCode and including them in a block with
+// execCode.
+type testRPCNode struct {
+	checkCode, execCode uint32
+
+	mu      sync.Mutex
+	methods []string
+	tx      cmttypes.Tx
+}
+
+func (n *testRPCNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req rpctypes.RPCRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.methods = append(n.methods, req.Method)
+
+	var result any
+	switch req.Method {
+	case "broadcast_tx_sync", "broadcast_tx_async":
+		var params struct {
+			Tx []byte `json:"tx"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		n.tx = params.Tx
+		res := &coretypes.ResultBroadcastTx{Hash: n.tx.Hash()}
+		if req.Method == "broadcast_tx_sync" {
+			res.Code, res.Log = n.checkCode, "checked"
+		}
+		result = res
+	case "tx":
+		result = &coretypes.ResultTx{
+			Hash:     n.tx.Hash(),
+			Height:   5,
+			TxResult: abci.ExecTxResult{Code: n.execCode, Log: "executed"},
+			Tx:       n.tx,
+		}
+	case "block":
+		result = &coretypes.ResultBlock{Block: &cmttypes.Block{Header: cmttypes.Header{Height: 5, Time: time.Now()}}}
+	default:
+		http.Error(w, "unexpected method "+req.Method, http.StatusBadRequest)
+		return
+	}
+	bz, err := json.Marshal(rpctypes.NewRPCSuccessResponse(req.ID, result))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(bz)
+}
+
+func TestBroadcastCommand(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	txJSON, err := clientCtx.TxConfig.TxJSONEncoder()(newTestTx(t, clientCtx, nil, 200000, newTestSend(alice, alice, 10)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	txFile := filepath.Join(t.TempDir(), "tx.json")
+	if err := os.WriteFile(txFile, txJSON, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name                string
+		mode                string
+		checkCode, execCode uint32
+		methods             []string
+		height              string
+		wantErr             string
+	}{
+		{name: "sync", mode: flags.BroadcastSync, methods: []string{"broadcast_tx_sync"}, height: "0"},
+		{name: "async", mode: flags.BroadcastAsync, methods: []string{"broadcast_tx_async"}, height: "0"},
+		{name: "commit", mode: broadcastModeCommit, methods: []string{"broadcast_tx_sync", "tx", "block"}, height: "5"},
+		{name: "rejected", mode: broadcastModeCommit, checkCode: 13, methods: []string{"broadcast_tx_sync"}, wantErr: "failed with code 13"},
+		{name: "failed", mode: broadcastModeCommit, execCode: 5, methods: []string{"broadcast_tx_sync", "tx", "block"}, wantErr: "failed with code 5"},
+		{name: "invalid mode", mode: "block", wantErr: "invalid --broadcast-mode"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &testRPCNode{checkCode: tt.checkCode, execCode: tt.execCode}
+			server := httptest.NewServer(node)
+			defer server.Close()
+
+			out := filepath.Join(t.TempDir(), "res.json")
+			cmd := GetBroadcastCommand()
+			cmd.SetContext(context.WithValue(context.Background(), client.ClientContextKey, &clientCtx))
+			cmd.SetArgs([]string{
+				txFile,
+				"--" + flags.FlagNode, server.URL,
+				"--" + flags.FlagBroadcastMode, tt.mode,
+				"--" + flagPluginsDir, t.TempDir(),
+				"--" + flags.FlagOutputDocument, out,
+			})
+			cmd.SilenceUsage, cmd.SilenceErrors = true, true
+			err := cmd.Execute()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+			if strings.Join(node.methods, ",") != strings.Join(tt.methods, ",") {
+				t.Fatalf("got RPC calls %v, want %v", node.methods, tt.methods)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+
+			bz, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var res struct {
+				Height string `json:"height"`
+				TxHash string `json:"txhash"`
+			}
+			if err := json.Unmarshal(bz, &res); err != nil {
+				t.Fatal(err)
+			}
+			if hash := fmt.Sprintf("%X", node.tx.Hash()); res.Height != tt.height || res.TxHash != hash {
+				t.Fatalf("got response %s, want height %s and hash %s", bz, tt.height, hash)
+			}
+		})
+	}
+}
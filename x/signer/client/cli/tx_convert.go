@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+)
+
+const (
+	flagTo = "to"
+
+	txFormatAmino = "amino"
+	txFormatProto = "proto"
+)
+
+// GetConvertCommand returns the transaction convert command.
+func GetConvertCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "convert [file]",
+		Short: "Convert a transaction between legacy amino and protobuf JSON",
+		Long: `Convert a transaction between the legacy amino JSON StdTx encoding
+("cosmos-sdk/StdTx") and the protobuf JSON Tx encoding.
+
+The amino names of the messages are resolved using the legacy amino codecs
+registered by the plugins found in --plugins-dir.
+
+If --to is not set, the transaction is converted to the encoding it is not in.
+Signed amino transactions cannot be converted to protobuf, as the amino
+encoding does not carry the signers sequences.
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx := client.GetClientContextFromCmd(cmd)
+
+			pluginsDir, err := cmd.Flags().GetString(flagPluginsDir)
+			if err != nil {
+				return err
+			}
+			to, err := cmd.Flags().GetString(flagTo)
+			if err != nil {
+				return err
+			}
+
+			filename := args[0]
+			bz, err := os.ReadFile(filename)
+			if err != nil {
+				return err
+			}
+			if to == "" {
+				to = txFormatAmino
+				if isLegacyAminoTx(bz) {
+					to = txFormatProto
+				}
+			}
+
+			tx, err := decodeTxJSON(clientCtx, pluginsDir, bz)
+			if err != nil {
+				return fmt.Errorf("JSON decode %s: %v", filename, err)
+			}
+
+			var out []byte
+			switch to {
+			case txFormatAmino:
+				out, err = encodeLegacyAminoTx(clientCtx, tx)
+			case txFormatProto:
+				out, err = clientCtx.TxConfig.TxJSONEncoder()(tx)
+			default:
+				return fmt.Errorf("invalid --%s value %q, expected %s or %s", flagTo, to, txFormatAmino, txFormatProto)
+			}
+			if err != nil {
+				return err
+			}
+
+			return printOutput(cmd, out)
+		},
+	}
+
+	cmd.Flags().String(flagPluginsDir, "", "The directory to search for plugin files")
+	cmd.Flags().String(flagTo, "", fmt.Sprintf("The target encoding (%s|%s)", txFormatAmino, txFormatProto))
+	cmd.Flags().String(flags.FlagOutputDocument, "", "The document will be written to the given file instead of STDOUT")
+	_ = cmd.MarkFlagRequired(flagPluginsDir)
+
+	return cmd
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+)
+
+// GetDecodeCommand returns the transaction decode command.
+func GetDecodeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "decode [protobuf-byte-string]",
+		Short: "Decode a binary encoded transaction string to JSON",
+		Long: `Decode a base64 (or hex with --hex) protobuf encoded transaction and print
+its JSON encoding. The message types are registered from the plugins found
+in --plugins-dir.
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			clientCtx := client.GetClientContextFromCmd(cmd)
+
+			pluginsDir, err := cmd.Flags().GetString(flagPluginsDir)
+			if err != nil {
+				return err
+			}
+
+			var txBytes []byte
+			if useHex, _ := cmd.Flags().GetBool(flagHex); useHex {
+				txBytes, err = hex.DecodeString(args[0])
+			} else {
+				txBytes, err = base64.StdEncoding.DecodeString(args[0])
+			}
+			if err != nil {
+				return err
+			}
+
+			tx, err := decodeTxBytes(clientCtx, pluginsDir, txBytes)
+			if err != nil {
+				return err
+			}
+
+			json, err := clientCtx.TxConfig.TxJSONEncoder()(tx)
+			if err != nil {
+				return err
+			}
+
+			return printOutput(cmd, json)
+		},
+	}
+
+	cmd.Flags().String(flagPluginsDir, "", "The directory to search for plugin files")
+	cmd.Flags().BoolP(flagHex, "x", false, "Treat input as hexadecimal instead of base64")
+	cmd.Flags().String(flags.FlagOutputDocument, "", "The document will be written to the given file instead of STDOUT")
+	_ = cmd.MarkFlagRequired(flagPluginsDir)
+
+	return cmd
+}
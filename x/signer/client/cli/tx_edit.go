@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+)
+
+const flagAllowNoFee = "allow-no-fee"
+
+// validateEditedTx checks the messages of the edited transaction with their
+// ValidateBasic, and its fee.
+func validateEditedTx(tx sdk.Tx) error {
+	for i, msg := range tx.GetMsgs() {
+		if m, ok := msg.(sdk.HasValidateBasic); ok {
+			if err := m.ValidateBasic(); err != nil {
+				return fmt.Errorf("invalid message %d %s: %w", i, sdk.MsgTypeURL(msg), err)
+			}
+		}
+	}
+	feeTx, ok := tx.(sdk.FeeTx)
+	if !ok {
+		return fmt.Errorf("unexpected transaction type %T", tx)
+	}
+	if err := feeTx.GetFee().Validate(); err != nil {
+		return fmt.Errorf("invalid fee %s: %w", feeTx.GetFee(), err)
+	}
+	return nil
+}
+
+// editTx applies the changed flags of the edit command to the transaction,
+// and returns the description of the changes.
+func editTx(cmd *cobra.Command, txBuilder client.TxBuilder, protoTx *txtypes.Tx) ([]string, error) {
+	var (
+		f       = cmd.Flags()
+		changes []string
+		fee     = protoTx.AuthInfo.GetFee()
+	)
+	change := func(name string, from, to any) {
+		changes = append(changes, fmt.Sprintf("%s: %v -> %v", name, from, to))
+	}
+
+	if f.Changed(flagMemo) {
+		memo, err := f.GetString(flagMemo)
+		if err != nil {
+			return nil, err
+		}
+		txBuilder.SetMemo(memo)
+		change("memo", fmt.Sprintf("%q", protoTx.Body.Memo), fmt.Sprintf("%q", memo))
+	}
+	if f.Changed(flags.FlagFees) {
+		s, err := f.GetString(flags.FlagFees)
+		if err != nil {
+			return nil, err
+		}
+		fees, err := sdk.ParseCoinsNormalized(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s %q: %w", flags.FlagFees, s, err)
+		}
+		allowNoFee, err := f.GetBool(flagAllowNoFee)
+		if err != nil {
+			return nil, err
+		}
+		if fees.IsZero() && !allowNoFee {
+			return nil, fmt.Errorf("--%s %q removes the fee, set --%s to remove it", flags.FlagFees, s, flagAllowNoFee)
+		}
+		txBuilder.SetFeeAmount(fees)
+		change("fee", sdk.Coins(fee.GetAmount()), fees)
+	}
+	if f.Changed(flags.FlagGas) {
+		gas, err := f.GetUint64(flags.FlagGas)
+		if err != nil {
+			return nil, err
+		}
+		if gas == 0 || gas > txtypes.MaxGasWanted {
+			return nil, fmt.Errorf("invalid --%s %d, must be between 1 and %d", flags.FlagGas, gas, txtypes.MaxGasWanted)
+		}
+		txBuilder.SetGasLimit(gas)
+		change("gas", fee.GetGasLimit(), gas)
+	}
+	if f.Changed(flags.FlagTimeoutHeight) {
+		height, err := f.GetUint64(flags.FlagTimeoutHeight)
+		if err != nil {
+			return nil, err
+		}
+		txBuilder.SetTimeoutHeight(height)
+		change("timeout height", protoTx.Body.TimeoutHeight, height)
+	}
+	if f.Changed(flags.FlagFeeGranter) {
+		s, err := f.GetString(flags.FlagFeeGranter)
+		if err != nil {
+			return nil, err
+		}
+		var granter sdk.AccAddress
+		if s != "" {
+			if granter, err = sdk.AccAddressFromBech32(s); err != nil {
+				return nil, fmt.Errorf("invalid --%s %q: %w", flags.FlagFeeGranter, s, err)
+			}
+		}
+		txBuilder.SetFeeGranter(granter)
+		change("fee granter", fmt.Sprintf("%q", fee.GetGranter()), fmt.Sprintf("%q", s))
+	}
+
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("nothing to change, set at least one of --%s", strings.Join([]string{
+			flagMemo, flags.FlagFees, flags.FlagGas, flags.FlagTimeoutHeight, flags.FlagFeeGranter,
+		}, ", --"))
+	}
+	return changes, nil
+}
+
+// GetEditCommand returns the transaction edit command.
+func GetEditCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "edit [file]",
+		Short: "Change the memo, fee, gas, timeout height or fee granter of an unsigned transaction",
+		Long: `Change the memo, the fee, the gas limit, the timeout height or the fee
+granter of the unsigned transaction in [file], and print the changed
+transaction. Only the fields of the flags that are set are changed, an empty
+--fee-granter removes the fee granter, and an empty or zero --fees removes
+the fee only with --allow-no-fee.
+
+The changed transaction is validated: the ValidateBasic of its messages and
+its fee coins. The fee is checked against the chain profile and the timeout
+height against the timeout rule, as by 'tx sign'.
+
+The review of the changed transaction, with the list of the changes, is
+printed to STDERR.
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientTxContext(cmd)
+			if err != nil {
+				return err
+			}
+			pluginsDir, err := cmd.Flags().GetString(flagPluginsDir)
+			if err != nil {
+				return err
+			}
+
+			tx, err := readTxFile(clientCtx, pluginsDir, args[0])
+			if err != nil {
+				return err
+			}
+			if err := checkUnsigned(tx, args[0]); err != nil {
+				return err
+			}
+			protoTx, err := getProtoTx(tx)
+			if err != nil {
+				return err
+			}
+			txBuilder, err := copyTxBuilder(clientCtx, tx)
+			if err != nil {
+				return err
+			}
+			changes, err := editTx(cmd, txBuilder, protoTx)
+			if err != nil {
+				return err
+			}
+
+			tx = txBuilder.GetTx()
+			if err := validateEditedTx(tx); err != nil {
+				return err
+			}
+			snapshot, err := loadSnapshotFromFlags(clientCtx, cmd)
+			if err != nil {
+				return err
+			}
+			if err := checkFee(clientCtx, cmd, tx); err != nil {
+				return err
+			}
+			if err := checkTimeout(clientCtx, cmd, tx, snapshot); err != nil {
+				return err
+			}
+
+			book, err := LoadAddressBook(addressBookPath(clientCtx.HomeDir))
+			if err != nil {
+				return err
+			}
+			review, err := newTxReview(clientCtx, cmd, tx, book, snapshot)
+			if err != nil {
+				return err
+			}
+			review.Changes = changes
+			cmd.PrintErrf("%s\n", review)
+
+			txJSON, err := clientCtx.TxConfig.TxJSONEncoder()(tx)
+			if err != nil {
+				return err
+			}
+			return printOutput(cmd, txJSON)
+		},
+	}
+
+	cmd.Flags().String(flagMemo, "", "The new memo")
+	cmd.Flags().String(flags.FlagFees, "", "The new fee, e.g. 10uatom")
+	cmd.Flags().Uint64(flags.FlagGas, 0, "The new gas limit")
+	cmd.Flags().Uint64(flags.FlagTimeoutHeight, 0, "The new timeout height, 0 for none")
+	cmd.Flags().String(flags.FlagFeeGranter, "", "The new fee granter address, empty for none")
+	cmd.Flags().Bool(flagAllowHighFee, false, "Accept a fee exceeding the bounds of the chain profile, printing a warning")
+	cmd.Flags().Bool(flagAllowNoFee, false, "Accept an empty --fees, removing the fee")
+	cmd.Flags().String(flagPluginsDir, "", "The directory to search for plugin files")
+	cmd.Flags().String(flagPolicy, "", "The signing policy file, config/policy.json in the home directory by default")
+	cmd.Flags().String(flagAccountsSnapshot, "", "The accounts snapshot file, giving the reference height of the timeout height")
+	addSnapshotCheckFlags(cmd)
+	cmd.Flags().String(flags.FlagOutputDocument, "", "The document will be written to the given file instead of STDOUT")
+	_ = cmd.MarkFlagRequired(flagPluginsDir)
+
+	return cmd
+}
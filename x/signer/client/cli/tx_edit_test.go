@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+)
+
+// invalidTestMsg is a MsgSend failing its ValidateBasic.
+type invalidTestMsg struct {
+	*banktypes.MsgSend
+}
+
+func (invalidTestMsg) ValidateBasic() error { return errors.New("invalid test message") }
+
+// testMsgsTx is a transaction with other messages.
+type testMsgsTx struct {
+	sdk.FeeTx
+	msgs []sdk.Msg
+}
+
+func (tx testMsgsTx) GetMsgs() []sdk.Msg { return tx.msgs }
+
+func TestValidateEditedTx(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	bob := newTestKey(t, clientCtx, "bob")
+	send := newTestSend(alice, bob, 10)
+	feeTx := newTestTx(t, clientCtx, sdk.NewCoins(sdk.NewInt64Coin("uatom", 10)), 200000, send).(sdk.FeeTx)
+
+	tests := []struct {
+		name    string
+		tx      sdk.Tx
+		wantErr string
+	}{
+		{name: "valid", tx: feeTx},
+		{name: "no fee", tx: newTestTx(t, clientCtx, nil, 200000, send)},
+		{name: "invalid message", tx: testMsgsTx{FeeTx: feeTx, msgs: []sdk.Msg{send, invalidTestMsg{send}}}, wantErr: "invalid message 1"},
+		{
+			name:    "duplicate fee denom",
+			tx:      newTestTx(t, clientCtx, sdk.Coins{sdk.NewInt64Coin("uatom", 1), sdk.NewInt64Coin("uatom", 2)}, 200000, send),
+			wantErr: "invalid fee",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateEditedTx(tt.tx)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestEditCommand(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	bob := newTestKey(t, clientCtx, "bob")
+	fee := sdk.NewCoins(sdk.NewInt64Coin("uatom", 10))
+
+	tests := []struct {
+		name        string
+		fee         sdk.Coins
+		args        []string
+		wantFee     sdk.Coins
+		wantChanges []string
+		wantErr     string
+	}{
+		{
+			name:        "memo",
+			fee:         fee,
+			args:        []string{"--" + flagMemo, "rent"},
+			wantFee:     fee,
+			wantChanges: []string{`memo: "" -> "rent"`},
+		},
+		{
+			name:        "fee and gas",
+			fee:         fee,
+			args:        []string{"--" + flags.FlagFees, "20uatom", "--" + flags.FlagGas, "300000"},
+			wantFee:     sdk.NewCoins(sdk.NewInt64Coin("uatom", 20)),
+			wantChanges: []string{"fee: 10uatom -> 20uatom", "gas: 200000 -> 300000"},
+		},
+		{name: "fee removed", fee: fee, args: []string{"--" + flags.FlagFees, ""}, wantErr: "set --allow-no-fee"},
+		{name: "zero fee", fee: fee, args: []string{"--" + flags.FlagFees, "0uatom"}, wantErr: "set --allow-no-fee"},
+		{
+			name:        "allowed fee removal",
+			fee:         fee,
+			args:        []string{"--" + flags.FlagFees, "", "--" + flagAllowNoFee},
+			wantChanges: []string{"fee: 10uatom -> "},
+		},
+		{
+			name:    "invalid fee kept",
+			fee:     sdk.Coins{sdk.NewInt64Coin("uatom", 1), sdk.NewInt64Coin("uatom", 2)},
+			args:    []string{"--" + flagMemo, "rent"},
+			wantErr: "invalid fee",
+		},
+		{name: "nothing to change", fee: fee, wantErr: "nothing to change"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientCtx := clientCtx.WithHomeDir(t.TempDir())
+			txJSON, err := clientCtx.TxConfig.TxJSONEncoder()(newTestTx(t, clientCtx, tt.fee, 200000, newTestSend(alice, bob, 10)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			file := filepath.Join(t.TempDir(), "tx.json")
+			if err := os.WriteFile(file, txJSON, 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			pluginsDir := t.TempDir()
+			out := filepath.Join(t.TempDir(), "edited.json")
+			cmd := GetEditCommand()
+			cmd.SetContext(context.WithValue(context.Background(), client.ClientContextKey, &clientCtx))
+			cmd.SetArgs(append([]string{file, "--" + flagPluginsDir, pluginsDir, "--" + flags.FlagOutputDocument, out}, tt.args...))
+			stderr := &bytes.Buffer{}
+			cmd.SetErr(stderr)
+			cmd.SilenceUsage, cmd.SilenceErrors = true, true
+			err = cmd.Execute()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			case tt.wantErr != "":
+				if _, err := os.Stat(out); err == nil {
+					t.Fatalf("got output %s despite the error", out)
+				}
+				return
+			}
+
+			tx, err := readTxFile(clientCtx, pluginsDir, out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tx.(sdk.FeeTx).GetFee(); !got.Equal(tt.wantFee) {
+				t.Fatalf("got fee %s, want %s", got, tt.wantFee)
+			}
+
+			// the changes are listed in the review printed to STDERR
+			for _, change := range tt.wantChanges {
+				if !strings.Contains(stderr.String(), change) {
+					t.Fatalf("review %q does not list the change %q", stderr, change)
+				}
+			}
+		})
+	}
+}
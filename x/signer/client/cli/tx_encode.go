@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"encoding/base64"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+)
+
+// GetEncodeCommand returns the transaction encode command.
+func GetEncodeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "encode [file]",
+		Short: "Encode a transaction to base64 protobuf bytes",
+		Long: `Encode the JSON transaction in [file], either protobuf or legacy amino,
+to the protobuf TxRaw wire format, and print it as base64, ready to be
+broadcast.
+`,
+		Args:        cobra.ExactArgs(1),
+		Annotations: map[string]string{rawOutputAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx := client.GetClientContextFromCmd(cmd)
+
+			pluginsDir, err := cmd.Flags().GetString(flagPluginsDir)
+			if err != nil {
+				return err
+			}
+			tx, err := readTxFile(clientCtx, pluginsDir, args[0])
+			if err != nil {
+				return err
+			}
+
+			txBytes, err := clientCtx.TxConfig.TxEncoder()(tx)
+			if err != nil {
+				return err
+			}
+
+			return printOutput(cmd, []byte(base64.StdEncoding.EncodeToString(txBytes)))
+		},
+	}
+
+	cmd.Flags().String(flagPluginsDir, "", "The directory to search for plugin files")
+	cmd.Flags().String(flags.FlagOutputDocument, "", "The document will be written to the given file instead of STDOUT")
+	_ = cmd.MarkFlagRequired(flagPluginsDir)
+
+	return cmd
+}
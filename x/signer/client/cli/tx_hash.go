@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	cmttypes "github.com/cometbft/cometbft/types"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+)
+
+// GetHashCommand returns the transaction hash command.
+func GetHashCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hash [file]",
+		Short: "Compute the hash of a transaction",
+		Long: `Compute the CometBFT hash of the transaction in [file], which is the hash
+the transaction will be known by once broadcast.
+
+The file can contain either a JSON transaction, protobuf or legacy amino, or
+the base64 encoding of its protobuf bytes, as printed by the encode command.
+`,
+		Args:        cobra.ExactArgs(1),
+		Annotations: map[string]string{rawOutputAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx := client.GetClientContextFromCmd(cmd)
+
+			pluginsDir, err := cmd.Flags().GetString(flagPluginsDir)
+			if err != nil {
+				return err
+			}
+
+			bz, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			txBytes, err := encodeTxFileContent(clientCtx, pluginsDir, bz)
+			if err != nil {
+				return fmt.Errorf("decode %s: %v", args[0], err)
+			}
+
+			return printOutput(cmd, []byte(txHash(txBytes)))
+		},
+	}
+
+	cmd.Flags().String(flagPluginsDir, "", "The directory to search for plugin files")
+	cmd.Flags().String(flags.FlagOutputDocument, "", "The document will be written to the given file instead of STDOUT")
+	_ = cmd.MarkFlagRequired(flagPluginsDir)
+
+	return cmd
+}
+
+// encodeTxFileContent returns the protobuf bytes of a transaction file
+// content, either a JSON transaction or base64 encoded bytes.
+func encodeTxFileContent(clientCtx client.Context, pluginsDir string, bz []byte) ([]byte, error) {
+	bz = bytes.TrimSpace(bz)
+	if !bytes.HasPrefix(bz, []byte("{")) {
+		txBytes, err := base64.StdEncoding.DecodeString(string(bz))
+		if err != nil {
+			return nil, err
+		}
+		// make sure the bytes are actually a transaction
+		if _, err := decodeTxBytes(clientCtx, pluginsDir, txBytes); err != nil {
+			return nil, err
+		}
+		return txBytes, nil
+	}
+
+	tx, err := decodeTxJSON(clientCtx, pluginsDir, bz)
+	if err != nil {
+		return nil, err
+	}
+	return clientCtx.TxConfig.TxEncoder()(tx)
+}
+
+// txHash returns the CometBFT hash of the transaction bytes, as shown by
+// block explorers and used to query the transaction.
+func txHash(txBytes []byte) string {
+	return fmt.Sprintf("%X", cmttypes.Tx(txBytes).Hash())
+}
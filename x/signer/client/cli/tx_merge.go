@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const flagMemo = "memo"
+
+// GetMergeCommand returns the transaction merge command.
+func GetMergeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "merge [file] [file]...",
+		Short: "Merge several unsigned transactions of the same signers into one",
+		Long: `Merge the unsigned transactions in the [file]s into a single transaction, to
+sign them at once. The transactions must have the same signers, fee payer and
+fee granter. The messages are kept in the order of the files, the fees and the
+gas limits are added up, and the lowest timeout height is kept.
+
+The memos of the transactions must be the same, unless --memo is set.
+`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx := client.GetClientContextFromCmd(cmd)
+			f := cmd.Flags()
+			pluginsDir, err := f.GetString(flagPluginsDir)
+			if err != nil {
+				return err
+			}
+
+			var (
+				txBuilder client.TxBuilder
+				first     sdk.FeeTx
+				signers   []string
+				msgs      []sdk.Msg
+				fee       sdk.Coins
+				gas       uint64
+				timeout   uint64
+			)
+			for i, file := range args {
+				tx, err := readTxFile(clientCtx, pluginsDir, file)
+				if err != nil {
+					return err
+				}
+				if err := checkUnsigned(tx, file); err != nil {
+					return err
+				}
+				feeTx, ok := tx.(sdk.FeeTx)
+				if !ok {
+					return fmt.Errorf("unexpected transaction type %T", tx)
+				}
+				addrs, err := getTxSigners(clientCtx, tx)
+				if err != nil {
+					return err
+				}
+				timeoutTx, ok := tx.(sdk.TxWithTimeoutHeight)
+				if !ok {
+					return fmt.Errorf("unexpected transaction type %T", tx)
+				}
+
+				if i == 0 {
+					if txBuilder, err = copyTxBuilder(clientCtx, tx); err != nil {
+						return err
+					}
+					first, signers = feeTx, addrs
+				} else {
+					if !slices.Equal(addrs, signers) {
+						return fmt.Errorf("the signers of %s differ from the signers of %s", file, args[0])
+					}
+					if !bytes.Equal(feeTx.FeePayer(), first.FeePayer()) {
+						return fmt.Errorf("the fee payer of %s differs from the fee payer of %s", file, args[0])
+					}
+					if !bytes.Equal(feeTx.FeeGranter(), first.FeeGranter()) {
+						return fmt.Errorf("the fee granter of %s differs from the fee granter of %s", file, args[0])
+					}
+					if !f.Changed(flagMemo) && tx.(sdk.TxWithMemo).GetMemo() != first.(sdk.TxWithMemo).GetMemo() {
+						return fmt.Errorf("the memo of %s differs from the memo of %s, set --%s", file, args[0], flagMemo)
+					}
+				}
+
+				msgs = append(msgs, tx.GetMsgs()...)
+				fee = fee.Add(feeTx.GetFee()...)
+				if gas+feeTx.GetGas() < gas {
+					return fmt.Errorf("the gas limits of the transactions overflow")
+				}
+				gas += feeTx.GetGas()
+				if h := timeoutTx.GetTimeoutHeight(); h > 0 && (timeout == 0 || h < timeout) {
+					timeout = h
+				}
+			}
+
+			if err := txBuilder.SetMsgs(msgs...); err != nil {
+				return err
+			}
+			txBuilder.SetFeeAmount(fee)
+			txBuilder.SetGasLimit(gas)
+			txBuilder.SetTimeoutHeight(timeout)
+			if f.Changed(flagMemo) {
+				memo, err := f.GetString(flagMemo)
+				if err != nil {
+					return err
+				}
+				txBuilder.SetMemo(memo)
+			}
+
+			txJSON, err := clientCtx.TxConfig.TxJSONEncoder()(txBuilder.GetTx())
+			if err != nil {
+				return err
+			}
+			return printOutput(cmd, txJSON)
+		},
+	}
+
+	cmd.Flags().String(flagMemo, "", "The memo of the merged transaction, required if the memos differ")
+	cmd.Flags().String(flagPluginsDir, "", "The directory to search for plugin files")
+	cmd.Flags().String(flags.FlagOutputDocument, "", "The document will be written to the given file instead of STDOUT")
+	_ = cmd.MarkFlagRequired(flagPluginsDir)
+
+	return cmd
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	gogoproto "github.com/cosmos/gogoproto/proto"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	cdctypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+)
+
+// readTxFile reads the JSON transaction stored in filename, either protobuf or
+// legacy amino encoded, and decodes it after registering the required types
+// from the plugins found in pluginsDir.
+func readTxFile(clientCtx client.Context, pluginsDir, filename string) (sdk.Tx, error) {
+	bz, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := decodeTxJSON(clientCtx, pluginsDir, bz)
+	if err != nil {
+		return nil, fmt.Errorf("JSON decode %s: %v", filename, err)
+	}
+	return tx, nil
+}
+
+// decodeTxJSON decodes a JSON transaction, either protobuf or legacy amino
+// encoded, after registering the required types from the plugins found in
+// pluginsDir.
+func decodeTxJSON(clientCtx client.Context, pluginsDir string, bz []byte) (sdk.Tx, error) {
+	if isLegacyAminoTx(bz) {
+		return decodeLegacyAminoTx(clientCtx, pluginsDir, bz)
+	}
+
+	if err := registerTxTypes(clientCtx, pluginsDir, bz); err != nil {
+		return nil, err
+	}
+	return clientCtx.TxConfig.TxJSONDecoder()(bz)
+}
+
+// decodeTxBytes decodes a protobuf encoded transaction after registering the
+// types of its messages, and of the messages they wrap, from the plugins found
+// in pluginsDir.
+func decodeTxBytes(clientCtx client.Context, pluginsDir string, bz []byte) (sdk.Tx, error) {
+	var txRaw txtypes.TxRaw
+	if err := txRaw.Unmarshal(bz); err != nil {
+		return nil, err
+	}
+	// the messages are not unpacked when unmarshaling the body alone, so
+	// their type URLs can be read before the types are registered.
+	var body txtypes.TxBody
+	if err := body.Unmarshal(txRaw.BodyBytes); err != nil {
+		return nil, err
+	}
+
+	// the types of the messages wrapped in other messages, e.g. by authz
+	// MsgExec or gov proposals, are only known once the wrapping message is
+	// registered, so the types are registered until all of them are known.
+	attempted := make(map[string]struct{})
+	for {
+		collector := typeURLCollector{
+			registry:          clientCtx.Codec.InterfaceRegistry(),
+			unregisteredTypes: make(map[string]struct{}),
+		}
+		for _, msg := range body.Messages {
+			if err := collector.UnpackAny(msg, nil); err != nil {
+				return nil, err
+			}
+		}
+		for typeURL := range collector.unregisteredTypes {
+			if _, ok := attempted[typeURL]; ok {
+				// the plugins do not provide the type, the decoder reports it
+				delete(collector.unregisteredTypes, typeURL)
+				continue
+			}
+			attempted[typeURL] = struct{}{}
+		}
+		if len(collector.unregisteredTypes) == 0 {
+			break
+		}
+		if err := RegisterTypes(clientCtx, pluginsDir, collector.unregisteredTypes); err != nil {
+			return nil, err
+		}
+	}
+
+	return clientCtx.TxConfig.TxDecoder()(bz)
+}
+
+// typeURLCollector is an AnyUnpacker which collects the type URLs of the
+// nested Anys of a message that are not known to the interface registry.
+type typeURLCollector struct {
+	registry          cdctypes.InterfaceRegistry
+	unregisteredTypes map[string]struct{}
+}
+
+// UnpackAny records the type URL of msgAny if it is not registered, or else
+// walks the Anys of the message it wraps.
+func (c typeURLCollector) UnpackAny(msgAny *cdctypes.Any, _ interface{}) error {
+	if msgAny == nil {
+		return nil
+	}
+	msg, err := c.registry.Resolve(msgAny.TypeUrl)
+	if err != nil {
+		c.unregisteredTypes[msgAny.TypeUrl] = struct{}{}
+		return nil
+	}
+	if err := gogoproto.Unmarshal(msgAny.Value, msg); err != nil {
+		return err
+	}
+	return cdctypes.UnpackInterfaces(msg, c)
+}
+
+// registerTxTypes registers the types of the messages of the protobuf JSON
+// transaction bz that are not yet known to the interface registry.
+func registerTxTypes(clientCtx client.Context, pluginsDir string, bz []byte) error {
+	var rawTx struct {
+		Body struct {
+			Messages []map[string]any
+		}
+	}
+	if err := json.Unmarshal(bz, &rawTx); err != nil {
+		return err
+	}
+	unregisteredTypes, err := findUnregisteredTypes(clientCtx, rawTx.Body.Messages)
+	if err != nil {
+		return err
+	}
+
+	if len(unregisteredTypes) > 0 {
+		return RegisterTypes(clientCtx, pluginsDir, unregisteredTypes)
+	}
+	return nil
+}
+
+// getProtoTx returns the underlying protobuf transaction of tx.
+func getProtoTx(tx sdk.Tx) (*txtypes.Tx, error) {
+	protoTxProvider, ok := tx.(interface{ GetProtoTx() *txtypes.Tx })
+	if !ok {
+		return nil, fmt.Errorf("unexpected transaction type %T", tx)
+	}
+	return protoTxProvider.GetProtoTx(), nil
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	moduletestutil "github.com/cosmos/cosmos-sdk/types/module/testutil"
+	"github.com/cosmos/cosmos-sdk/x/authz"
+	authzmodule "github.com/cosmos/cosmos-sdk/x/authz/module"
+)
+
+func TestDecodeTxBytesNestedTypes(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	granter := newTestKey(t, clientCtx, "granter")
+	grantee := newTestKey(t, clientCtx, "grantee")
+	send := newTestSend(granter, grantee, 10)
+	exec := authz.NewMsgExec(grantee, []sdk.Msg{send})
+	nested := authz.NewMsgExec(grantee, []sdk.Msg{&exec})
+	revoke := authz.NewMsgRevoke(granter, grantee, sdk.MsgTypeURL(send))
+
+	// the application knows authz but not bank, which comes from a plugin
+	encCfg := moduletestutil.MakeTestEncodingConfig(authzmodule.AppModuleBasic{})
+	authzCtx := client.Context{}.
+		WithCodec(encCfg.Codec).
+		WithInterfaceRegistry(encCfg.InterfaceRegistry).
+		WithTxConfig(encCfg.TxConfig)
+
+	tests := []struct {
+		name string
+		msgs []sdk.Msg
+		want []string
+	}{
+		{name: "top-level message", msgs: []sdk.Msg{send}, want: []string{sdk.MsgTypeURL(send)}},
+		{name: "message wrapped by MsgExec", msgs: []sdk.Msg{&exec}, want: []string{sdk.MsgTypeURL(send)}},
+		{name: "nested MsgExec", msgs: []sdk.Msg{&nested}, want: []string{sdk.MsgTypeURL(send)}},
+		{name: "registered types", msgs: []sdk.Msg{&revoke}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bz, err := clientCtx.TxConfig.TxEncoder()(newTestTx(t, clientCtx, nil, 200000, tt.msgs...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := getProtoTx(mustDecode(t, clientCtx, bz))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			collector := typeURLCollector{
+				registry:          authzCtx.Codec.InterfaceRegistry(),
+				unregisteredTypes: make(map[string]struct{}),
+			}
+			for _, msg := range body.Body.Messages {
+				if err := collector.UnpackAny(msg, nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if len(collector.unregisteredTypes) != len(tt.want) {
+				t.Fatalf("got unregistered types %v, want %v", collector.unregisteredTypes, tt.want)
+			}
+			for _, typeURL := range tt.want {
+				if _, ok := collector.unregisteredTypes[typeURL]; !ok {
+					t.Fatalf("got unregistered types %v, want %v", collector.unregisteredTypes, tt.want)
+				}
+			}
+
+			// without plugins, the nested types are looked up and not found
+			_, err = decodeTxBytes(authzCtx, t.TempDir(), bz)
+			switch {
+			case len(tt.want) == 0 && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case len(tt.want) > 0 && (err == nil || !strings.Contains(err.Error(), "/cosmos.bank.v1beta1")):
+				t.Fatalf("expected a lookup error of the bank types, got %v", err)
+			}
+		})
+	}
+}
+
+func mustDecode(t *testing.T, clientCtx client.Context, bz []byte) sdk.Tx {
+	t.Helper()
+	tx, err := clientCtx.TxConfig.TxDecoder()(bz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
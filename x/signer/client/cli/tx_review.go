@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// txReview is the human readable description of a transaction, shown to
+// the operator before signing.
+type txReview struct {
+	ChainID       string
+	Memo          string
+	Fee           sdk.Coins
+	Gas           uint64
+	FeePayer      string
+	FeeGranter    string
+	TimeoutHeight uint64
+	// ReferenceHeight is the height of the accounts snapshot, if any.
+	ReferenceHeight int64
+	Signers         []string
+	Msgs            []msgSummary
+	Book            *AddressBook
+	// Changes are the fields changed by 'tx edit'.
+	Changes []string
+	// Notes are the findings of the checks made on the transaction.
+	Notes []string
+}
+
+// newTxReview returns the review of the transaction.
+func newTxReview(clientCtx client.Context, cmd *cobra.Command, tx sdk.Tx, book *AddressBook, snapshot *AccountsSnapshot) (*txReview, error) {
+	protoTx, err := getProtoTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := summarizeMsgs(clientCtx, tx)
+	if err != nil {
+		return nil, err
+	}
+	signers, err := getTxSigners(clientCtx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	review := &txReview{
+		ChainID:       clientCtx.ChainID,
+		Memo:          protoTx.Body.Memo,
+		TimeoutHeight: protoTx.Body.TimeoutHeight,
+		Signers:       signers,
+		Msgs:          msgs,
+		Book:          book,
+	}
+	if fee := protoTx.AuthInfo.Fee; fee != nil {
+		review.Fee = fee.Amount
+		review.Gas = fee.GasLimit
+		review.FeePayer = fee.Payer
+		review.FeeGranter = fee.Granter
+	}
+
+	check, err := getFeeCheck(clientCtx, tx)
+	if err != nil {
+		return nil, err
+	}
+	review.Notes = append(review.Notes, check.Errors...)
+	review.Notes = append(review.Notes, check.Warnings...)
+
+	if snapshot != nil {
+		review.ReferenceHeight = snapshot.Height
+	}
+	reasons, err := getTimeoutCheck(clientCtx, cmd, tx, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	review.Notes = append(review.Notes, reasons...)
+	return review, nil
+}
+
+func (r *txReview) annotate(addrs []string) string {
+	annotated := make([]string, len(addrs))
+	for i, addr := range addrs {
+		annotated[i] = r.Book.Annotate(addr)
+	}
+	return strings.Join(annotated, "\n                ")
+}
+
+// String renders the review.
+func (r *txReview) String() string {
+	var b strings.Builder
+	line := func(name string, value any) {
+		fmt.Fprintf(&b, "%-16s%v\n", name+":", value)
+	}
+
+	line("Chain ID", r.ChainID)
+	line("Signers", r.annotate(r.Signers))
+	line("Fee", fmt.Sprintf("%s (gas %d)", r.Fee, r.Gas))
+	if r.FeePayer != "" {
+		line("Fee payer", r.Book.Annotate(r.FeePayer))
+	}
+	if r.FeeGranter != "" {
+		line("Fee granter", r.Book.Annotate(r.FeeGranter))
+	}
+	line("Memo", fmt.Sprintf("%q", r.Memo))
+	if r.ReferenceHeight > 0 && r.TimeoutHeight > 0 {
+		line("Timeout height", fmt.Sprintf("%d (%d blocks after the reference height %d)",
+			r.TimeoutHeight, int64(r.TimeoutHeight)-r.ReferenceHeight, r.ReferenceHeight))
+	} else {
+		line("Timeout height", r.TimeoutHeight)
+	}
+
+	fmt.Fprintf(&b, "Messages:\n")
+	var writeMsgs func(msgs []msgSummary, indent string)
+	writeMsgs = func(msgs []msgSummary, indent string) {
+		for i, msg := range msgs {
+			fmt.Fprintf(&b, "%s#%d %s\n", indent, i, msg.TypeURL)
+			if len(msg.Senders) > 0 {
+				fmt.Fprintf(&b, "%s   from: %s\n", indent, r.annotate(msg.Senders))
+			}
+			for _, recipient := range msg.Recipients {
+				fmt.Fprintf(&b, "%s   to:   %s\n", indent, r.Book.Annotate(recipient))
+			}
+			if !msg.Amount.IsZero() {
+				fmt.Fprintf(&b, "%s   amount: %s\n", indent, msg.Amount)
+			}
+			writeMsgs(msg.Msgs, indent+"   ")
+		}
+	}
+	writeMsgs(r.Msgs, "  ")
+
+	if len(r.Changes) > 0 {
+		fmt.Fprintf(&b, "Changes:\n")
+		for _, change := range r.Changes {
+			fmt.Fprintf(&b, "  - %s\n", change)
+		}
+	}
+	if len(r.Notes) > 0 {
+		fmt.Fprintf(&b, "Notes:\n")
+		for _, note := range r.Notes {
+			fmt.Fprintf(&b, "  - %s\n", note)
+		}
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// GetReviewCommand returns the transaction review command.
+func GetReviewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "review [file]",
+		Short: "Print a human readable review of a transaction",
+		Long: `Print a human readable review of the transaction in [file]: the chain-id,
+the signers, the fee, the memo, the timeout height and for every message its
+senders, recipients and amount.
+
+The addresses are annotated with their label and trust level from the
+address book, see 'addressbook --help', and the fee is compared with the
+chain profile and the timeout height with the timeout rule, relative to the
+reference height of the --accounts-snapshot, see 'tx sign --help'.
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientTxContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			pluginsDir, err := cmd.Flags().GetString(flagPluginsDir)
+			if err != nil {
+				return err
+			}
+			tx, err := readTxFile(clientCtx, pluginsDir, args[0])
+			if err != nil {
+				return err
+			}
+			book, err := LoadAddressBook(addressBookPath(clientCtx.HomeDir))
+			if err != nil {
+				return err
+			}
+
+			snapshot, err := loadSnapshotFromFlags(clientCtx, cmd)
+			if err != nil {
+				return err
+			}
+
+			review, err := newTxReview(clientCtx, cmd, tx, book, snapshot)
+			if err != nil {
+				return err
+			}
+			return printOutput(cmd, []byte(review.String()))
+		},
+	}
+
+	cmd.Flags().String(flagPluginsDir, "", "The directory to search for plugin files")
+	cmd.Flags().String(flagPolicy, "", "The signing policy file, config/policy.json in the home directory by default")
+	cmd.Flags().String(flagAccountsSnapshot, "", "The accounts snapshot file, giving the reference height of the timeout height")
+	addSnapshotCheckFlags(cmd)
+	cmd.Flags().String(flags.FlagOutputDocument, "", "The document will be written to the given file instead of STDOUT")
+	_ = cmd.MarkFlagRequired(flagPluginsDir)
+
+	return cmd
+}
@@ -1,46 +1,184 @@
 package cli
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	errorsmod "cosmossdk.io/errors"
 
 	"github.com/cosmos/cosmos-sdk/client"
 	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/client/tx"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	kmultisig "github.com/cosmos/cosmos-sdk/crypto/keys/multisig"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
 	authcli "github.com/cosmos/cosmos-sdk/x/auth/client/cli"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
 )
 
 const (
 	flagPluginsDir = "plugins-dir"
+	flagMultisig   = "multisig"
+	flagSigOnly    = "signature-only"
 )
 
 // GetSignCommand returns the transaction sign command.
 func GetSignCommand() *cobra.Command {
 	cmd := authcli.GetSignCommand()
+	cmd.Long += `
+The --from flag can be repeated to sign with several local keys in one pass,
+in which case --account-number and --sequence must be repeated in the same
+order. The signatures are set in the order of the transaction signers, and
+every signer must be either one of the --from keys or have already signed.
+
+Signing is refused if a --from key (or the --multisig account) is not a
+required signer of the messages, unless --force is set.
+
+Before signing, the transaction is evaluated against the signing policy, see
+'policy test --help'.
+
+Keys protected by approval requirements only sign with enough approvals
+given with --approval, see 'approve --help'.
+
+The fee is compared with the gas prices and maximum fees of the chain profile,
+config/chains/<chain-id>.json in the home directory, a chain registry
+chain.json file with optional "max_fees" and per fee token "max_gas_price"
+(10 times "high_gas_price" by default) additions. Fees likely to be rejected
+are reported with a warning, and excessive fees are refused unless
+--allow-high-fee is set. Without a chain profile, or fee bounds in it, the
+fee is not checked, which is reported with a warning.
+
+The account numbers and sequences of the signers can be read from an accounts
+snapshot given with --accounts-snapshot instead of --account-number and
+--sequence, see 'accounts export --help'. Snapshots older than
+--snapshot-max-age, or older than the signatures of the sequence journal, are
+refused. Every account of the snapshot is verified with its ICS23 proof
+against --trusted-app-hash, the app hash of the block following the snapshot
+height, as confirmed by the operator from the header of the block at
+--trusted-height, which binds the snapshot height. Snapshots which cannot be
+verified are refused, and unverified snapshots are only used with
+--allow-unverified-snapshot. The snapshot time is not proven.
+
+A signing bundle produced by 'tx prepare' on the online machine can be given
+with --bundle instead of [file], --chain-id, --offline and the account
+numbers and sequences. Its checksum is verified, and without --from the keys
+of the keyring signing for the signers of the bundle are used. Only the
+assets of its chain profile are used, for the denoms the local chain profile
+has no metadata of: the fee bounds and the timeout rule are always the local
+ones, which a bundle cannot relax.
+
+With --envelope, the transaction or signing bundle is read from an envelope
+sealed to the envelope key of the home directory, see 'envelope --help'. Its
+signature is verified, its sender reported, or checked with --sender, and the
+output is sealed back to the envelope key of the sender, signed by the first
+--from key, which must be a local key. Envelopes of a sender that is neither
+checked with --sender nor known in the address book are refused, since the
+reply would be sealed to whoever sealed them.
+
+The timeout height can be required, and bounded to a maximum number of blocks
+after the reference height of the accounts snapshot given with
+--accounts-snapshot, with the "timeout" of the policy, overridden by the
+"timeout" of the chain profile:
+
+"timeout": {"required": true, "max_blocks": 1000}
+
+The hook executables configured in config/hooks.json in the home directory
+are run around signing:
+
+{
+  "pre_sign": [["/usr/local/bin/check-ticket", "--strict"]],
+  "post_sign": [["/usr/local/bin/notify"]]
+}
+
+Pre-sign hooks receive the transaction JSON on their standard input and veto
+the signature by exiting with a non-zero status. Post-sign hooks receive a
+JSON object with the chain_id, the signers, the tx_hash and the signed tx.
+The COSMOS_SIGNER_CHAIN_ID, COSMOS_SIGNER_SIGNERS and, after signing,
+COSMOS_SIGNER_TX_HASH environment variables are also set. Hooks running for
+longer than the "timeout" of config/hooks.json, "1m" by default, are killed.
+
+Recipients that are not known in the address book are reported with a
+warning, or refused with --require-known-recipients, see 'addressbook --help'.
+
+The sign bytes of every signature are recorded in the sequence journal, and
+signing a different transaction with an already used sequence is refused,
+see 'sequence next --help'. Every signature is recorded in the audit log, see
+'audit --help'.
+`
 	cmd.Flags().String(flagPluginsDir, "", "The directory to search for plugin files")
+	cmd.Flags().Bool(flagForce, false, "Sign even if a key is not a required signer of the transaction, printing a warning")
+	cmd.Flags().Bool(flagRequireKnownRecipients, false, "Refuse to sign if a recipient is not known in the address book")
+	cmd.Flags().StringArray(flagApproval, nil, "An approval file produced by the approve command, can be repeated")
+	cmd.Flags().Bool(flagAllowHighFee, false, "Sign even if the fee exceeds the bounds of the chain profile, printing a warning")
+	cmd.Flags().String(flagPolicy, "", "The signing policy file, config/policy.json in the home directory by default")
+	cmd.Flags().String(flagAccountsSnapshot, "", "The accounts snapshot file, giving the account numbers and sequences of the signers, and the reference height of the timeout height")
+	cmd.Flags().String(flagBundle, "", "The signing bundle file produced by tx prepare, instead of [file]")
+	cmd.Flags().String(flagEnvelope, "", "An envelope holding the transaction or signing bundle, instead of [file], the output is sealed back to its sender")
+	cmd.Flags().String(flagSender, "", "The address of the expected sender of the --envelope")
+	addSnapshotCheckFlags(cmd)
+
+	// the auth command only supports a single signer
+	signerFlags := pflag.NewFlagSet("signers", pflag.ContinueOnError)
+	signerFlags.StringArray(flags.FlagFrom, nil, "Name or address of private key with which to sign, can be repeated")
+	signerFlags.UintSliceP(flags.FlagAccountNumber, "a", nil, "The account number of each signing account (offline mode only)")
+	signerFlags.UintSliceP(flags.FlagSequence, "s", nil, "The sequence number of each signing account (offline mode only)")
+	replaceFlags(cmd, signerFlags)
+
+	// the transaction is read from the signing bundle with --bundle, or the
+	// envelope with --envelope
+	cmd.Args = cobra.RangeArgs(0, 1)
 
 	cmd.PreRun = preSignCmd
-	authMakeSignCmd := cmd.RunE
-	cmd.RunE = makeSignCmd(authMakeSignCmd)
+	cmd.RunE = makeSignCmd()
 
 	return cmd
 }
 
+// replaceFlags replaces the flags of cmd with the ones with the same name
+// in newFlags.
+func replaceFlags(cmd *cobra.Command, newFlags *pflag.FlagSet) {
+	oldFlags := cmd.Flags()
+	cmd.ResetFlags()
+	oldFlags.VisitAll(func(f *pflag.Flag) {
+		if newFlags.Lookup(f.Name) == nil {
+			cmd.Flags().AddFlag(f)
+		}
+	})
+	cmd.Flags().AddFlagSet(newFlags)
+}
+
 func preSignCmd(cmd *cobra.Command, _ []string) {
-	err := cmd.MarkFlagRequired(flags.FlagOffline)
-	if err != nil {
-		panic(err)
+	var err error
+	// a signing bundle holds everything else, and so may an envelope
+	if !cmd.Flags().Changed(flagBundle) && !cmd.Flags().Changed(flagEnvelope) {
+		err = cmd.MarkFlagRequired(flags.FlagOffline)
+		if err != nil {
+			panic(err)
+		}
+		err = cmd.MarkFlagRequired(flags.FlagFrom)
+		if err != nil {
+			panic(err)
+		}
 	}
 
-	err = cmd.MarkFlagRequired(flags.FlagAccountNumber)
-	if err != nil {
-		panic(err)
-	}
-	err = cmd.MarkFlagRequired(flags.FlagSequence)
-	if err != nil {
-		panic(err)
+	// the account numbers and sequences can be read from a snapshot
+	if !cmd.Flags().Changed(flagAccountsSnapshot) && !cmd.Flags().Changed(flagBundle) && !cmd.Flags().Changed(flagEnvelope) {
+		err = cmd.MarkFlagRequired(flags.FlagAccountNumber)
+		if err != nil {
+			panic(err)
+		}
+		err = cmd.MarkFlagRequired(flags.FlagSequence)
+		if err != nil {
+			panic(err)
+		}
 	}
 
 	err = cmd.MarkFlagRequired(flagPluginsDir)
@@ -49,44 +187,671 @@ func preSignCmd(cmd *cobra.Command, _ []string) {
 	}
 }
 
-func makeSignCmd(origMakeSignCmd func(cmd *cobra.Command, args []string) error) func(cmd *cobra.Command, args []string) error {
+func makeSignCmd() func(cmd *cobra.Command, args []string) error {
 	return func(cmd *cobra.Command, args []string) (err error) {
 		var clientCtx client.Context
 		clientCtx, err = client.GetClientTxContext(cmd)
 		if err != nil {
 			return err
 		}
-
-		filename := args[0]
-		f, err := os.Open(filename)
+		input, err := readSignInput(clientCtx, cmd, args)
 		if err != nil {
 			return err
 		}
-		defer f.Close()
-		var rawTx struct {
-			Body struct {
-				Messages []map[string]any
+		return input.sign(cmd)
+	}
+}
+
+// signInput is a transaction to sign, along with the context it is signed
+// in: the accounts snapshot of its signers and the envelope it was read
+// from, if any.
+type signInput struct {
+	ClientCtx client.Context
+	Tx        sdk.Tx
+	Snapshot  *AccountsSnapshot
+	Reply     *Envelope
+}
+
+// readSignInput reads the transaction to sign from [file], the signing
+// bundle set with --bundle or the envelope set with --envelope.
+func readSignInput(clientCtx client.Context, cmd *cobra.Command, args []string) (*signInput, error) {
+	pluginsDir, err := cmd.Flags().GetString(flagPluginsDir)
+	if err != nil {
+		return nil, err
+	}
+	bundleFile, err := cmd.Flags().GetString(flagBundle)
+	if err != nil {
+		return nil, err
+	}
+	envelopeFile, err := cmd.Flags().GetString(flagEnvelope)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		bz     []byte
+		source string
+		reply  *Envelope
+	)
+	switch {
+	case bundleFile != "" && envelopeFile != "":
+		return nil, fmt.Errorf("--%s cannot be used with --%s", flagBundle, flagEnvelope)
+	case (bundleFile != "" || envelopeFile != "") && len(args) > 0:
+		return nil, fmt.Errorf("[file] cannot be used with --%s or --%s", flagBundle, flagEnvelope)
+	case envelopeFile != "":
+		source = envelopeFile
+		if bz, reply, err = readEnvelope(clientCtx, cmd, envelopeFile); err != nil {
+			return nil, err
+		}
+		if err := checkReplyRecipient(clientCtx, cmd, reply); err != nil {
+			return nil, err
+		}
+		// the envelope holds the document the offline signer was given
+		clientCtx = clientCtx.WithOffline(true)
+	case bundleFile != "":
+		source = bundleFile
+		if bz, err = os.ReadFile(bundleFile); err != nil {
+			return nil, err
+		}
+	default:
+		if len(args) != 1 {
+			return nil, fmt.Errorf("accepts 1 arg(s), received %d", len(args))
+		}
+		source = args[0]
+		if bz, err = os.ReadFile(args[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	input := &signInput{Reply: reply}
+	if bundleFile != "" || (reply != nil && isSigningBundle(bz)) {
+		bundle, err := parseSigningBundle(bz, source)
+		if err != nil {
+			return nil, err
+		}
+		if clientCtx, err = readBundle(clientCtx, cmd, bundle, source); err != nil {
+			return nil, err
+		}
+		if input.Tx, err = decodeTxJSON(clientCtx, pluginsDir, bundle.Tx); err != nil {
+			return nil, fmt.Errorf("JSON decode %s: %v", source, err)
+		}
+		input.Snapshot = bundle.Accounts
+	} else {
+		if input.Tx, err = decodeTxJSON(clientCtx, pluginsDir, bz); err != nil {
+			return nil, fmt.Errorf("JSON decode %s: %v", source, err)
+		}
+		if input.Snapshot, err = loadSnapshotFromFlags(clientCtx, cmd); err != nil {
+			return nil, err
+		}
+	}
+	input.ClientCtx = clientCtx
+	return input, nil
+}
+
+// sign signs the transaction with the flags of cmd.
+func (in *signInput) sign(cmd *cobra.Command) error {
+	// the factory requires --account-number and --sequence in offline
+	// mode, they are read from the snapshot instead
+	factoryCtx := in.ClientCtx
+	if in.Snapshot != nil {
+		factoryCtx = factoryCtx.WithOffline(false)
+	}
+	txF, err := tx.NewFactoryCLI(factoryCtx, cmd.Flags())
+	if err != nil {
+		return err
+	}
+
+	return signTx(cmd, in.ClientCtx, txF, in.Tx, in.Snapshot, in.Reply)
+}
+
+// readBundle checks the signing bundle read from file and returns clientCtx
+// set for its chain, offline, and carrying the assets of its chain profile.
+// Without --from, the keys of the keyring signing for the signers of the
+// bundle are used.
+func readBundle(clientCtx client.Context, cmd *cobra.Command, bundle *SigningBundle, file string) (client.Context, error) {
+	if cmd.Flags().Changed(flags.FlagChainID) && clientCtx.ChainID != bundle.ChainID {
+		return clientCtx, fmt.Errorf("signing bundle %s is for chain %s, not %s", file, bundle.ChainID, clientCtx.ChainID)
+	}
+	clientCtx = clientCtx.WithChainID(bundle.ChainID).WithOffline(true)
+	if bundle.ChainProfile != nil {
+		clientCtx = withChainProfile(clientCtx, bundle.ChainProfile)
+	}
+	if err := checkSnapshot(clientCtx, cmd, bundle.Accounts, file); err != nil {
+		return clientCtx, err
+	}
+
+	if !cmd.Flags().Changed(flags.FlagFrom) {
+		names, err := bundleKeys(clientCtx, bundle)
+		if err != nil {
+			return clientCtx, err
+		}
+		for _, name := range names {
+			if err := cmd.Flags().Set(flags.FlagFrom, name); err != nil {
+				return clientCtx, err
 			}
 		}
-		if err := json.NewDecoder(f).Decode(&rawTx); err != nil {
-			return fmt.Errorf("JSON decode %s: %v", filename, err)
+	}
+	return clientCtx, nil
+}
+
+// localSigner is a key of the keyring signing the transaction.
+type localSigner struct {
+	Name          string
+	Address       sdk.AccAddress
+	PubKey        cryptotypes.PubKey
+	AccountNumber uint64
+	Sequence      uint64
+}
+
+// getLocalSigners returns the keys set with --from, along with their
+// account numbers and sequences, read from the accounts snapshot if they
+// are not set with --account-number and --sequence.
+func getLocalSigners(clientCtx client.Context, cmd *cobra.Command, snapshot *AccountsSnapshot) ([]localSigner, error) {
+	froms, err := cmd.Flags().GetStringArray(flags.FlagFrom)
+	if err != nil {
+		return nil, err
+	}
+	accountNumbers, err := cmd.Flags().GetUintSlice(flags.FlagAccountNumber)
+	if err != nil {
+		return nil, err
+	}
+	sequences, err := cmd.Flags().GetUintSlice(flags.FlagSequence)
+	if err != nil {
+		return nil, err
+	}
+	if len(froms) == 0 {
+		return nil, fmt.Errorf("required flag(s) \"%s\" not set", flags.FlagFrom)
+	}
+	fromSnapshot := snapshot != nil && len(accountNumbers) == 0 && len(sequences) == 0
+	if !fromSnapshot && (len(accountNumbers) != len(froms) || len(sequences) != len(froms)) {
+		return nil, fmt.Errorf("expected one --%s and one --%s for each of the %d --%s keys, got %d and %d",
+			flags.FlagAccountNumber, flags.FlagSequence, len(froms), flags.FlagFrom, len(accountNumbers), len(sequences))
+	}
+
+	signers := make([]localSigner, len(froms))
+	for i, from := range froms {
+		addr, name, _, err := client.GetFromFields(clientCtx, clientCtx.Keyring, from)
+		if err != nil {
+			return nil, fmt.Errorf("error getting account from keybase: %w", err)
+		}
+		record, err := clientCtx.Keyring.Key(name)
+		if err != nil {
+			return nil, fmt.Errorf("error getting account from keybase: %w", err)
 		}
-		unregisteredTypes, err := findUnregisteredTypes(clientCtx, rawTx.Body.Messages)
+		pubKey, err := record.GetPubKey()
 		if err != nil {
-			return err
+			return nil, err
+		}
+		signers[i] = localSigner{
+			Name:    name,
+			Address: addr,
+			PubKey:  pubKey,
+		}
+		if !fromSnapshot {
+			signers[i].AccountNumber = uint64(accountNumbers[i])
+			signers[i].Sequence = uint64(sequences[i])
+		}
+	}
+	if fromSnapshot {
+		if err := setSnapshotAccounts(clientCtx, cmd, snapshot, signers); err != nil {
+			return nil, err
 		}
+	}
+	return signers, nil
+}
 
-		if len(unregisteredTypes) > 0 {
-			pluginsDir, err := cmd.Flags().GetString(flagPluginsDir)
-			if err != nil {
-				return err
+// setSnapshotAccounts sets the account numbers and sequences of the signers
+// from the accounts snapshot. With --multisig, the account signed for is
+// the multisig account.
+func setSnapshotAccounts(clientCtx client.Context, cmd *cobra.Command, snapshot *AccountsSnapshot, signers []localSigner) error {
+	multisig, err := cmd.Flags().GetString(flagMultisig)
+	if err != nil {
+		return err
+	}
+	journal, err := openJournal(clientCtx.HomeDir)
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+
+	for i, signer := range signers {
+		addr, pubKey := signer.Address, signer.PubKey
+		if multisig != "" {
+			if addr, _, _, err = client.GetFromFields(clientCtx, clientCtx.Keyring, multisig); err != nil {
+				return fmt.Errorf("error getting account from keybase: %w", err)
 			}
-			err = RegisterTypes(clientCtx, pluginsDir, unregisteredTypes)
-			if err != nil {
-				return err
+			pubKey = nil
+		}
+		account, err := snapshotAccount(clientCtx, journal, snapshot, addr, pubKey)
+		if err != nil {
+			return err
+		}
+		signers[i].AccountNumber = account.AccountNumber
+		signers[i].Sequence = account.Sequence
+	}
+	return nil
+}
+
+// signTx signs the transaction. With reply, the output is sealed in an
+// envelope to the sender of the reply envelope.
+func signTx(cmd *cobra.Command, clientCtx client.Context, txF tx.Factory, newTx sdk.Tx, snapshot *AccountsSnapshot, reply *Envelope) error {
+	f := cmd.Flags()
+	txCfg := clientCtx.TxConfig
+	txBuilder, err := txCfg.WrapTxBuilder(newTx)
+	if err != nil {
+		return err
+	}
+
+	printSignatureOnly, err := f.GetBool(flagSigOnly)
+	if err != nil {
+		return err
+	}
+
+	multisig, err := f.GetString(flagMultisig)
+	if err != nil {
+		return err
+	}
+
+	overwrite, err := f.GetBool(flagOverwrite)
+	if err != nil {
+		return err
+	}
+
+	signers, err := getLocalSigners(clientCtx, cmd, snapshot)
+	if err != nil {
+		return err
+	}
+	if len(signers) > 1 && multisig != "" {
+		return fmt.Errorf("--%s cannot be used with several --%s keys", flagMultisig, flags.FlagFrom)
+	}
+	// the reply is sealed with the first key, checked before signing
+	if reply != nil {
+		if err := checkEnvelopeKey(clientCtx, signers[0].Name); err != nil {
+			return err
+		}
+	}
+
+	txSigners, err := getTxSigners(clientCtx, txBuilder.GetTx())
+	if err != nil {
+		return err
+	}
+	var keys []signingKey
+	if multisig != "" {
+		multisigAddr, multisigName, _, err := client.GetFromFields(clientCtx, txF.Keybase(), multisig)
+		if err != nil {
+			return fmt.Errorf("error getting account from keybase: %w", err)
+		}
+		keys = append(keys, signingKey{Name: multisigName, Address: multisigAddr})
+	} else {
+		for _, signer := range signers {
+			keys = append(keys, signingKey{Name: signer.Name, Address: signer.Address})
+		}
+	}
+	if err := checkRequiredSigners(cmd, txSigners, keys); err != nil {
+		return err
+	}
+	guard, err := newSignGuard(clientCtx, cmd, txBuilder.GetTx(), snapshot, signers, keys)
+	if err != nil {
+		return err
+	}
+	defer guard.Close()
+
+	// the signer infos are set with empty signatures first, so that the sign
+	// bytes are known, and checked, before any key is used.
+	switch {
+	case len(signers) > 1:
+		err = setSignersSignatures(clientCtx, txF, txBuilder, signers, txSigners)
+	case multisig != "":
+		err = setMultisigSignature(clientCtx, txF, txBuilder, signers[0], multisig)
+		printSignatureOnly = true
+	default:
+		err = setSignerSignature(clientCtx, txF, txBuilder, signers[0], overwrite)
+	}
+	if err != nil {
+		return err
+	}
+	sigs, err := getJournalSignatures(clientCtx.CmdContext, clientCtx, txBuilder.GetTx(), signers, keys)
+	if err != nil {
+		return err
+	}
+	if err := guard.checkSignatures(sigs); err != nil {
+		return err
+	}
+	if err := signPendingSignatures(txF, txBuilder, signers, sigs); err != nil {
+		return err
+	}
+	txHash, err := guard.record(txBuilder.GetTx(), sigs)
+	if err != nil {
+		return err
+	}
+
+	json, err := marshalSignatureJSON(txCfg, txBuilder, printSignatureOnly)
+	if err != nil {
+		return err
+	}
+	if reply != nil {
+		if json, err = sealReply(clientCtx, reply, signers[0].Name, json); err != nil {
+			return err
+		}
+	}
+	if err := printOutput(cmd, json); err != nil {
+		return err
+	}
+
+	signedTxJSON, err := txCfg.TxJSONEncoder()(txBuilder.GetTx())
+	if err != nil {
+		return err
+	}
+	return guard.postSign(signedTxJSON, txHash)
+}
+
+// signGuard carries the checks of a transaction made before it is signed,
+// with tx sign or pst sign, to the records made once it is.
+type signGuard struct {
+	clientCtx client.Context
+	cmd       *cobra.Command
+	signers   []localSigner
+	keys      []signingKey
+	ledger    *spendingLedger
+	decision  PolicyDecision
+	hooks     *HooksConfig
+}
+
+// newSignGuard checks the transaction against the address book, the chain
+// profile, the timeout rule, the signing policy and the pre-sign hooks,
+// before its sign bytes are computed. keys are the accounts the signers
+// sign for, and the guard must be closed.
+func newSignGuard(clientCtx client.Context, cmd *cobra.Command, tx sdk.Tx, snapshot *AccountsSnapshot, signers []localSigner, keys []signingKey) (*signGuard, error) {
+	if err := checkAddressBook(clientCtx, cmd, tx); err != nil {
+		return nil, err
+	}
+	if err := checkFee(clientCtx, cmd, tx); err != nil {
+		return nil, err
+	}
+	if err := checkTimeout(clientCtx, cmd, tx, snapshot); err != nil {
+		return nil, err
+	}
+
+	ledger, err := openLedger(clientCtx.HomeDir)
+	if err != nil {
+		return nil, err
+	}
+	g := &signGuard{clientCtx: clientCtx, cmd: cmd, signers: signers, keys: keys, ledger: ledger}
+	if err := g.check(tx); err != nil {
+		ledger.Close()
+		return nil, err
+	}
+	return g, nil
+}
+
+// check checks the transaction against the signing policy, auditing a
+// denial, and runs the pre-sign hooks.
+func (g *signGuard) check(tx sdk.Tx) error {
+	var err error
+	g.decision, err = checkPolicy(g.clientCtx, g.cmd, g.ledger, tx, g.signers, g.keys)
+	if denied := (PolicyDecision{}); errors.As(err, &denied) {
+		if err := auditDenial(g.clientCtx, g.cmd.CommandPath(), tx, g.signers, g.keys, denied); err != nil {
+			return err
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if g.hooks, err = loadHooks(g.clientCtx); err != nil {
+		return err
+	}
+	txJSON, err := g.clientCtx.TxConfig.TxJSONEncoder()(tx)
+	if err != nil {
+		return err
+	}
+	return runPreSignHooks(g.clientCtx, g.cmd, g.hooks, txJSON, g.keys)
+}
+
+// Close closes the spending ledger.
+func (g *signGuard) Close() error {
+	return g.ledger.Close()
+}
+
+// checkSignatures checks the approvals and the sequence journal of the
+// sign bytes, in the order of the signers, before any key is used.
+func (g *signGuard) checkSignatures(sigs []journalSignature) error {
+	if err := checkApprovals(g.clientCtx, g.cmd, sigs, g.signers, g.keys); err != nil {
+		return err
+	}
+	return checkSequenceJournal(g.clientCtx, g.clientCtx.ChainID, sigs)
+}
+
+// record records the signatures of the signed transaction in the audit log
+// and its outflows in the spending ledger, and returns its hash.
+func (g *signGuard) record(tx sdk.Tx, sigs []journalSignature) (string, error) {
+	records, err := newAuditRecords(g.clientCtx, auditOperationSign, g.cmd.CommandPath(), tx, sigs, &g.decision)
+	if err != nil {
+		return "", err
+	}
+	if err := appendAuditRecords(g.clientCtx.HomeDir, records); err != nil {
+		return "", err
+	}
+	if err := recordOutflows(g.clientCtx, g.ledger, tx, sigs); err != nil {
+		return "", err
+	}
+	return records[0].TxHash, nil
+}
+
+// postSign runs the post-sign hooks, once the output is written.
+func (g *signGuard) postSign(signedTxJSON []byte, txHash string) error {
+	return runPostSignHooks(g.clientCtx, g.cmd, g.hooks, signedTxJSON, txHash, g.keys)
+}
+
+// setMultisigSignature sets the empty signature of signer on behalf of the
+// multisig account.
+func setMultisigSignature(clientCtx client.Context, txF tx.Factory, txBuilder client.TxBuilder, signer localSigner, multisig string) error {
+	_, multisigName, _, err := client.GetFromFields(clientCtx, txF.Keybase(), multisig)
+	if err != nil {
+		return fmt.Errorf("error getting account from keybase: %w", err)
+	}
+	multisigkey, err := getMultisigRecord(clientCtx, multisigName)
+	if err != nil {
+		return err
+	}
+	multisigPubKey, err := multisigkey.GetPubKey()
+	if err != nil {
+		return err
+	}
+	multisigLegacyPub, ok := multisigPubKey.(*kmultisig.LegacyAminoPubKey)
+	if !ok {
+		return fmt.Errorf("%s is not a multisig key", multisig)
+	}
+
+	var found bool
+	for _, pubkey := range multisigLegacyPub.GetPubKeys() {
+		if pubkey.Equals(signer.PubKey) {
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("signing key is not a part of multisig key")
+	}
+
+	// Multisigs only support LEGACY_AMINO_JSON signing.
+	signMode := txF.SignMode()
+	if signMode == signing.SignMode_SIGN_MODE_UNSPECIFIED {
+		signMode = signing.SignMode_SIGN_MODE_LEGACY_AMINO_JSON
+	}
+	return txBuilder.SetSignatures(signing.SignatureV2{
+		PubKey:   signer.PubKey,
+		Data:     &signing.SingleSignatureData{SignMode: signMode},
+		Sequence: signer.Sequence,
+	})
+}
+
+// setSignerSignature sets the empty signature of a single signer, the signer
+// having already been checked against the transaction signers.
+func setSignerSignature(clientCtx client.Context, txF tx.Factory, txBuilder client.TxBuilder, signer localSigner, overwrite bool) error {
+	record, err := txF.Keybase().Key(signer.Name)
+	if err != nil {
+		return err
+	}
+	signMode := txF.SignMode()
+	switch {
+	case signMode != signing.SignMode_SIGN_MODE_UNSPECIFIED:
+	case record.GetType() == keyring.TypeLedger || record.GetType() == keyring.TypeMulti:
+		// Ledger and Multisigs only support LEGACY_AMINO_JSON signing.
+		signMode = signing.SignMode_SIGN_MODE_LEGACY_AMINO_JSON
+	default:
+		if signMode, err = authsigning.APISignModeToInternal(clientCtx.TxConfig.SignModeHandler().DefaultMode()); err != nil {
+			return err
+		}
+	}
+
+	var sigs []signing.SignatureV2
+	if !overwrite {
+		if sigs, err = txBuilder.GetTx().GetSignaturesV2(); err != nil {
+			return err
+		}
+	}
+	sigs = append(sigs, signing.SignatureV2{
+		PubKey:   signer.PubKey,
+		Data:     &signing.SingleSignatureData{SignMode: signMode},
+		Sequence: signer.Sequence,
+	})
+	// the sign bytes of SIGN_MODE_DIRECT cover the signer infos, which the
+	// signatures added later would change.
+	var direct int
+	for _, sig := range sigs {
+		if data, ok := sig.Data.(*signing.SingleSignatureData); ok && data.SignMode == signing.SignMode_SIGN_MODE_DIRECT {
+			direct++
+		}
+	}
+	if direct > 1 {
+		return fmt.Errorf("txs signed with CLI can have maximum 1 DIRECT signer, use --%s with all the signers to sign them in one pass", flags.FlagFrom)
+	}
+	return txBuilder.SetSignatures(sigs...)
+}
+
+// setSignersSignatures sets the empty signatures of all the signers, to
+// sign the transaction in one pass. The signer infos are set in the order of
+// the transaction signers, so that all the signatures commit to the same auth
+// info. Existing signatures of signers that are not local are kept, and local
+// signers that are not transaction signers, only allowed with --force, come
+// last.
+func setSignersSignatures(clientCtx client.Context, txF tx.Factory, txBuilder client.TxBuilder, signers []localSigner, txSigners []string) error {
+	signMode := txF.SignMode()
+	if signMode == signing.SignMode_SIGN_MODE_UNSPECIFIED {
+		var err error
+		signMode, err = authsigning.APISignModeToInternal(clientCtx.TxConfig.SignModeHandler().DefaultMode())
+		if err != nil {
+			return err
+		}
+	}
+
+	prevSigs, err := txBuilder.GetTx().GetSignaturesV2()
+	if err != nil {
+		return err
+	}
+
+	localSigners := make(map[string]localSigner, len(signers))
+	for _, signer := range signers {
+		localSigners[signer.Address.String()] = signer
+	}
+
+	var (
+		sigs    = make([]signing.SignatureV2, len(txSigners))
+		missing []string
+	)
+	for i, addr := range txSigners {
+		if signer, ok := localSigners[addr]; ok {
+			sigs[i] = signing.SignatureV2{
+				PubKey:   signer.PubKey,
+				Data:     &signing.SingleSignatureData{SignMode: signMode},
+				Sequence: signer.Sequence,
 			}
+			continue
+		}
+		if sig, ok := findSignature(prevSigs, addr); ok {
+			sigs[i] = sig
+			continue
+		}
+		missing = append(missing, addr)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("required signers %s are missing from the --%s keys", strings.Join(missing, ", "), flags.FlagFrom)
+	}
+	for _, signer := range signers {
+		if !slices.Contains(txSigners, signer.Address.String()) {
+			sigs = append(sigs, signing.SignatureV2{
+				PubKey:   signer.PubKey,
+				Data:     &signing.SingleSignatureData{SignMode: signMode},
+				Sequence: signer.Sequence,
+			})
 		}
+	}
+
+	return txBuilder.SetSignatures(sigs...)
+}
 
-		return origMakeSignCmd(cmd, args)
+// signPendingSignatures signs the sign bytes of the empty signatures of the
+// signers, sigs being their journal signatures in the same order.
+func signPendingSignatures(txF tx.Factory, txBuilder client.TxBuilder, signers []localSigner, sigs []journalSignature) error {
+	txSigs, err := txBuilder.GetTx().GetSignaturesV2()
+	if err != nil {
+		return err
 	}
+	for i, signer := range signers {
+		j, ok := findPendingSignature(txSigs, signer.Address.String())
+		if !ok {
+			return fmt.Errorf("no pending signature of %s in the transaction", signer.Address)
+		}
+		sigBytes, _, err := txF.Keybase().Sign(signer.Name, sigs[i].SignBytes, sigs[i].SignMode)
+		if err != nil {
+			return err
+		}
+		txSigs[j].Data = &signing.SingleSignatureData{SignMode: sigs[i].SignMode, Signature: sigBytes}
+	}
+	return txBuilder.SetSignatures(txSigs...)
+}
+
+// findPendingSignature returns the index of the empty signature of addr
+// among sigs.
+func findPendingSignature(sigs []signing.SignatureV2, addr string) (int, bool) {
+	for i := len(sigs) - 1; i >= 0; i-- {
+		data, ok := sigs[i].Data.(*signing.SingleSignatureData)
+		if ok && len(data.Signature) == 0 && sigs[i].PubKey != nil && sdk.AccAddress(sigs[i].PubKey.Address()).String() == addr {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// findSignature returns the signature of addr among sigs.
+func findSignature(sigs []signing.SignatureV2, addr string) (signing.SignatureV2, bool) {
+	for _, sig := range sigs {
+		if sig.PubKey != nil && sdk.AccAddress(sig.PubKey.Address()).String() == addr {
+			return sig, true
+		}
+	}
+	return signing.SignatureV2{}, false
+}
+
+func getMultisigRecord(clientCtx client.Context, name string) (*keyring.Record, error) {
+	kb := clientCtx.Keyring
+	multisigRecord, err := kb.Key(name)
+	if err != nil {
+		return nil, errorsmod.Wrap(err, "error getting keybase multisig account")
+	}
+
+	return multisigRecord, nil
+}
+
+func marshalSignatureJSON(txConfig client.TxConfig, txBldr client.TxBuilder, signatureOnly bool) ([]byte, error) {
+	parsedTx := txBldr.GetTx()
+	if signatureOnly {
+		sigs, err := parsedTx.GetSignaturesV2()
+		if err != nil {
+			return nil, err
+		}
+		return txConfig.MarshalSignatureJSON(sigs)
+	}
+
+	return txConfig.TxJSONEncoder()(parsedTx)
 }
@@ -0,0 +1,316 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+)
+
+const (
+	flagPubKey    = "pubkey"
+	flagOverwrite = "overwrite"
+)
+
+// signerInfo holds everything needed to compute the sign bytes of a signer.
+type signerInfo struct {
+	ChainID       string
+	AccountNumber uint64
+	Sequence      uint64
+	PubKey        cryptotypes.PubKey
+	SignMode      signing.SignMode
+}
+
+// signBytesOutput is the output of the sign-bytes command.
+type signBytesOutput struct {
+	Signer        string `json:"signer"`
+	ChainID       string `json:"chain_id"`
+	AccountNumber uint64 `json:"account_number,string"`
+	Sequence      uint64 `json:"sequence,string"`
+	SignMode      string `json:"sign_mode"`
+	Hex           string `json:"hex"`
+	Base64        string `json:"base64"`
+	SHA256        string `json:"sha256"`
+}
+
+// GetSignBytesCommand returns the transaction sign-bytes command.
+func GetSignBytesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sign-bytes [file]",
+		Short: "Print the bytes to sign for a transaction generated offline",
+		Long: `Print the exact bytes a signer has to sign for the transaction in [file],
+in hex and base64 encoding, along with their SHA-256 fingerprint.
+
+It is meant to sign transactions with devices the signer cannot talk to,
+and to add the resulting signature with the attach-signature command, which
+must be called with the same flags.
+
+The signer public key is read from the keyring with --from, or provided
+directly with --pubkey, e.g. '{"@type":"/cosmos.crypto.secp256k1.PubKey","key":"A..."}'.
+
+The sign bytes are only printed once the transaction passes the checks of
+tx sign: the address book, the fee bounds of the chain profile, the timeout
+rule, checked against the reference height of --accounts-snapshot, the
+signing policy, the pre-sign hooks and the approvals. The sign bytes are then
+recorded in the sequence journal, as a signature is to be made over them.
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx, err := client.GetClientTxContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			txBuilder, signer, err := readTxAndSigner(clientCtx, cmd, args[0])
+			if err != nil {
+				return err
+			}
+			overwrite, err := cmd.Flags().GetBool(flagOverwrite)
+			if err != nil {
+				return err
+			}
+			guard, err := newExternalSignGuard(clientCtx, cmd, txBuilder.GetTx(), signer)
+			if err != nil {
+				return err
+			}
+			defer guard.Close()
+
+			signBytes, err := getSignBytes(cmd.Context(), clientCtx, txBuilder, signer, overwrite)
+			if err != nil {
+				return err
+			}
+			if err := guard.checkSignatures(externalSignatures(signer, signBytes)); err != nil {
+				return err
+			}
+
+			fingerprint := sha256.Sum256(signBytes)
+			out, err := json.Marshal(signBytesOutput{
+				Signer:        sdk.AccAddress(signer.PubKey.Address()).String(),
+				ChainID:       signer.ChainID,
+				AccountNumber: signer.AccountNumber,
+				Sequence:      signer.Sequence,
+				SignMode:      signer.SignMode.String(),
+				Hex:           hex.EncodeToString(signBytes),
+				Base64:        base64.StdEncoding.EncodeToString(signBytes),
+				SHA256:        hex.EncodeToString(fingerprint[:]),
+			})
+			if err != nil {
+				return err
+			}
+
+			return printOutput(cmd, out)
+		},
+	}
+
+	addSignerFlags(cmd)
+	addSignGuardFlags(cmd)
+
+	return cmd
+}
+
+// addSignGuardFlags adds the flags of the checks of the signature of an
+// external signer.
+func addSignGuardFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool(flagRequireKnownRecipients, false, "Refuse to sign if a recipient is not known in the address book")
+	cmd.Flags().Bool(flagAllowHighFee, false, "Sign even if the fee exceeds the bounds of the chain profile, printing a warning")
+	cmd.Flags().String(flagPolicy, "", "The signing policy file, config/policy.json in the home directory by default")
+	cmd.Flags().StringArray(flagApproval, nil, "An approval file produced by the approve command, can be repeated")
+	cmd.Flags().String(flagAccountsSnapshot, "", "The accounts snapshot file, giving the reference height of the timeout height")
+	addSnapshotCheckFlags(cmd)
+}
+
+// addSignerFlags adds the flags needed to identify an external signer.
+func addSignerFlags(cmd *cobra.Command) {
+	cmd.Flags().String(flagPluginsDir, "", "The directory to search for plugin files")
+	cmd.Flags().String(flagPubKey, "", "The signer public key in JSON, if not using --from")
+	cmd.Flags().Bool(flagOverwrite, false, "Overwrite existing signatures with a new one. If disabled, new signature will be appended")
+	cmd.Flags().String(flags.FlagOutputDocument, "", "The document will be written to the given file instead of STDOUT")
+	flags.AddTxFlagsToCmd(cmd)
+
+	_ = cmd.MarkFlagRequired(flags.FlagAccountNumber)
+	_ = cmd.MarkFlagRequired(flags.FlagSequence)
+	_ = cmd.MarkFlagRequired(flagPluginsDir)
+}
+
+// readTxAndSigner reads the transaction in filename and the signer defined
+// by the command flags.
+func readTxAndSigner(clientCtx client.Context, cmd *cobra.Command, filename string) (client.TxBuilder, signerInfo, error) {
+	pluginsDir, err := cmd.Flags().GetString(flagPluginsDir)
+	if err != nil {
+		return nil, signerInfo{}, err
+	}
+	tx, err := readTxFile(clientCtx, pluginsDir, filename)
+	if err != nil {
+		return nil, signerInfo{}, err
+	}
+	txBuilder, err := clientCtx.TxConfig.WrapTxBuilder(tx)
+	if err != nil {
+		return nil, signerInfo{}, err
+	}
+
+	pubKey, err := getSignerPubKey(clientCtx, cmd)
+	if err != nil {
+		return nil, signerInfo{}, err
+	}
+	signMode, err := parseSignMode(clientCtx, clientCtx.SignModeStr)
+	if err != nil {
+		return nil, signerInfo{}, err
+	}
+	accountNumber, err := cmd.Flags().GetUint64(flags.FlagAccountNumber)
+	if err != nil {
+		return nil, signerInfo{}, err
+	}
+	sequence, err := cmd.Flags().GetUint64(flags.FlagSequence)
+	if err != nil {
+		return nil, signerInfo{}, err
+	}
+
+	return txBuilder, signerInfo{
+		ChainID:       clientCtx.ChainID,
+		AccountNumber: accountNumber,
+		Sequence:      sequence,
+		PubKey:        pubKey,
+		SignMode:      signMode,
+	}, nil
+}
+
+// newExternalSignGuard returns the guard of the signature of the external
+// signer, see newSignGuard.
+func newExternalSignGuard(clientCtx client.Context, cmd *cobra.Command, tx sdk.Tx, signer signerInfo) (*signGuard, error) {
+	snapshot, err := loadSnapshotFromFlags(clientCtx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	local := localSigner{
+		Name:          clientCtx.FromName,
+		Address:       sdk.AccAddress(signer.PubKey.Address()),
+		PubKey:        signer.PubKey,
+		AccountNumber: signer.AccountNumber,
+		Sequence:      signer.Sequence,
+	}
+	keys := []signingKey{{Name: local.Name, Address: local.Address}}
+	return newSignGuard(clientCtx, cmd, tx, snapshot, []localSigner{local}, keys)
+}
+
+// externalSignatures returns the signature of the external signer over
+// signBytes, for the sequence journal and the audit log.
+func externalSignatures(signer signerInfo, signBytes []byte) []journalSignature {
+	addr := sdk.AccAddress(signer.PubKey.Address()).String()
+	return []journalSignature{{
+		Signer:        addr,
+		Account:       addr,
+		AccountNumber: signer.AccountNumber,
+		Sequence:      signer.Sequence,
+		SignMode:      signer.SignMode,
+		SignBytes:     signBytes,
+	}}
+}
+
+// getSignerPubKey returns the public key set with --pubkey, or the one of
+// the --from key otherwise.
+func getSignerPubKey(clientCtx client.Context, cmd *cobra.Command) (cryptotypes.PubKey, error) {
+	pubKeyJSON, err := cmd.Flags().GetString(flagPubKey)
+	if err != nil {
+		return nil, err
+	}
+	if pubKeyJSON != "" {
+		var pubKey cryptotypes.PubKey
+		if err := clientCtx.Codec.UnmarshalInterfaceJSON([]byte(pubKeyJSON), &pubKey); err != nil {
+			return nil, fmt.Errorf("invalid --%s: %w", flagPubKey, err)
+		}
+		return pubKey, nil
+	}
+
+	if clientCtx.FromName == "" {
+		return nil, fmt.Errorf("either --%s or --%s must be set", flags.FlagFrom, flagPubKey)
+	}
+	record, err := clientCtx.Keyring.Key(clientCtx.FromName)
+	if err != nil {
+		return nil, fmt.Errorf("error getting account from keybase: %w", err)
+	}
+	return record.GetPubKey()
+}
+
+// parseSignMode parses a --sign-mode value. The sign mode handler default
+// is used if signModeStr is empty.
+func parseSignMode(clientCtx client.Context, signModeStr string) (signing.SignMode, error) {
+	switch signModeStr {
+	case flags.SignModeDirect:
+		return signing.SignMode_SIGN_MODE_DIRECT, nil
+	case flags.SignModeLegacyAminoJSON:
+		return signing.SignMode_SIGN_MODE_LEGACY_AMINO_JSON, nil
+	case flags.SignModeDirectAux:
+		return signing.SignMode_SIGN_MODE_DIRECT_AUX, nil
+	case flags.SignModeTextual:
+		return signing.SignMode_SIGN_MODE_TEXTUAL, nil
+	case flags.SignModeEIP191:
+		return signing.SignMode_SIGN_MODE_EIP_191, nil
+	case "":
+		return authsigning.APISignModeToInternal(clientCtx.TxConfig.SignModeHandler().DefaultMode())
+	default:
+		return signing.SignMode_SIGN_MODE_UNSPECIFIED, fmt.Errorf("invalid sign mode %q", signModeStr)
+	}
+}
+
+// getSignBytes returns the bytes the signer has to sign. As the SDK does
+// before signing, it sets an empty signature for the signer on txBuilder,
+// since for SIGN_MODE_DIRECT the signer infos are part of the sign bytes.
+// The empty signature can then be replaced with setSignature.
+func getSignBytes(ctx context.Context, clientCtx client.Context, txBuilder client.TxBuilder, signer signerInfo, overwrite bool) ([]byte, error) {
+	sig := signing.SignatureV2{
+		PubKey:   signer.PubKey,
+		Data:     &signing.SingleSignatureData{SignMode: signer.SignMode},
+		Sequence: signer.Sequence,
+	}
+
+	var sigs []signing.SignatureV2
+	if !overwrite {
+		prevSigs, err := txBuilder.GetTx().GetSignaturesV2()
+		if err != nil {
+			return nil, err
+		}
+		sigs = append(sigs, prevSigs...)
+	}
+	sigs = append(sigs, sig)
+	if err := txBuilder.SetSignatures(sigs...); err != nil {
+		return nil, err
+	}
+
+	signerData := authsigning.SignerData{
+		ChainID:       signer.ChainID,
+		AccountNumber: signer.AccountNumber,
+		Sequence:      signer.Sequence,
+		PubKey:        signer.PubKey,
+		Address:       sdk.AccAddress(signer.PubKey.Address()).String(),
+	}
+	return authsigning.GetSignBytesAdapter(ctx, clientCtx.TxConfig.SignModeHandler(), signer.SignMode, signerData, txBuilder.GetTx())
+}
+
+// setSignature replaces the empty signature set by getSignBytes with sigBytes.
+func setSignature(txBuilder client.TxBuilder, signer signerInfo, sigBytes []byte) error {
+	sigs, err := txBuilder.GetTx().GetSignaturesV2()
+	if err != nil {
+		return err
+	}
+	if len(sigs) == 0 || !sigs[len(sigs)-1].PubKey.Equals(signer.PubKey) {
+		return errors.New("no pending signature for the signer")
+	}
+	sigs[len(sigs)-1] = signing.SignatureV2{
+		PubKey:   signer.PubKey,
+		Data:     &signing.SingleSignatureData{SignMode: signer.SignMode, Signature: sigBytes},
+		Sequence: signer.Sequence,
+	}
+	return txBuilder.SetSignatures(sigs...)
+}
@@ -0,0 +1,197 @@
+package cli
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+)
+
+func TestSignBytesAttachSignature(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	file := writeTestTx(t, clientCtx, newTestTx(t, clientCtx, nil, 200000, newTestSend(alice, alice, 10)))
+	signerArgs := []string{file, "--" + flags.FlagFrom, "alice", "--" + flags.FlagAccountNumber, "3", "--" + flags.FlagSequence, "7"}
+
+	bz, err := runTestCommand(t, clientCtx, GetSignBytesCommand(), signerArgs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out signBytesOutput
+	if err := json.Unmarshal(bz, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Signer != alice.String() || out.AccountNumber != 3 || out.Sequence != 7 {
+		t.Fatalf("got signer %s, account number %d and sequence %d", out.Signer, out.AccountNumber, out.Sequence)
+	}
+	signBytes, err := base64.StdEncoding.DecodeString(out.Base64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		signed  []byte
+		wantErr string
+	}{
+		{name: "signature of the sign bytes", signed: signBytes},
+		{name: "signature of other bytes", signed: []byte("other bytes"), wantErr: "signature verification failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig, _, err := clientCtx.Keyring.Sign("alice", tt.signed, signing.SignMode_SIGN_MODE_DIRECT)
+			if err != nil {
+				t.Fatal(err)
+			}
+			args := append([]string{"--" + flagSignature, base64.StdEncoding.EncodeToString(sig)}, signerArgs...)
+			bz, err := runTestCommand(t, clientCtx, GetAttachSignatureCommand(), args...)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			case tt.wantErr != "":
+				return
+			}
+
+			tx, err := clientCtx.TxConfig.TxJSONDecoder()(bz)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sigs, err := tx.(authsigning.Tx).GetSignaturesV2()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sigs) != 1 || sigs[0].Sequence != 7 || !sigs[0].PubKey.VerifySignature(signBytes, sigs[0].Data.(*signing.SingleSignatureData).Signature) {
+				t.Fatalf("got signatures %v, want one valid signature at sequence 7", sigs)
+			}
+		})
+	}
+}
+
+func TestSignBytesGuards(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	bob := newTestKey(t, clientCtx, "bob")
+	file := writeTestTx(t, clientCtx, newTestTx(t, clientCtx, nil, 200000, newTestSend(alice, bob, 10)))
+	signerArgs := []string{file, "--" + flags.FlagFrom, "alice", "--" + flags.FlagAccountNumber, "3", "--" + flags.FlagSequence, "7"}
+
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T, clientCtx client.Context) []string
+		wantErr string
+	}{
+		{name: "no check failing", setup: func(*testing.T, client.Context) []string { return nil }},
+		{
+			name: "policy denial",
+			setup: func(t *testing.T, clientCtx client.Context) []string {
+				file := filepath.Join(t.TempDir(), "policy.json")
+				if err := os.WriteFile(file, []byte(`{"rules": [{"name": "small", "max_amounts": [{"denom": "uatom", "amount": "5"}]}]}`), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				return []string{"--" + flagPolicy, file}
+			},
+			wantErr: "total amount 10uatom exceeds the maximum 5uatom",
+		},
+		{
+			name: "pre-sign veto",
+			setup: func(t *testing.T, clientCtx client.Context) []string {
+				writeTestHooksConfig(t, clientCtx, HooksConfig{PreSign: [][]string{{writeTestHook(t, "exit 3")}}})
+				return nil
+			},
+			wantErr: "signature vetoed by pre-sign hook",
+		},
+		{
+			name: "sequence already used",
+			setup: func(t *testing.T, clientCtx client.Context) []string {
+				journal, err := openJournal(clientCtx.HomeDir)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer journal.Close()
+				if err := journal.Record(testChainID, []journalSignature{{Signer: alice.String(), Account: alice.String(), AccountNumber: 3, Sequence: 7, SignBytes: []byte("other")}}); err != nil {
+					t.Fatal(err)
+				}
+				return nil
+			},
+			wantErr: "sequence 7 of account " + alice.String() + " on chain test-chain was already used",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientCtx := clientCtx.WithHomeDir(t.TempDir())
+			args := append(tt.setup(t, clientCtx), signerArgs...)
+			bz, err := runTestCommand(t, clientCtx, GetSignBytesCommand(), args...)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			case tt.wantErr != "" && bz != nil:
+				t.Fatalf("got the sign bytes of a refused transaction: %s", bz)
+			}
+		})
+	}
+}
+
+func TestAttachSignatureRecords(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	bob := newTestKey(t, clientCtx, "bob")
+	file := writeTestTx(t, clientCtx, newTestTx(t, clientCtx, nil, 200000, newTestSend(alice, bob, 10)))
+	signerArgs := []string{file, "--" + flags.FlagFrom, "alice", "--" + flags.FlagAccountNumber, "3", "--" + flags.FlagSequence, "7"}
+	hookOutput := filepath.Join(t.TempDir(), "tx-hash")
+	writeTestHooksConfig(t, clientCtx, HooksConfig{PostSign: [][]string{{writeTestHook(t, `echo "$COSMOS_SIGNER_TX_HASH" > `+hookOutput)}}})
+
+	bz, err := runTestCommand(t, clientCtx, GetSignBytesCommand(), signerArgs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out signBytesOutput
+	if err := json.Unmarshal(bz, &out); err != nil {
+		t.Fatal(err)
+	}
+	signBytes, err := base64.StdEncoding.DecodeString(out.Base64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, _, err := clientCtx.Keyring.Sign("alice", signBytes, signing.SignMode_SIGN_MODE_DIRECT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := append([]string{"--" + flagSignature, base64.StdEncoding.EncodeToString(sig)}, signerArgs...)
+	if _, err := runTestCommand(t, clientCtx, GetAttachSignatureCommand(), args...); err != nil {
+		t.Fatal(err)
+	}
+
+	// the outflow is recorded in the spending ledger
+	ledger, err := openLedger(clientCtx.HomeDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ledger.Close()
+	spent, err := ledger.Spent(testChainID, alice.String(), "", time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := spent.AmountOf("uatom").Int64(); got != 10 {
+		t.Fatalf("got spent %d, want 10", got)
+	}
+	// and the post-sign hooks are run with the hash of the signed
+	// transaction
+	txHash, err := os.ReadFile(hookOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(strings.TrimSpace(string(txHash))) != 64 {
+		t.Fatalf("got transaction hash %q", txHash)
+	}
+}
@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/client/tx"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+)
+
+// verifyTestSignatures decodes the signed transaction bz and verifies its
+// signatures, the signature of the i-th signer with accountNumbers[i].
+func verifyTestSignatures(t *testing.T, clientCtx client.Context, bz []byte, accountNumbers ...uint64) []signing.SignatureV2 {
+	t.Helper()
+	tx, err := clientCtx.TxConfig.TxJSONDecoder()(bz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigTx := tx.(authsigning.Tx)
+	sigs, err := sigTx.GetSignaturesV2()
+	if err != nil {
+		t.Fatal(err)
+	}
+	txSigners, err := sigTx.GetSigners()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sigs) != len(accountNumbers) || len(sigs) != len(txSigners) {
+		t.Fatalf("got %d signatures for %d signers, want %d", len(sigs), len(txSigners), len(accountNumbers))
+	}
+	for i, sig := range sigs {
+		addr := sdk.AccAddress(sig.PubKey.Address())
+		if !addr.Equals(sdk.AccAddress(txSigners[i])) {
+			t.Fatalf("got signature %d of %s, want %s", i, addr, sdk.AccAddress(txSigners[i]))
+		}
+		data := sig.Data.(*signing.SingleSignatureData)
+		signerData := authsigning.SignerData{
+			ChainID:       testChainID,
+			AccountNumber: accountNumbers[i],
+			Sequence:      sig.Sequence,
+			PubKey:        sig.PubKey,
+			Address:       addr.String(),
+		}
+		signBytes, err := authsigning.GetSignBytesAdapter(context.Background(), clientCtx.TxConfig.SignModeHandler(), data.SignMode, signerData, tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !sig.PubKey.VerifySignature(signBytes, data.Signature) {
+			t.Fatalf("invalid signature %d of %s", i, addr)
+		}
+	}
+	return sigs
+}
+
+func TestSignMultipleKeys(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	bob := newTestKey(t, clientCtx, "bob")
+	carol := newTestKey(t, clientCtx, "carol")
+	twoSigners := newTestTx(t, clientCtx, nil, 200000, newTestSend(alice, bob, 10), newTestSend(bob, alice, 5))
+	threeSigners := newTestTx(t, clientCtx, nil, 200000, newTestSend(alice, bob, 10), newTestSend(bob, alice, 5), newTestSend(carol, alice, 1))
+
+	tests := []struct {
+		name          string
+		tx            sdk.Tx
+		args          []string
+		wantSequences []uint64
+		wantErr       string
+	}{
+		{
+			name:          "signer order",
+			tx:            twoSigners,
+			args:          []string{"--from", "alice", "--from", "bob", "-a", "1", "-a", "2", "-s", "5", "-s", "6"},
+			wantSequences: []uint64{5, 6},
+		},
+		{
+			name:          "reversed order",
+			tx:            twoSigners,
+			args:          []string{"--from", "bob", "--from", "alice", "-a", "2", "-a", "1", "-s", "6", "-s", "5"},
+			wantSequences: []uint64{5, 6},
+		},
+		{
+			name:    "missing signer",
+			tx:      threeSigners,
+			args:    []string{"--from", "alice", "--from", "bob", "-a", "1", "-a", "2", "-s", "5", "-s", "6"},
+			wantErr: "are missing from the --from keys",
+		},
+		{
+			name:    "missing sequence",
+			tx:      twoSigners,
+			args:    []string{"--from", "alice", "--from", "bob", "-a", "1", "-a", "2", "-s", "5"},
+			wantErr: "expected one --account-number and one --sequence",
+		},
+		{
+			name:    "multisig",
+			tx:      twoSigners,
+			args:    []string{"--from", "alice", "--from", "bob", "-a", "1", "-a", "2", "-s", "5", "-s", "6", "--" + flagMultisig, "carol"},
+			wantErr: "cannot be used with several --from keys",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientCtx := clientCtx.WithHomeDir(t.TempDir())
+			args := append([]string{writeTestTx(t, clientCtx, tt.tx), "--" + flags.FlagOffline}, tt.args...)
+			bz, err := runTestCommand(t, clientCtx, GetSignCommand(), args...)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			case tt.wantErr != "":
+				return
+			}
+
+			sigs := verifyTestSignatures(t, clientCtx, bz, 1, 2)
+			for i, sig := range sigs {
+				if sig.Sequence != tt.wantSequences[i] {
+					t.Fatalf("got sequence %d for signature %d, want %d", sig.Sequence, i, tt.wantSequences[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSignPendingSignatures(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	record, err := clientCtx.Keyring.Key("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubKey, err := record.GetPubKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := localSigner{Name: "alice", Address: alice, PubKey: pubKey, AccountNumber: 3, Sequence: 7}
+	keys := []signingKey{{Name: "alice", Address: alice}}
+
+	tests := []struct {
+		name     string
+		signMode signing.SignMode
+	}{
+		{name: "default sign mode", signMode: signing.SignMode_SIGN_MODE_UNSPECIFIED},
+		{name: "direct", signMode: signing.SignMode_SIGN_MODE_DIRECT},
+		{name: "amino-json", signMode: signing.SignMode_SIGN_MODE_LEGACY_AMINO_JSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txF := tx.Factory{}.
+				WithTxConfig(clientCtx.TxConfig).
+				WithKeybase(clientCtx.Keyring).
+				WithChainID(testChainID).
+				WithAccountNumber(signer.AccountNumber).
+				WithSequence(signer.Sequence).
+				WithSignMode(tt.signMode)
+			unsigned := newTestTx(t, clientCtx, nil, 200000, newTestSend(alice, alice, 10))
+
+			// the SDK signature is the reference, secp256k1 signatures being
+			// deterministic
+			want, err := clientCtx.TxConfig.WrapTxBuilder(unsigned)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tx.Sign(context.Background(), txF, "alice", want, true); err != nil {
+				t.Fatal(err)
+			}
+
+			txBuilder, err := clientCtx.TxConfig.WrapTxBuilder(newTestTx(t, clientCtx, nil, 200000, newTestSend(alice, alice, 10)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := setSignerSignature(clientCtx, txF, txBuilder, signer, true); err != nil {
+				t.Fatal(err)
+			}
+			sigs, err := getJournalSignatures(context.Background(), clientCtx, txBuilder.GetTx(), []localSigner{signer}, keys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// nothing is signed before the sign bytes are known
+			if _, ok := findPendingSignature(mustSignatures(t, txBuilder), alice.String()); !ok {
+				t.Fatal("expected a pending signature")
+			}
+			if err := signPendingSignatures(txF, txBuilder, []localSigner{signer}, sigs); err != nil {
+				t.Fatal(err)
+			}
+
+			got, wantSigs := mustSignatures(t, txBuilder), mustSignatures(t, want)
+			if len(got) != 1 || len(wantSigs) != 1 {
+				t.Fatalf("got %d signatures, want 1", len(got))
+			}
+			gotData := got[0].Data.(*signing.SingleSignatureData)
+			wantData := wantSigs[0].Data.(*signing.SingleSignatureData)
+			if gotData.SignMode != wantData.SignMode || !bytes.Equal(gotData.Signature, wantData.Signature) {
+				t.Fatalf("got signature %X (%s), want %X (%s)", gotData.Signature, gotData.SignMode, wantData.Signature, wantData.SignMode)
+			}
+			if !pubKey.VerifySignature(sigs[0].SignBytes, gotData.Signature) {
+				t.Fatal("signature does not verify over the journal sign bytes")
+			}
+		})
+	}
+}
+
+func mustSignatures(t *testing.T, txBuilder client.TxBuilder) []signing.SignatureV2 {
+	t.Helper()
+	sigs, err := txBuilder.GetTx().GetSignaturesV2()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sigs
+}
@@ -0,0 +1,363 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"cosmossdk.io/math"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+)
+
+const (
+	flagParts     = "parts"
+	flagMaxMsgs   = "max-msgs"
+	flagOutputDir = "output-dir"
+	flagGasLimits = "gas-limits"
+)
+
+// splitPart is a transaction written by the split command.
+type splitPart struct {
+	File     string    `json:"file"`
+	Msgs     int       `json:"msgs"`
+	Fee      sdk.Coins `json:"fee"`
+	Gas      uint64    `json:"gas,string"`
+	Sequence string    `json:"sequence,omitempty"`
+}
+
+// copyTxBuilder returns a builder of a copy of the transaction, through its
+// protobuf encoding.
+func copyTxBuilder(clientCtx client.Context, tx sdk.Tx) (client.TxBuilder, error) {
+	bz, err := clientCtx.TxConfig.TxEncoder()(tx)
+	if err != nil {
+		return nil, err
+	}
+	txCopy, err := clientCtx.TxConfig.TxDecoder()(bz)
+	if err != nil {
+		return nil, err
+	}
+	return clientCtx.TxConfig.WrapTxBuilder(txCopy)
+}
+
+// checkUnsigned refuses signed transactions, whose signatures would not be
+// valid once the transaction is changed.
+func checkUnsigned(tx sdk.Tx, file string) error {
+	sigTx, ok := tx.(authsigning.SigVerifiableTx)
+	if !ok {
+		return fmt.Errorf("unexpected transaction type %T", tx)
+	}
+	sigs, err := sigTx.GetSignaturesV2()
+	if err != nil {
+		return err
+	}
+	if len(sigs) > 0 {
+		return fmt.Errorf("the transaction in %s is signed, only unsigned transactions can be changed", file)
+	}
+	return nil
+}
+
+// splitSizes returns the number of messages of each of the parts, as even
+// as possible.
+func splitSizes(msgs, parts int) []int {
+	sizes := make([]int, parts)
+	for i := range sizes {
+		sizes[i] = msgs / parts
+		if i < msgs%parts {
+			sizes[i]++
+		}
+	}
+	return sizes
+}
+
+// splitAmount divides amount in proportion to the weights. The cumulative
+// shares are rounded down, so that the rounding remainders are spread over
+// the parts, every share is within 1 of its exact value, and the shares add
+// up to amount.
+func splitAmount(amount math.Int, weights []uint64) []math.Int {
+	total := math.ZeroInt()
+	for _, weight := range weights {
+		total = total.Add(math.NewIntFromUint64(weight))
+	}
+	var (
+		shares     = make([]math.Int, len(weights))
+		cumulative = math.ZeroInt()
+		previous   = math.ZeroInt()
+	)
+	for i, weight := range weights {
+		cumulative = cumulative.Add(math.NewIntFromUint64(weight))
+		next := amount.Mul(cumulative).Quo(total)
+		shares[i] = next.Sub(previous)
+		previous = next
+	}
+	return shares
+}
+
+// splitFee divides every coin of the fee in proportion to the weights.
+func splitFee(fee sdk.Coins, weights []uint64) []sdk.Coins {
+	fees := make([]sdk.Coins, len(weights))
+	for _, coin := range fee {
+		for i, amount := range splitAmount(coin.Amount, weights) {
+			fees[i] = fees[i].Add(sdk.NewCoin(coin.Denom, amount))
+		}
+	}
+	return fees
+}
+
+// splitGas divides the gas limit in proportion to the number of messages of
+// each part.
+func splitGas(gas uint64, sizes []int) []uint64 {
+	weights := make([]uint64, len(sizes))
+	for i, size := range sizes {
+		weights[i] = uint64(size)
+	}
+	gases := make([]uint64, len(sizes))
+	for i, amount := range splitAmount(math.NewIntFromUint64(gas), weights) {
+		gases[i] = amount.Uint64()
+	}
+	return gases
+}
+
+// checkSplitSequence refuses a first --sequence already signed for signer
+// according to the sequence journal, since the parts would reuse it.
+func checkSplitSequence(clientCtx client.Context, signer string, sequence uint64) error {
+	journal, err := openJournal(clientCtx.HomeDir)
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+	next, ok, err := journal.NextSequence(clientCtx.ChainID, signer)
+	if err != nil {
+		return err
+	}
+	if ok && sequence < next {
+		return fmt.Errorf("sequence %d of %s on chain %s was already signed, the next unused sequence is %d", sequence, signer, clientCtx.ChainID, next)
+	}
+	return nil
+}
+
+// writeNewFile writes bz to file, which must not exist.
+func writeNewFile(file string, bz []byte) error {
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(bz); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// GetSplitCommand returns the transaction split command.
+func GetSplitCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "split [file]",
+		Short: "Split an unsigned multi-message transaction into several transactions",
+		Long: `Split the unsigned transaction in [file] into --parts transactions, or into
+transactions of at most --max-msgs messages, e.g. so that they fit in the
+block gas limit. The messages are kept in order and divided as evenly as
+possible, and the memo, the timeout height, the fee payer and the fee granter
+are kept.
+
+The gas limit is divided in proportion to the number of messages of each
+part, which only approximates their gas use since messages use different
+amounts of gas. --gas-limits sets the gas limit of every part instead, e.g.
+from simulating them, and the fee is then divided in proportion to the gas
+limits, so that the parts keep the gas price of the transaction; otherwise it
+is divided in proportion to the number of messages. The rounding remainders
+are spread over the parts, which add up to the fee of the transaction. A part
+left without any fee is refused, and the fee of every part is checked
+against the chain profile of --chain-id, see 'tx sign --help'.
+
+The transactions are written to --output-dir, the directory of [file] by
+default, as <name>.part<i>.json, and the list of parts is printed. Existing
+files are never overwritten.
+
+With --sequence, the sequence of the signer of the first part, the parts are
+listed with consecutive sequences, to sign them in order with 'tx sign
+--sequence'. The sequences are only in the list, not in the unsigned
+transactions, and the first one is refused if the sequence journal shows it
+was already signed on --chain-id, which is then required, see 'sequence next
+--help'.
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientCtx := client.GetClientContextFromCmd(cmd)
+			f := cmd.Flags()
+			pluginsDir, err := f.GetString(flagPluginsDir)
+			if err != nil {
+				return err
+			}
+			parts, err := f.GetInt(flagParts)
+			if err != nil {
+				return err
+			}
+			maxMsgs, err := f.GetInt(flagMaxMsgs)
+			if err != nil {
+				return err
+			}
+			outputDir, err := f.GetString(flagOutputDir)
+			if err != nil {
+				return err
+			}
+			if outputDir == "" {
+				outputDir = filepath.Dir(args[0])
+			}
+			gasLimits, err := f.GetUintSlice(flagGasLimits)
+			if err != nil {
+				return err
+			}
+			if f.Changed(flags.FlagChainID) {
+				chainID, err := f.GetString(flags.FlagChainID)
+				if err != nil {
+					return err
+				}
+				clientCtx = clientCtx.WithChainID(chainID)
+			}
+
+			tx, err := readTxFile(clientCtx, pluginsDir, args[0])
+			if err != nil {
+				return err
+			}
+			if err := checkUnsigned(tx, args[0]); err != nil {
+				return err
+			}
+			msgs := tx.GetMsgs()
+			switch {
+			case (parts > 0) == (maxMsgs > 0):
+				return fmt.Errorf("either --%s or --%s must be set", flagParts, flagMaxMsgs)
+			case maxMsgs > 0:
+				parts = (len(msgs) + maxMsgs - 1) / maxMsgs
+			}
+			if parts < 2 || parts > len(msgs) {
+				return fmt.Errorf("cannot split the %d messages of %s into %d transactions", len(msgs), args[0], parts)
+			}
+
+			var firstSequence uint64
+			if f.Changed(flags.FlagSequence) {
+				if firstSequence, err = f.GetUint64(flags.FlagSequence); err != nil {
+					return err
+				}
+				signers, err := getTxSigners(clientCtx, tx)
+				if err != nil {
+					return err
+				}
+				if len(signers) != 1 {
+					return fmt.Errorf("--%s requires a transaction with a single signer, %s has %d", flags.FlagSequence, args[0], len(signers))
+				}
+				if clientCtx.ChainID == "" {
+					return fmt.Errorf("--%s requires --%s, to check the sequence journal", flags.FlagSequence, flags.FlagChainID)
+				}
+				if err := checkSplitSequence(clientCtx, signers[0], firstSequence); err != nil {
+					return err
+				}
+			}
+
+			feeTx, ok := tx.(sdk.FeeTx)
+			if !ok {
+				return fmt.Errorf("unexpected transaction type %T", tx)
+			}
+			sizes := splitSizes(len(msgs), parts)
+			var (
+				gases   []uint64
+				weights = make([]uint64, len(sizes))
+			)
+			switch {
+			case len(gasLimits) == 0:
+				gases = splitGas(feeTx.GetGas(), sizes)
+				for i, size := range sizes {
+					weights[i] = uint64(size)
+				}
+			case len(gasLimits) != len(sizes):
+				return fmt.Errorf("expected one --%s for each of the %d parts, got %d", flagGasLimits, len(sizes), len(gasLimits))
+			default:
+				gases = make([]uint64, len(gasLimits))
+				for i, gas := range gasLimits {
+					if gas == 0 {
+						return fmt.Errorf("invalid --%s: the gas limit of part %d is 0", flagGasLimits, i+1)
+					}
+					gases[i], weights[i] = uint64(gas), uint64(gas)
+				}
+			}
+			fees := splitFee(feeTx.GetFee(), weights)
+
+			// every part is built and checked before any file is written
+			var (
+				list    []splitPart
+				txJSONs [][]byte
+				start   int
+				base    = strings.TrimSuffix(filepath.Base(args[0]), filepath.Ext(args[0]))
+			)
+			for i, size := range sizes {
+				if fees[i].IsZero() && !feeTx.GetFee().IsZero() {
+					return fmt.Errorf("part %d would have no fee: the fee %s of %s cannot be split into %d parts", i+1, feeTx.GetFee(), args[0], len(sizes))
+				}
+				txBuilder, err := copyTxBuilder(clientCtx, tx)
+				if err != nil {
+					return err
+				}
+				if err := txBuilder.SetMsgs(msgs[start : start+size]...); err != nil {
+					return err
+				}
+				txBuilder.SetFeeAmount(fees[i])
+				txBuilder.SetGasLimit(gases[i])
+				start += size
+				if err := checkFee(clientCtx, cmd, txBuilder.GetTx()); err != nil {
+					return fmt.Errorf("part %d: %w", i+1, err)
+				}
+
+				txJSON, err := clientCtx.TxConfig.TxJSONEncoder()(txBuilder.GetTx())
+				if err != nil {
+					return err
+				}
+				if txJSON, err = filterNullJSONKeys(txJSON); err != nil {
+					return err
+				}
+				file := filepath.Join(outputDir, fmt.Sprintf("%s.part%d.json", base, i+1))
+				if _, err := os.Stat(file); err == nil {
+					return fmt.Errorf("%s already exists", file)
+				}
+				txJSONs = append(txJSONs, txJSON)
+
+				part := splitPart{File: file, Msgs: size, Fee: fees[i], Gas: gases[i]}
+				if f.Changed(flags.FlagSequence) {
+					part.Sequence = strconv.FormatUint(firstSequence+uint64(i), 10)
+				}
+				list = append(list, part)
+			}
+			for i, part := range list {
+				if err := writeNewFile(part.File, txJSONs[i]); err != nil {
+					return err
+				}
+			}
+
+			bz, err := json.MarshalIndent(list, "", "  ")
+			if err != nil {
+				return err
+			}
+			return printOutput(cmd, bz)
+		},
+	}
+
+	cmd.Flags().Int(flagParts, 0, "The number of transactions to split the transaction into")
+	cmd.Flags().Int(flagMaxMsgs, 0, "The maximum number of messages of each transaction, instead of --parts")
+	cmd.Flags().String(flagOutputDir, "", "The directory the transactions are written to, the directory of [file] by default")
+	cmd.Flags().UintSlice(flagGasLimits, nil, "The gas limit of each transaction, instead of dividing the gas limit by their number of messages")
+	cmd.Flags().Uint64(flags.FlagSequence, 0, "The sequence of the signer of the first transaction, checked against the sequence journal")
+	cmd.Flags().String(flags.FlagChainID, "", "The network chain ID, for the chain profile and the sequence journal")
+	cmd.Flags().Bool(flagAllowHighFee, false, "Split even if the fee of a transaction exceeds the bounds of the chain profile, printing a warning")
+	cmd.Flags().String(flagPluginsDir, "", "The directory to search for plugin files")
+	cmd.Flags().String(flags.FlagOutputDocument, "", "The document will be written to the given file instead of STDOUT")
+	_ = cmd.MarkFlagRequired(flagPluginsDir)
+
+	return cmd
+}
@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"cosmossdk.io/math"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestSplitAmount(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  int64
+		weights []uint64
+		want    []int64
+	}{
+		{name: "exact", amount: 9, weights: []uint64{1, 1, 1}, want: []int64{3, 3, 3}},
+		{name: "spread remainders", amount: 7, weights: []uint64{1, 1, 1, 1}, want: []int64{1, 2, 2, 2}},
+		{name: "weighted", amount: 10, weights: []uint64{1, 3}, want: []int64{2, 8}},
+		{name: "too small", amount: 1, weights: []uint64{1, 1, 1}, want: []int64{0, 0, 1}},
+		{name: "zero", amount: 0, weights: []uint64{2, 1}, want: []int64{0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares := splitAmount(math.NewInt(tt.amount), tt.weights)
+			got := make([]int64, len(shares))
+			for i, share := range shares {
+				got[i] = share.Int64()
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("got shares %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	bob := newTestKey(t, clientCtx, "bob")
+	msgs := []sdk.Msg{newTestSend(alice, bob, 1), newTestSend(alice, bob, 2), newTestSend(alice, bob, 3)}
+
+	usedSequence := func(t *testing.T, clientCtx client.Context, _ string) {
+		journal, err := openJournal(clientCtx.HomeDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer journal.Close()
+		if err := journal.Record(testChainID, []journalSignature{{Signer: alice.String(), Account: alice.String(), Sequence: 4, SignBytes: []byte("other")}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		fee       int64
+		setup     func(t *testing.T, clientCtx client.Context, dir string)
+		args      []string
+		wantParts []splitPart
+		wantErr   string
+	}{
+		{
+			name:      "parts",
+			fee:       10,
+			args:      []string{"--" + flagParts, "3"},
+			wantParts: []splitPart{{Msgs: 1, Fee: sdk.NewCoins(sdk.NewInt64Coin("uatom", 3)), Gas: 100000}, {Msgs: 1, Fee: sdk.NewCoins(sdk.NewInt64Coin("uatom", 3)), Gas: 100000}, {Msgs: 1, Fee: sdk.NewCoins(sdk.NewInt64Coin("uatom", 4)), Gas: 100000}},
+		},
+		{
+			name:      "max msgs",
+			fee:       10,
+			args:      []string{"--" + flagMaxMsgs, "2"},
+			wantParts: []splitPart{{Msgs: 2, Fee: sdk.NewCoins(sdk.NewInt64Coin("uatom", 6)), Gas: 200000}, {Msgs: 1, Fee: sdk.NewCoins(sdk.NewInt64Coin("uatom", 4)), Gas: 100000}},
+		},
+		{
+			name:      "gas limits",
+			fee:       10,
+			args:      []string{"--" + flagParts, "2", "--" + flagGasLimits, "50000,200000"},
+			wantParts: []splitPart{{Msgs: 2, Fee: sdk.NewCoins(sdk.NewInt64Coin("uatom", 2)), Gas: 50000}, {Msgs: 1, Fee: sdk.NewCoins(sdk.NewInt64Coin("uatom", 8)), Gas: 200000}},
+		},
+		{
+			name:      "sequences",
+			fee:       10,
+			setup:     usedSequence,
+			args:      []string{"--" + flagParts, "2", "--" + flags.FlagSequence, "5", "--" + flags.FlagChainID, testChainID},
+			wantParts: []splitPart{{Msgs: 2, Fee: sdk.NewCoins(sdk.NewInt64Coin("uatom", 6)), Gas: 200000, Sequence: "5"}, {Msgs: 1, Fee: sdk.NewCoins(sdk.NewInt64Coin("uatom", 4)), Gas: 100000, Sequence: "6"}},
+		},
+		{name: "gas limit of every part", fee: 10, args: []string{"--" + flagParts, "3", "--" + flagGasLimits, "50000,200000"}, wantErr: "expected one --gas-limits for each of the 3 parts"},
+		{name: "no fee left", fee: 2, args: []string{"--" + flagParts, "3"}, wantErr: "part 1 would have no fee"},
+		{
+			name: "excessive fee",
+			fee:  10,
+			setup: func(t *testing.T, clientCtx client.Context, _ string) {
+				writeTestChainProfile(t, clientCtx, `{"chain_id": "test-chain", "max_fees": [{"denom": "uatom", "amount": "4"}]}`)
+			},
+			args:    []string{"--" + flagParts, "2", "--" + flags.FlagChainID, testChainID},
+			wantErr: "part 1: excessive fee",
+		},
+		{
+			name: "existing file",
+			fee:  10,
+			setup: func(t *testing.T, _ client.Context, dir string) {
+				if err := os.WriteFile(filepath.Join(dir, "tx.part2.json"), []byte("{}"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			args:    []string{"--" + flagParts, "2"},
+			wantErr: "tx.part2.json already exists",
+		},
+		{
+			name:    "used sequence",
+			fee:     10,
+			setup:   usedSequence,
+			args:    []string{"--" + flagParts, "2", "--" + flags.FlagSequence, "4", "--" + flags.FlagChainID, testChainID},
+			wantErr: "the next unused sequence is 5",
+		},
+		{name: "sequence without chain", fee: 10, args: []string{"--" + flagParts, "2", "--" + flags.FlagSequence, "4"}, wantErr: "requires --chain-id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the chain is only set with --chain-id
+			clientCtx := clientCtx.WithHomeDir(t.TempDir()).WithChainID("")
+			dir := t.TempDir()
+			if tt.setup != nil {
+				tt.setup(t, clientCtx, dir)
+			}
+			txJSON, err := clientCtx.TxConfig.TxJSONEncoder()(newTestTx(t, clientCtx, sdk.NewCoins(sdk.NewInt64Coin("uatom", tt.fee)), 300000, msgs...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			file := filepath.Join(dir, "tx.json")
+			if err := os.WriteFile(file, txJSON, 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			out := filepath.Join(t.TempDir(), "parts.json")
+			cmd := GetSplitCommand()
+			cmd.SetContext(context.WithValue(context.Background(), client.ClientContextKey, &clientCtx))
+			cmd.SetArgs(append([]string{file, "--" + flagPluginsDir, t.TempDir(), "--" + flags.FlagOutputDocument, out}, tt.args...))
+			cmd.SilenceUsage, cmd.SilenceErrors = true, true
+			err = cmd.Execute()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			case tt.wantErr != "":
+				// no part is written
+				if _, err := os.Stat(filepath.Join(dir, "tx.part1.json")); err == nil {
+					t.Fatal("got a part despite the error")
+				}
+				return
+			}
+
+			bz, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var parts []splitPart
+			if err := json.Unmarshal(bz, &parts); err != nil {
+				t.Fatal(err)
+			}
+			if len(parts) != len(tt.wantParts) {
+				t.Fatalf("got %d parts, want %d", len(parts), len(tt.wantParts))
+			}
+			for i, part := range parts {
+				want := tt.wantParts[i]
+				if part.Msgs != want.Msgs || !part.Fee.Equal(want.Fee) || part.Gas != want.Gas || part.Sequence != want.Sequence {
+					t.Fatalf("got part %+v, want %+v", part, want)
+				}
+				tx, err := readTxFile(clientCtx, t.TempDir(), part.File)
+				if err != nil {
+					t.Fatal(err)
+				}
+				feeTx := tx.(sdk.FeeTx)
+				if len(tx.GetMsgs()) != want.Msgs || !feeTx.GetFee().Equal(want.Fee) || feeTx.GetGas() != want.Gas {
+					t.Fatalf("got part %s with %d messages, fee %s and gas %d, want %+v", part.File, len(tx.GetMsgs()), feeTx.GetFee(), feeTx.GetGas(), want)
+				}
+			}
+		})
+	}
+}
@@ -2,8 +2,13 @@ package cli
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client/flags"
 )
 
 var (
@@ -64,24 +69,69 @@ func (w *FilterNullKeysJSON) FilterNullJSONKeys(data interface{}) interface{} {
 	}
 }
 
-func FilterNullJSONKeysFile(outputDoc string) {
-	w := NewFilterNullKeysJSON(nil)
-	if outputDoc != "" {
-		content, err := os.ReadFile(outputDoc)
-		if err != nil {
-			panic(err)
-		}
-		var data interface{}
-		if err := json.Unmarshal(content, &data); err != nil {
-			panic(err)
-		}
-		filteredData := w.FilterNullJSONKeys(data)
-		filteredBytes, err := json.Marshal(filteredData)
-		if err != nil {
-			panic(err)
-		}
-		if err := os.WriteFile(outputDoc, filteredBytes, 0644); err != nil {
-			panic(err)
-		}
+// filterNullJSONKeys filters out the null values of the default keys from
+// the JSON document bz.
+func filterNullJSONKeys(bz []byte) ([]byte, error) {
+	var data interface{}
+	if err := json.Unmarshal(bz, &data); err != nil {
+		return nil, err
+	}
+	return json.Marshal(NewFilterNullKeysJSON(nil).FilterNullJSONKeys(data))
+}
+
+// FilterNullJSONKeysFile filters out the null values of the default keys
+// from the JSON document in outputDoc, if set.
+func FilterNullJSONKeysFile(outputDoc string) error {
+	if outputDoc == "" {
+		return nil
+	}
+	content, err := os.ReadFile(outputDoc)
+	if err != nil {
+		return err
+	}
+	filteredBytes, err := filterNullJSONKeys(content)
+	if err != nil {
+		return fmt.Errorf("filter %s: %w", outputDoc, err)
+	}
+	return os.WriteFile(outputDoc, filteredBytes, 0644)
+}
+
+// rawOutputAnnotation is the annotation of the commands whose output is not
+// JSON, and so is not filtered.
+const rawOutputAnnotation = "cosmos-signer/raw-output"
+
+// FilterOutput sets the output of cmd to filter out the null values of the
+// default keys from its JSON output, unless its output is not JSON.
+func FilterOutput(cmd *cobra.Command) {
+	if _, ok := cmd.Annotations[rawOutputAnnotation]; ok {
+		return
+	}
+	cmd.SetOut(NewFilterNullKeysJSON(cmd.OutOrStdout()))
+}
+
+// FilterOutputDocument filters out the null values of the default keys from
+// the --output-document of cmd, unless its output is not JSON.
+func FilterOutputDocument(cmd *cobra.Command) error {
+	if _, ok := cmd.Annotations[rawOutputAnnotation]; ok {
+		return nil
+	}
+	outputDoc, err := cmd.Flags().GetString(flags.FlagOutputDocument)
+	if err != nil {
+		return err
+	}
+	return FilterNullJSONKeysFile(outputDoc)
+}
+
+// printOutput prints bz to the document set with the --output-document flag
+// or to the command output if the flag is not set.
+func printOutput(cmd *cobra.Command, bz []byte) error {
+	outputDoc, err := cmd.Flags().GetString(flags.FlagOutputDocument)
+	if err != nil {
+		return err
+	}
+	if outputDoc == "" {
+		cmd.Printf("%s\n", bz)
+		return nil
 	}
+	return os.WriteFile(outputDoc, append(bz, '\n'), 0o644)
 }
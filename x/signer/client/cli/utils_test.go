@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client/flags"
+)
+
+func TestFilterOutputDocument(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     bool
+		doc     string
+		want    string
+		wantErr bool
+	}{
+		{name: "null tip filtered", doc: `{"body":{"memo":"m"},"tip":null}`, want: `{"body":{"memo":"m"}}`},
+		{name: "tip kept", doc: `{"tip":{"tipper":"a"}}`, want: `{"tip":{"tipper":"a"}}`},
+		{name: "raw output", raw: true, doc: "EhM=\n", want: "EhM=\n"},
+		{name: "not JSON", doc: "EhM=\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "out")
+			if err := os.WriteFile(file, []byte(tt.doc), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			cmd := &cobra.Command{}
+			if tt.raw {
+				cmd.Annotations = map[string]string{rawOutputAnnotation: "true"}
+			}
+			cmd.Flags().String(flags.FlagOutputDocument, file, "")
+
+			err := FilterOutputDocument(cmd)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			bz, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(bz) != tt.want {
+				t.Fatalf("got %q, want %q", bz, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,427 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/client/input"
+)
+
+const (
+	flagInbox          = "inbox"
+	flagOutbox         = "outbox"
+	flagArchive        = "archive"
+	flagNonInteractive = "non-interactive"
+	flagOnce           = "once"
+
+	defaultWatchInterval = 2 * time.Second
+
+	watchStatusSigned   = "signed"
+	watchStatusDeclined = "declined"
+	watchStatusFailed   = "failed"
+)
+
+// watchOnlyFlags are the flags of the watch command that are not passed on
+// to the signature of every file.
+var watchOnlyFlags = []string{
+	flagInbox, flagOutbox, flagArchive, flagNonInteractive, flagOnce, flagInterval,
+	flagBundle, flagEnvelope, flags.FlagOutputDocument,
+}
+
+var errDeclined = errors.New("declined by the operator")
+
+// WatchStatus is the status file written to the archive next to every
+// processed input file.
+type WatchStatus struct {
+	File   string    `json:"file"`
+	Status string    `json:"status"`
+	Output string    `json:"output,omitempty"`
+	Error  string    `json:"error,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// fileState is the size and modification time of an inbox file, files are
+// only processed once they did not change between two polls.
+type fileState struct {
+	Size    int64
+	ModTime time.Time
+}
+
+// watcher signs the files dropped in the inbox.
+type watcher struct {
+	cmd         *cobra.Command
+	clientCtx   client.Context
+	inbox       string
+	outbox      string
+	archive     string
+	interactive bool
+	stdin       *bufio.Reader
+	seen        map[string]fileState
+}
+
+// inboxFiles returns the names of the files of the inbox, skipping hidden
+// and partial files.
+func inboxFiles(dir string) ([]os.DirEntry, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []os.DirEntry
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || strings.HasPrefix(name, ".") ||
+			strings.HasSuffix(name, ".tmp") || strings.HasSuffix(name, ".part") {
+			continue
+		}
+		files = append(files, entry)
+	}
+	return files, nil
+}
+
+// poll processes the files of the inbox. Unless all is set, only the files
+// that did not change since the previous poll are processed, so that files
+// still being copied are left alone.
+func (w *watcher) poll(all bool) error {
+	files, err := inboxFiles(w.inbox)
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]fileState, len(files))
+	for _, file := range files {
+		info, err := file.Info()
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		state := fileState{Size: info.Size(), ModTime: info.ModTime()}
+		if prev, ok := w.seen[file.Name()]; !all && (!ok || prev != state) {
+			seen[file.Name()] = state
+			continue
+		}
+		if err := w.process(file.Name()); err != nil {
+			return err
+		}
+	}
+	w.seen = seen
+	return nil
+}
+
+// process signs an inbox file, and moves it to the archive along with its
+// status file. Only the errors of the hot folder itself are returned, the
+// errors of the signature are reported in the status file.
+func (w *watcher) process(name string) error {
+	status := WatchStatus{File: name}
+	output, err := w.sign(name)
+	switch {
+	case errors.Is(err, errDeclined):
+		status.Status = watchStatusDeclined
+	case err != nil:
+		status.Status = watchStatusFailed
+		status.Error = err.Error()
+	default:
+		status.Status = watchStatusSigned
+		status.Output = output
+	}
+	status.Time = time.Now().UTC()
+
+	archived, err := uniquePath(w.archive, name)
+	if err != nil {
+		return err
+	}
+	if err := moveFile(filepath.Join(w.inbox, name), archived); err != nil {
+		return err
+	}
+	bz, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(archived+".status.json", append(bz, '\n'), 0o644); err != nil {
+		return err
+	}
+
+	if status.Error != "" {
+		w.cmd.PrintErrf("%s: %s: %s\n", name, status.Status, status.Error)
+	} else {
+		w.cmd.PrintErrf("%s: %s\n", name, status.Status)
+	}
+	return nil
+}
+
+// sign signs an inbox file, a transaction, a signing bundle or an envelope,
+// and returns the name of the output written to the outbox.
+func (w *watcher) sign(name string) (string, error) {
+	file := filepath.Join(w.inbox, name)
+	bz, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	signCmd, err := w.signCommand()
+	if err != nil {
+		return "", err
+	}
+	var args []string
+	switch {
+	case isEnvelope(bz):
+		err = signCmd.Flags().Set(flagEnvelope, file)
+	case isSigningBundle(bz):
+		err = signCmd.Flags().Set(flagBundle, file)
+	default:
+		args = []string{file}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	in, err := readSignInput(w.clientCtx, signCmd, args)
+	if err != nil {
+		return "", err
+	}
+	if w.interactive {
+		if err := w.confirm(signCmd, name, in); err != nil {
+			return "", err
+		}
+	}
+
+	// the output is written aside and moved to the outbox once complete
+	output, err := uniquePath(w.outbox, strings.TrimSuffix(name, filepath.Ext(name))+".signed.json")
+	if err != nil {
+		return "", err
+	}
+	tmp := filepath.Join(w.outbox, "."+filepath.Base(output)+".tmp")
+	if err := signCmd.Flags().Set(flags.FlagOutputDocument, tmp); err != nil {
+		return "", err
+	}
+	if err := in.sign(signCmd); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	// the signed output is kept aside if it cannot be filtered
+	if err := FilterNullJSONKeysFile(tmp); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, output); err != nil {
+		return "", err
+	}
+	return filepath.Base(output), nil
+}
+
+// confirm shows the review of the transaction and asks the operator to
+// confirm its signature.
+func (w *watcher) confirm(signCmd *cobra.Command, name string, in *signInput) error {
+	book, err := LoadAddressBook(addressBookPath(in.ClientCtx.HomeDir))
+	if err != nil {
+		return err
+	}
+	review, err := newTxReview(in.ClientCtx, signCmd, in.Tx, book, in.Snapshot)
+	if err != nil {
+		return err
+	}
+	w.cmd.PrintErrf("\n%s\n%s\n", name, review)
+	ok, err := input.GetConfirmation(fmt.Sprintf("Sign %s?", name), w.stdin, w.cmd.ErrOrStderr())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errDeclined
+	}
+	return nil
+}
+
+// signCommand returns a sign command with the flags set on the watch
+// command, offline.
+func (w *watcher) signCommand() (*cobra.Command, error) {
+	signCmd := GetSignCommand()
+	signCmd.SetContext(w.cmd.Context())
+	signCmd.SetIn(w.cmd.InOrStdin())
+	signCmd.SetOut(w.cmd.ErrOrStderr())
+	signCmd.SetErr(w.cmd.ErrOrStderr())
+
+	var err error
+	w.cmd.Flags().Visit(func(f *pflag.Flag) {
+		target := signCmd.Flags().Lookup(f.Name)
+		if err != nil || target == nil || slices.Contains(watchOnlyFlags, f.Name) {
+			return
+		}
+		if src, ok := f.Value.(pflag.SliceValue); ok {
+			err = target.Value.(pflag.SliceValue).Replace(src.GetSlice())
+		} else {
+			err = target.Value.Set(f.Value.String())
+		}
+		target.Changed = true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return signCmd, signCmd.Flags().Set(flags.FlagOffline, "true")
+}
+
+// uniquePath returns the path of name in dir, suffixed with a number if a
+// file with that name exists already.
+func uniquePath(dir, name string) (string, error) {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return path, nil
+		} else if err != nil {
+			return "", err
+		}
+		name = fmt.Sprintf("%s.%d%s", base, i, ext)
+	}
+}
+
+// moveFile moves a file, copying it if it cannot be renamed, e.g. from a
+// removable drive.
+func moveFile(from, to string) error {
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(from)
+}
+
+// GetWatchCommand returns the watch command.
+func GetWatchCommand() *cobra.Command {
+	cmd := GetSignCommand()
+	cmd.Use = "watch"
+	cmd.Short = "Sign the transactions dropped in an inbox directory"
+	cmd.Long = `Watch the --inbox directory, e.g. on a mounted removable drive, and sign the
+files dropped in it: unsigned transactions, signing bundles produced by
+'tx prepare' or envelopes, see 'envelope --help'. Files are picked up once
+they did not change for --interval, hidden files and files ending in .tmp or
+.part are ignored.
+
+Every transaction is reviewed as with 'tx review' and signed once confirmed
+by the operator, or, with --non-interactive, signed if the signing policy
+allows it. The signing policy, the chain profiles, the approvals, the
+sequence journal and the hooks apply as with 'tx sign', whose flags are
+passed on to the signature of every file, see 'tx sign --help'. Signing is
+offline.
+
+The output of every signature is written to the --outbox directory as
+<name>.signed.json, and the input file is moved to the --archive directory
+along with a <name>.status.json status file:
+
+{"file": "tx.json", "status": "signed", "output": "tx.signed.json", "time": "..."}
+
+where the status is signed, declined or failed, with the error. With --once,
+the files of the inbox are processed and the command exits.
+`
+	cmd.Args = cobra.NoArgs
+	cmd.PreRun = nil
+	cmd.RunE = func(cmd *cobra.Command, _ []string) error {
+		clientCtx, err := client.GetClientTxContext(cmd)
+		if err != nil {
+			return err
+		}
+		f := cmd.Flags()
+		w := &watcher{cmd: cmd, clientCtx: clientCtx.WithOffline(true), stdin: bufio.NewReader(cmd.InOrStdin())}
+		if w.inbox, err = f.GetString(flagInbox); err != nil {
+			return err
+		}
+		if w.outbox, err = f.GetString(flagOutbox); err != nil {
+			return err
+		}
+		if w.archive, err = f.GetString(flagArchive); err != nil {
+			return err
+		}
+		nonInteractive, err := f.GetBool(flagNonInteractive)
+		if err != nil {
+			return err
+		}
+		w.interactive = !nonInteractive
+		once, err := f.GetBool(flagOnce)
+		if err != nil {
+			return err
+		}
+		interval, err := f.GetDuration(flagInterval)
+		if err != nil {
+			return err
+		}
+		if interval <= 0 {
+			return fmt.Errorf("invalid --%s %s", flagInterval, interval)
+		}
+
+		// unattended signatures are only made under a policy
+		if nonInteractive {
+			policy, err := loadPolicyFromFlags(clientCtx, cmd)
+			if err != nil {
+				return err
+			}
+			if policy == nil {
+				return fmt.Errorf("--%s requires a signing policy, see 'policy --help'", flagNonInteractive)
+			}
+		}
+		if _, err := os.Stat(w.inbox); err != nil {
+			return err
+		}
+		for _, dir := range []string{w.outbox, w.archive} {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return err
+			}
+		}
+
+		if once {
+			return w.poll(true)
+		}
+		cmd.PrintErrf("Watching %s\n", w.inbox)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := w.poll(false); err != nil {
+				return err
+			}
+			select {
+			case <-cmd.Context().Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	}
+
+	cmd.Flags().String(flagInbox, "", "The directory to watch for files to sign")
+	cmd.Flags().String(flagOutbox, "", "The directory the signed outputs are written to")
+	cmd.Flags().String(flagArchive, "", "The directory the processed files and their status files are moved to")
+	cmd.Flags().Bool(flagNonInteractive, false, "Sign without confirmation the transactions allowed by the signing policy")
+	cmd.Flags().Bool(flagOnce, false, "Process the files of the inbox and exit")
+	cmd.Flags().Duration(flagInterval, defaultWatchInterval, "How often the inbox is polled")
+	for _, name := range []string{flagBundle, flagEnvelope, flags.FlagOutputDocument} {
+		_ = cmd.Flags().MarkHidden(name)
+	}
+	for _, name := range []string{flagInbox, flagOutbox, flagArchive, flagPluginsDir} {
+		_ = cmd.MarkFlagRequired(name)
+	}
+
+	return cmd
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+)
+
+func TestWatchOnce(t *testing.T) {
+	clientCtx := newTestClientCtx(t)
+	alice := newTestKey(t, clientCtx, "alice")
+	bob := newTestKey(t, clientCtx, "bob")
+	sendJSON := func(amount int64) string {
+		bz, err := clientCtx.TxConfig.TxJSONEncoder()(newTestTx(t, clientCtx, nil, 200000, newTestSend(alice, bob, amount)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(bz)
+	}
+
+	tests := []struct {
+		name       string
+		files      map[string]string
+		args       []string
+		stdin      string
+		wantStatus map[string]string
+		wantError  string
+	}{
+		{
+			name:       "non-interactive",
+			files:      map[string]string{"a.json": sendJSON(10), "b.json": "not a transaction", ".c.json": sendJSON(11), "d.json.part": sendJSON(12)},
+			args:       []string{"--" + flagNonInteractive},
+			wantStatus: map[string]string{"a.json": watchStatusSigned, "b.json": watchStatusFailed},
+		},
+		{
+			name:       "interactive",
+			files:      map[string]string{"a.json": sendJSON(10), "b.json": sendJSON(11)},
+			stdin:      "y\nn\n",
+			wantStatus: map[string]string{"a.json": watchStatusSigned, "b.json": watchStatusDeclined},
+		},
+		{
+			name:       "conflicting sequence",
+			files:      map[string]string{"a.json": sendJSON(10), "b.json": sendJSON(11)},
+			args:       []string{"--" + flagNonInteractive},
+			wantStatus: map[string]string{"a.json": watchStatusSigned, "b.json": watchStatusFailed},
+			wantError:  "sequence 5 of account " + alice.String() + " on chain test-chain was already used",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientCtx := clientCtx.WithHomeDir(t.TempDir())
+			policyFile := filepath.Join(clientCtx.HomeDir, "config", defaultPolicyFile)
+			if err := os.MkdirAll(filepath.Dir(policyFile), 0o700); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(policyFile, []byte(`{"rules": []}`), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			dir := t.TempDir()
+			inbox, outbox, archive := filepath.Join(dir, "inbox"), filepath.Join(dir, "outbox"), filepath.Join(dir, "archive")
+			if err := os.Mkdir(inbox, 0o700); err != nil {
+				t.Fatal(err)
+			}
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(inbox, name), []byte(content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cmd := GetWatchCommand()
+			cmd.SetContext(context.WithValue(context.Background(), client.ClientContextKey, &clientCtx))
+			cmd.SetIn(strings.NewReader(tt.stdin))
+			cmd.SetArgs(append([]string{
+				"--" + flagInbox, inbox, "--" + flagOutbox, outbox, "--" + flagArchive, archive, "--" + flagOnce,
+				"--" + flagPluginsDir, t.TempDir(), "--" + flags.FlagFrom, "alice", "-a", "1", "-s", "5",
+			}, tt.args...))
+			cmd.SilenceUsage, cmd.SilenceErrors = true, true
+			if err := cmd.Execute(); err != nil {
+				t.Fatal(err)
+			}
+
+			for name := range tt.files {
+				_, err := os.Stat(filepath.Join(inbox, name))
+				if _, processed := tt.wantStatus[name]; processed == (err == nil) {
+					t.Fatalf("got %s in the inbox %t, want %t", name, err == nil, !processed)
+				}
+			}
+			for name, want := range tt.wantStatus {
+				bz, err := os.ReadFile(filepath.Join(archive, name+".status.json"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				var status WatchStatus
+				if err := json.Unmarshal(bz, &status); err != nil {
+					t.Fatal(err)
+				}
+				if status.Status != want {
+					t.Fatalf("got status %s of %s, want %s: %s", status.Status, name, want, status.Error)
+				}
+				if want == watchStatusFailed && !strings.Contains(status.Error, tt.wantError) {
+					t.Fatalf("got error %q of %s, want %q", status.Error, name, tt.wantError)
+				}
+				if want != watchStatusSigned {
+					continue
+				}
+				bz, err = os.ReadFile(filepath.Join(outbox, status.Output))
+				if err != nil {
+					t.Fatal(err)
+				}
+				verifyTestSignatures(t, clientCtx, bz, 1)
+			}
+		})
+	}
+}